@@ -1,18 +1,32 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/lskeey/go-filehub/config"
+	"github.com/lskeey/go-filehub/internal/clamd"
 	"github.com/lskeey/go-filehub/internal/database"
+	"github.com/lskeey/go-filehub/internal/davfs"
+	"github.com/lskeey/go-filehub/internal/grpcapi"
 	"github.com/lskeey/go-filehub/internal/handler"
 	"github.com/lskeey/go-filehub/internal/middleware"
 	"github.com/lskeey/go-filehub/internal/repository"
 	"github.com/lskeey/go-filehub/internal/service"
+	"github.com/lskeey/go-filehub/internal/storage"
+	filehubv1 "github.com/lskeey/go-filehub/proto/filehub/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 
 	docs "github.com/lskeey/go-filehub/docs"
 	swaggerfiles "github.com/swaggo/files"
@@ -49,32 +63,147 @@ func main() {
 	database.Connect(cfg)
 	db := database.DB
 
-	// 3. Initialize Repositories
+	// 3. Initialize Storage Backend
+	store, err := storage.New(cfg)
+	if err != nil {
+		log.Fatalf("could not initialize storage: %v", err)
+	}
+
+	// 4. Initialize Repositories
 	userRepo := repository.NewUserRepository(db)
 	fileRepo := repository.NewFileRepository(db)
+	uploadRepo := repository.NewUploadRepository(db)
+	blobRepo := repository.NewBlobRepository(db)
+	folderRepo := repository.NewFolderRepository(db)
+	shareRepo := repository.NewShareRepository(db)
+	linkRepo := repository.NewLinkRepository(db)
+	signedURLRepo := repository.NewSignedURLRepository(db)
+	contentRepo := repository.NewContentRepository(db)
+	jobRepo := repository.NewJobRepository(db)
+	scanRepo := repository.NewScanRepository(db)
+	thumbnailRepo := repository.NewThumbnailRepository(db)
+	appPasswordRepo := repository.NewAppPasswordRepository(db)
+	accessKeyRepo := repository.NewAccessKeyRepository(db)
+	multipartRepo := repository.NewMultipartRepository(db)
 
-	// 4. Initialize Services
+	// 5. Initialize Services
 	authService := service.NewAuthService(userRepo, cfg)
-	fileService := service.NewFileService(fileRepo)
+	blobService := service.NewBlobService(blobRepo, store)
+	quotaService := service.NewQuotaService(userRepo, cfg.DefaultQuotaBytes)
+	shareService := service.NewShareService(shareRepo, userRepo, fileRepo, folderRepo)
+	jobQueue := service.NewJobQueue(jobRepo)
+	var scanner *clamd.Client
+	if cfg.ClamdAddress != "" {
+		scanner = clamd.NewClient(cfg.ClamdAddress, time.Duration(cfg.ClamdTimeoutSeconds)*time.Second)
+	}
+	scanService := service.NewScanService(scanRepo, fileRepo, blobService, jobQueue, scanner)
+	uploadPolicy := service.NewUploadPolicy(cfg.UploadAllowedTypes, cfg.UploadDeniedTypes, cfg.UploadAllowedExtensions, cfg.UploadDeniedExtensions)
+	fileService := service.NewFileService(fileRepo, folderRepo, blobService, quotaService, shareService, scanService, uploadPolicy, cfg.MaxUploadSize)
+	searchService := service.NewContentSearchService(contentRepo, blobService)
+	thumbnailService := service.NewThumbnailService(thumbnailRepo, blobService, scanService, store)
+	linkService := service.NewLinkService(linkRepo, fileRepo, folderRepo)
+	folderService := service.NewFolderService(folderRepo, fileRepo, fileService)
+	trashRetention := time.Duration(cfg.TrashRetentionDays) * 24 * time.Hour
+	trashService := service.NewTrashService(fileRepo, folderRepo, fileService, trashRetention)
+	signedURLMaxTTL := time.Duration(cfg.SignedURLMaxTTLMinutes) * time.Minute
+	signedURLService, err := service.NewSignedURLService(signedURLRepo, cfg.SignedURLKeys, signedURLMaxTTL)
+	if err != nil {
+		log.Fatalf("could not initialize signed URLs: %v", err)
+	}
+	uploadService, err := service.NewUploadService(uploadRepo, fileService, cfg.UploadTempDir)
+	if err != nil {
+		log.Fatalf("could not initialize upload service: %v", err)
+	}
+	extractService, err := service.NewExtractService(fileService, folderService, cfg.UploadTempDir, service.ExtractLimits{
+		MaxEntries: cfg.ExtractMaxEntries,
+		MaxSize:    cfg.ExtractMaxSize,
+		MaxRatio:   cfg.ExtractMaxRatio,
+	})
+	if err != nil {
+		log.Fatalf("could not initialize extract service: %v", err)
+	}
+
+	appPasswordService := service.NewAppPasswordService(appPasswordRepo, userRepo)
+	accessKeyService := service.NewAccessKeyService(accessKeyRepo)
+	s3Service, err := service.NewS3Service(fileService, folderService, multipartRepo, cfg.UploadTempDir)
+	if err != nil {
+		log.Fatalf("could not initialize S3 service: %v", err)
+	}
 
-	// 5. Initialize Handlers
+	// 6. Initialize Handlers
 	authHandler := handler.NewAuthHandler(authService)
-	fileHandler := handler.NewFileHandler(fileService)
+	fileHandler := handler.NewFileHandler(fileService, extractService)
+	uploadHandler := handler.NewUploadHandler(uploadService)
+	folderHandler := handler.NewFolderHandler(folderService)
+	trashHandler := handler.NewTrashHandler(trashService)
+	userHandler := handler.NewUserHandler(quotaService)
+	appPasswordHandler := handler.NewAppPasswordHandler(appPasswordService)
+	accessKeyHandler := handler.NewAccessKeyHandler(accessKeyService)
+	s3Handler := handler.NewS3Handler(s3Service, accessKeyService, cfg.S3GatewayRegion)
+	webDAVHandler := handler.NewWebDAVHandler(appPasswordService, davfs.New(fileService, folderService), "/webdav")
+	shareHandler := handler.NewShareHandler(shareService)
+	linkHandler := handler.NewLinkHandler(linkService, fileService)
+	signedURLHandler := handler.NewSignedURLHandler(signedURLService, fileService)
+	searchHandler := handler.NewSearchHandler(searchService)
+	thumbnailHandler := handler.NewThumbnailHandler(thumbnailService, fileService)
+
+	// 7. Register Background Jobs
+	service.RegisterJob(jobQueue, service.JobPurgeTrash, trashService.PurgeJob)
+	service.RegisterJob(jobQueue, service.JobReconcileUsage, quotaService.ReconcileJob)
+	service.RegisterJob(jobQueue, service.JobIndexContent, searchService.IndexJob)
+	service.RegisterJob(jobQueue, service.JobGenerateThumbnails, thumbnailService.ThumbnailJob)
+	service.RegisterJob(jobQueue, service.JobScanFile, scanService.ScanFileJob)
+	service.RegisterJob(jobQueue, service.JobScanPending, scanService.ScanPendingJob)
+	service.RegisterJob(jobQueue, service.JobExpireMultipart, s3Service.ExpireMultipartJob)
+	service.RegisterJob(jobQueue, service.JobExpireUploads, uploadService.ExpireJob)
+	service.RegisterJob(jobQueue, service.JobPurgeNonces, signedURLService.PurgeNoncesJob)
+
+	purgeInterval := time.Duration(cfg.TrashPurgeIntervalMinutes) * time.Minute
+	if purgeInterval <= 0 {
+		purgeInterval = time.Hour
+	}
+	jobQueue.Schedule(service.JobPurgeTrash, purgeInterval)
+
+	reconcileInterval := time.Duration(cfg.QuotaReconcileIntervalHours) * time.Hour
+	if reconcileInterval <= 0 {
+		reconcileInterval = 24 * time.Hour
+	}
+	jobQueue.Schedule(service.JobReconcileUsage, reconcileInterval)
+
+	indexInterval := time.Duration(cfg.IndexIntervalSeconds) * time.Second
+	if indexInterval <= 0 {
+		indexInterval = 30 * time.Second
+	}
+	jobQueue.Schedule(service.JobIndexContent, indexInterval)
+
+	thumbnailInterval := time.Duration(cfg.ThumbnailIntervalSeconds) * time.Second
+	if thumbnailInterval <= 0 {
+		thumbnailInterval = 30 * time.Second
+	}
+	jobQueue.Schedule(service.JobGenerateThumbnails, thumbnailInterval)
 
-	// 6. Initialize Gin Server
+	jobQueue.Schedule(service.JobExpireMultipart, 24*time.Hour)
+	jobQueue.Schedule(service.JobExpireUploads, time.Hour)
+	jobQueue.Schedule(service.JobPurgeNonces, time.Hour)
+
+	if scanService.Enabled() {
+		jobQueue.Schedule(service.JobScanPending, time.Minute)
+	}
+
+	// 8. Initialize Gin Server
 	r := gin.Default()
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://127.0.0.1:5500"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Authorization", "Content-Type"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "HEAD", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Authorization", "Content-Type", "Range", "If-Range", "If-None-Match", "If-Modified-Since", "Tus-Resumable", "Upload-Length", "Upload-Metadata", "Upload-Offset", "X-Link-Password"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Range", "Content-Disposition", "Accept-Ranges", "ETag", "Last-Modified", "Location", "X-Thumbnail-Placeholder", "Tus-Resumable", "Tus-Version", "Tus-Extension", "Upload-Length", "Upload-Offset", "X-File-ID", "X-Archive-Skipped"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
 	docs.SwaggerInfo.BasePath = "/api/v1"
 
-	// 7. Setup Routes
+	// 9. Setup Routes
 	api := r.Group("/api/v1")
 	{
 		// Auth routes
@@ -84,14 +213,112 @@ func main() {
 			auth.POST("/login", authHandler.Login)
 		}
 
+		// File downloads and thumbnails (protected by auth middleware or a signed URL)
+		signedDownload := signedURLHandler.SignedOrAuth(service.SignedRouteDownload, middleware.AuthMiddleware(cfg.JWTSecretKey))
+		signedThumbnail := signedURLHandler.SignedOrAuth(service.SignedRouteThumbnail, middleware.AuthMiddleware(cfg.JWTSecretKey))
+		api.GET("/files/:id/download", signedDownload, fileHandler.DownloadFile)
+		api.HEAD("/files/:id/download", signedDownload, fileHandler.DownloadFile)
+		api.GET("/files/:id/thumbnail", signedThumbnail, thumbnailHandler.GetThumbnail)
+
 		// File routes (protected by auth middleware)
 		files := api.Group("/files")
 		files.Use(middleware.AuthMiddleware(cfg.JWTSecretKey))
 		{
 			files.POST("/upload", fileHandler.UploadFile)
 			files.GET("", fileHandler.ListFiles)
-			files.GET("/:id/download", fileHandler.DownloadFile)
+			files.POST("/archive", fileHandler.DownloadArchive)
+			files.POST("/:id/signed-url", signedURLHandler.CreateSignedURL)
 			files.DELETE("/:id", fileHandler.DeleteFile)
+			files.PATCH("/:id/rename", fileHandler.RenameFile)
+			files.PATCH("/:id/move", fileHandler.MoveFile)
+			files.PATCH("/:id/metadata", fileHandler.UpdateMetadata)
+
+			// File versions
+			files.GET("/:id/versions", fileHandler.ListVersions)
+			files.POST("/:id/versions", fileHandler.UploadVersion)
+			files.POST("/:id/versions/prune", fileHandler.PruneVersions)
+			files.GET("/:id/versions/:version/download", fileHandler.DownloadVersion)
+			files.POST("/:id/versions/:version/restore", fileHandler.RestoreVersion)
+
+			// Resumable uploads (tus 1.0)
+			uploads := files.Group("/uploads")
+			uploads.Use(uploadHandler.TusResumable)
+			{
+				uploads.OPTIONS("", uploadHandler.Options)
+				uploads.POST("", uploadHandler.CreateUpload)
+				uploads.HEAD("/:uploadId", uploadHandler.GetUploadOffset)
+				uploads.PATCH("/:uploadId", uploadHandler.PatchUpload)
+				uploads.DELETE("/:uploadId", uploadHandler.TerminateUpload)
+			}
+		}
+
+		// Folder routes (protected by auth middleware)
+		folders := api.Group("/folders")
+		folders.Use(middleware.AuthMiddleware(cfg.JWTSecretKey))
+		{
+			folders.POST("", folderHandler.CreateFolder)
+			folders.GET("/resolve", folderHandler.ResolvePath)
+			folders.GET("/:id/children", folderHandler.ListChildren)
+			folders.PATCH("/:id/rename", folderHandler.RenameFolder)
+			folders.PATCH("/:id/move", folderHandler.MoveFolder)
+			folders.DELETE("/:id", folderHandler.DeleteFolder)
+		}
+
+		// Full-text search (protected by auth middleware)
+		api.GET("/search", middleware.AuthMiddleware(cfg.JWTSecretKey), searchHandler.Search)
+
+		// Share routes (protected by auth middleware)
+		shares := api.Group("/shares")
+		shares.Use(middleware.AuthMiddleware(cfg.JWTSecretKey))
+		{
+			shares.POST("", shareHandler.CreateShare)
+			shares.GET("", shareHandler.ListShares)
+			shares.GET("/with-me", shareHandler.ListSharedWithMe)
+			shares.DELETE("/:id", shareHandler.RevokeShare)
+		}
+
+		// Public link management (protected by auth middleware)
+		links := api.Group("/links")
+		links.Use(middleware.AuthMiddleware(cfg.JWTSecretKey))
+		{
+			links.POST("", linkHandler.CreateLink)
+			links.GET("", linkHandler.ListLinks)
+			links.DELETE("/:id", linkHandler.RevokeLink)
+			links.GET("/:id/accesses", linkHandler.ListAccesses)
+		}
+
+		// Public link access (no account needed)
+		public := api.Group("/public")
+		{
+			public.GET("/links/:token", linkHandler.ViewLink)
+			public.GET("/links/:token/download", linkHandler.DownloadLink)
+			public.HEAD("/links/:token/download", linkHandler.DownloadLink)
+		}
+
+		// Current user routes (protected by auth middleware)
+		me := api.Group("/me")
+		me.Use(middleware.AuthMiddleware(cfg.JWTSecretKey))
+		{
+			me.GET("/usage", userHandler.GetUsage)
+			me.POST("/usage/reconcile", userHandler.ReconcileUsage)
+			me.POST("/app-passwords", appPasswordHandler.CreateAppPassword)
+			me.GET("/app-passwords", appPasswordHandler.ListAppPasswords)
+			me.DELETE("/app-passwords/:id", appPasswordHandler.DeleteAppPassword)
+			me.POST("/access-keys", accessKeyHandler.CreateAccessKey)
+			me.GET("/access-keys", accessKeyHandler.ListAccessKeys)
+			me.DELETE("/access-keys/:id", accessKeyHandler.DeleteAccessKey)
+		}
+
+		// Trash routes (protected by auth middleware)
+		trash := api.Group("/trash")
+		trash.Use(middleware.AuthMiddleware(cfg.JWTSecretKey))
+		{
+			trash.GET("", trashHandler.ListTrash)
+			trash.DELETE("", trashHandler.EmptyTrash)
+			trash.POST("/files/:id/restore", trashHandler.RestoreFile)
+			trash.DELETE("/files/:id", trashHandler.DeleteFile)
+			trash.POST("/folders/:id/restore", trashHandler.RestoreFolder)
+			trash.DELETE("/folders/:id", trashHandler.DeleteFolder)
 		}
 	}
 
@@ -101,10 +328,111 @@ func main() {
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
 
-	// 8. Run Server
+	// WebDAV (Basic auth with the account password or an app password)
+	dav := r.Group("/webdav", webDAVHandler.BasicAuth)
+	for _, method := range handler.WebDAVMethods {
+		dav.Handle(method, "", webDAVHandler.ServeDAV)
+		dav.Handle(method, "/*path", webDAVHandler.ServeDAV)
+	}
+
+	// S3-compatible API on its own port, since S3 clients expect buckets at
+	// the root of the URL (AWS Signature Version 4 with an access key)
+	var s3Server *http.Server
+	if cfg.S3GatewayPort != "" {
+		s3 := gin.Default()
+		s3.RedirectTrailingSlash = false
+		s3.RedirectFixedPath = false
+		for _, method := range handler.S3Methods {
+			s3.Handle(method, "/*path", s3Handler.Authenticate, s3Handler.Serve)
+		}
+		s3Server = &http.Server{Addr: fmt.Sprintf(":%s", cfg.S3GatewayPort), Handler: s3}
+	}
+
+	// gRPC API on its own port (JWT in the "authorization" metadata)
+	var grpcServer *grpc.Server
+	if cfg.GRPCPort != "" {
+		grpcServer = grpc.NewServer(
+			grpc.UnaryInterceptor(grpcapi.UnaryAuthInterceptor(cfg.JWTSecretKey)),
+			grpc.StreamInterceptor(grpcapi.StreamAuthInterceptor(cfg.JWTSecretKey)),
+		)
+		filehubv1.RegisterFileHubServer(grpcServer, grpcapi.NewServer(authService, fileService))
+		reflection.Register(grpcServer)
+	}
+
+	// 10. Run Server and Job Workers
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTimeout := time.Duration(cfg.ShutdownTimeoutSeconds) * time.Second
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = 30 * time.Second
+	}
+
+	workersDone := make(chan struct{})
+	go func() {
+		defer close(workersDone)
+		jobQueue.Run(ctx, cfg.JobWorkers, shutdownTimeout)
+	}()
+
 	serverAddr := fmt.Sprintf(":%s", cfg.AppPort)
-	log.Printf("Server is running at %s", serverAddr)
-	if err := r.Run(serverAddr); err != nil {
-		log.Fatalf("Failed to run server: %v", err)
+	server := &http.Server{Addr: serverAddr, Handler: r}
+	go func() {
+		log.Printf("Server is running at %s", serverAddr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to run server: %v", err)
+		}
+	}()
+
+	if s3Server != nil {
+		go func() {
+			log.Printf("S3 gateway is running at %s", s3Server.Addr)
+			if err := s3Server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatalf("Failed to run S3 gateway: %v", err)
+			}
+		}()
+	}
+
+	if grpcServer != nil {
+		grpcAddr := fmt.Sprintf(":%s", cfg.GRPCPort)
+		listener, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			log.Fatalf("Failed to listen for gRPC: %v", err)
+		}
+		go func() {
+			log.Printf("gRPC server is running at %s", grpcAddr)
+			if err := grpcServer.Serve(listener); err != nil {
+				log.Fatalf("Failed to run gRPC server: %v", err)
+			}
+		}()
+	}
+
+	// 11. Shut Down Gracefully
+	<-ctx.Done()
+	log.Println("Shutting down...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server shutdown failed: %v", err)
+	}
+	if s3Server != nil {
+		if err := s3Server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("S3 gateway shutdown failed: %v", err)
+		}
+	}
+	if grpcServer != nil {
+		// GracefulStop waits for open streams, so bound it by the same deadline
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-shutdownCtx.Done():
+			grpcServer.Stop()
+		}
 	}
+	<-workersDone
+	log.Println("Server stopped")
 }
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// errNotLoggedIn is returned before calling an endpoint that needs a token
+// when there is none.
+var errNotLoggedIn = errors.New("not logged in, run: filehub login")
+
+// apiError is an error response of the API.
+type apiError struct {
+	Status  int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	if e.Status == http.StatusUnauthorized {
+		return e.Message + " (run: filehub login)"
+	}
+	return e.Message
+}
+
+// client calls the /api/v1 endpoints of a FileHub server.
+type client struct {
+	server string
+	token  string
+	http   *http.Client
+}
+
+func newClient(cfg *cliConfig) *client {
+	return &client{server: cfg.Server, token: cfg.serverToken(), http: http.DefaultClient}
+}
+
+// newRequest builds a request to an API path like "/files", authenticated
+// with the stored token.
+func (c *client) newRequest(method, path string, query url.Values, body io.Reader) (*http.Request, error) {
+	if c.token == "" {
+		return nil, errNotLoggedIn
+	}
+	req, err := c.newPublicRequest(method, path, query, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	return req, nil
+}
+
+// newPublicRequest builds a request that is sent without a token.
+func (c *client) newPublicRequest(method, path string, query url.Values, body io.Reader) (*http.Request, error) {
+	u := c.server + "/api/v1" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return http.NewRequest(method, u, body)
+}
+
+// do sends req and returns the response if it succeeded. Error responses
+// are returned as *apiError.
+func (c *client) do(req *http.Request) (*http.Response, error) {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Error string `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if json.Unmarshal(data, &body) != nil || body.Error == "" {
+		body.Error = fmt.Sprintf("server responded with %s", resp.Status)
+	}
+	return nil, &apiError{Status: resp.StatusCode, Message: body.Error}
+}
+
+// doJSON sends req and returns the raw JSON response body.
+func (c *client) doJSON(req *http.Request) (json.RawMessage, error) {
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// jsonBody encodes v as a request body.
+func jsonBody(v interface{}) (io.Reader, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(data), nil
+}
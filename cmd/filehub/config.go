@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultServer is used until a server is given to login.
+const defaultServer = "http://localhost:8080"
+
+// cliConfig is what login remembers between runs.
+type cliConfig struct {
+	Server      string `json:"server"`
+	Email       string `json:"email,omitempty"`
+	Token       string `json:"token,omitempty"`
+	TokenServer string `json:"token_server,omitempty"` // Server that issued Token
+
+	tokenFromEnv bool // Token is FILEHUB_TOKEN, meant for whatever server is used
+}
+
+// serverToken returns the token to send to Server. A stored token is only
+// sent to the server it was issued by, so it doesn't leak when --server
+// points somewhere else.
+func (cfg *cliConfig) serverToken() string {
+	if cfg.tokenFromEnv || cfg.TokenServer == cfg.Server {
+		return cfg.Token
+	}
+	return ""
+}
+
+// configPath returns the config file location: FILEHUB_CONFIG, or
+// filehub/config.json in the user's config directory.
+func configPath() (string, error) {
+	if path := os.Getenv("FILEHUB_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "filehub", "config.json"), nil
+}
+
+// loadConfig reads the config file, if there is one, and applies the
+// FILEHUB_SERVER and FILEHUB_TOKEN overrides.
+func loadConfig() (*cliConfig, error) {
+	cfg := &cliConfig{Server: defaultServer}
+
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+		// Files written before token_server hold the login server in server
+		if cfg.Token != "" && cfg.TokenServer == "" {
+			cfg.TokenServer = cfg.Server
+		}
+		// The token grants full access to the account
+		if info, err := os.Stat(path); err == nil && info.Mode().Perm()&0o077 != 0 {
+			fmt.Fprintf(os.Stderr, "warning: %s is accessible by other users, run chmod 600 on it\n", path)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, err
+	}
+
+	if server := os.Getenv("FILEHUB_SERVER"); server != "" {
+		cfg.Server = server
+	}
+	if token := os.Getenv("FILEHUB_TOKEN"); token != "" {
+		cfg.Token = token
+		cfg.tokenFromEnv = true
+	}
+	cfg.Server = strings.TrimRight(cfg.Server, "/")
+	cfg.TokenServer = strings.TrimRight(cfg.TokenServer, "/")
+	return cfg, nil
+}
+
+// saveConfig writes the config file readable only by the current user.
+// The file is replaced atomically, so a failed write never leaves a
+// truncated config behind.
+func saveConfig(cfg *cliConfig) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".config-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,420 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// fileInfo is the part of a file record the CLI shows. The API serializes
+// records with their Go field names.
+type fileInfo struct {
+	ID        uint
+	FileName  string
+	Size      int64
+	UpdatedAt time.Time
+}
+
+type folderInfo struct {
+	ID        uint
+	Name      string
+	UpdatedAt time.Time
+}
+
+// runList lists the user's files page by page, or the content of a folder.
+func runList(a *app, args []string) error {
+	fs := newFlagSet("ls", "ls [--folder ID] [-q TEXT] [--sort FIELD] [--order asc|desc] [--limit N] [--cursor CURSOR] [--all]")
+	folder := fs.String("folder", "", `List this folder ("root" for the top level) instead of all files`)
+	query := fs.String("q", "", "Only files whose name contains TEXT")
+	sort := fs.String("sort", "", "Sort by name, size or created_at (default)")
+	order := fs.String("order", "", "asc or desc (default)")
+	limit := fs.Int("limit", 0, "Files per page, 1-200 (default 50)")
+	cursor := fs.String("cursor", "", "Continue from the next_cursor of a previous page")
+	all := fs.Bool("all", false, "Fetch all pages")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usagef("ls takes no arguments")
+	}
+	if *folder != "" {
+		return listFolder(a, *folder)
+	}
+
+	q := url.Values{}
+	for key, value := range map[string]string{"q": *query, "sort": *sort, "order": *order} {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+	if *limit != 0 {
+		q.Set("limit", strconv.Itoa(*limit))
+	}
+
+	var page struct {
+		Data       []json.RawMessage `json:"data"`
+		NextCursor string            `json:"next_cursor"`
+	}
+	files := []json.RawMessage{}
+	next := *cursor
+	for {
+		if next != "" {
+			q.Set("cursor", next)
+		}
+		req, err := a.client.newRequest(http.MethodGet, "/files", q, nil)
+		if err != nil {
+			return err
+		}
+		data, err := a.client.doJSON(req)
+		if err != nil {
+			return err
+		}
+		page.Data, page.NextCursor = nil, ""
+		if err := json.Unmarshal(data, &page); err != nil {
+			return fmt.Errorf("unexpected response: %w", err)
+		}
+		files = append(files, page.Data...)
+		next = page.NextCursor
+		if !*all || next == "" {
+			break
+		}
+	}
+
+	if a.json {
+		return a.printValue(map[string]interface{}{"data": files, "next_cursor": next})
+	}
+	w := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSIZE\tMODIFIED\tNAME")
+	for _, raw := range files {
+		var f fileInfo
+		if err := json.Unmarshal(raw, &f); err != nil {
+			return fmt.Errorf("unexpected response: %w", err)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", f.ID, formatSize(f.Size), formatTime(f.UpdatedAt), f.FileName)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if next != "" {
+		fmt.Fprintf(os.Stderr, "More files: filehub ls --cursor %s (or --all)\n", next)
+	}
+	return nil
+}
+
+// listFolder lists the folders and files directly inside a folder.
+func listFolder(a *app, folder string) error {
+	req, err := a.client.newRequest(http.MethodGet, "/folders/"+url.PathEscape(folder)+"/children", nil, nil)
+	if err != nil {
+		return err
+	}
+	data, err := a.client.doJSON(req)
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.printJSON(data)
+	}
+
+	var resp struct {
+		Data struct {
+			Folders []folderInfo `json:"folders"`
+			Files   []fileInfo   `json:"files"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("unexpected response: %w", err)
+	}
+	w := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSIZE\tMODIFIED\tNAME")
+	for _, f := range resp.Data.Folders {
+		fmt.Fprintf(w, "%d\t-\t%s\t%s/\n", f.ID, formatTime(f.UpdatedAt), f.Name)
+	}
+	for _, f := range resp.Data.Files {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", f.ID, formatSize(f.Size), formatTime(f.UpdatedAt), f.FileName)
+	}
+	return w.Flush()
+}
+
+// runUpload uploads local files, stopping at the first failure.
+func runUpload(a *app, args []string) error {
+	fs := newFlagSet("upload", "upload [--folder ID] [--extract] FILE...")
+	folder := fs.Uint("folder", 0, "Upload into this folder instead of the root")
+	extract := fs.Bool("extract", false, "Expand ZIP and tar.gz archives into files and folders")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return usagef("upload needs at least one file")
+	}
+
+	q := url.Values{}
+	if *folder != 0 {
+		q.Set("folder_id", strconv.FormatUint(uint64(*folder), 10))
+	}
+	if *extract {
+		q.Set("extract", "true")
+	}
+	for _, path := range fs.Args() {
+		if err := uploadFile(a, path, q, *extract); err != nil {
+			// Errors opening the file already name it
+			var pathErr *os.PathError
+			if errors.As(err, &pathErr) {
+				return err
+			}
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func uploadFile(a *app, path string, q url.Values, extract bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("is a directory")
+	}
+
+	name := filepath.Base(path)
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	progress := newProgress(f, name, info.Size(), a.json)
+	defer progress.Close()
+
+	// Stream the multipart body instead of buffering the file
+	body, pw := io.Pipe()
+	defer body.Close()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": "file", "filename": name}))
+		header.Set("Content-Type", contentType)
+		part, err := mw.CreatePart(header)
+		if err == nil {
+			_, err = io.Copy(part, progress)
+		}
+		if err == nil {
+			err = mw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	req, err := a.client.newRequest(http.MethodPost, "/files/upload", q, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	data, err := a.client.doJSON(req)
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.printJSON(data)
+	}
+
+	if extract {
+		var resp struct {
+			Data struct {
+				Created, Updated, Folders, Skipped, Failed int
+				Aborted                                    string
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return fmt.Errorf("unexpected response: %w", err)
+		}
+		r := resp.Data
+		fmt.Fprintf(a.stdout, "Extracted %s: %d created, %d updated, %d folders, %d skipped, %d failed\n", name, r.Created, r.Updated, r.Folders, r.Skipped, r.Failed)
+		if r.Aborted != "" {
+			return fmt.Errorf("extraction stopped early: %s", r.Aborted)
+		}
+		if r.Failed > 0 {
+			return fmt.Errorf("%d entries failed, run with --json for details", r.Failed)
+		}
+		return nil
+	}
+
+	var resp struct {
+		Data struct {
+			fileInfo
+			Version int
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("unexpected response: %w", err)
+	}
+	fmt.Fprintf(a.stdout, "Uploaded %s (%s) as file %d, version %d\n", name, formatSize(resp.Data.Size), resp.Data.ID, resp.Data.Version)
+	return nil
+}
+
+// runDownload downloads files into the current directory, or as given by -o.
+func runDownload(a *app, args []string) error {
+	fs := newFlagSet("download", "download [-o PATH] ID...")
+	output := fs.String("o", "", `Save to PATH (a directory when downloading several files), or "-" for stdout`)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return usagef("download needs at least one file ID")
+	}
+	ids, err := parseIDs(fs.Args())
+	if err != nil {
+		return err
+	}
+	if len(ids) > 1 && *output == "-" {
+		return usagef("only a single file can be written to stdout")
+	}
+
+	for _, id := range ids {
+		if err := downloadFile(a, id, *output, len(ids) > 1); err != nil {
+			return fmt.Errorf("file %d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+func downloadFile(a *app, id uint64, output string, several bool) error {
+	req, err := a.client.newRequest(http.MethodGet, fmt.Sprintf("/files/%d/download", id), nil, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := a.client.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	name := fmt.Sprintf("file-%d", id)
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		// Never let the server pick a path outside the target directory
+		if base := filepath.Base(params["filename"]); base != "." && base != ".." && base != string(filepath.Separator) {
+			name = base
+		}
+	}
+	progress := newProgress(resp.Body, name, resp.ContentLength, a.json || output == "-")
+	defer progress.Close()
+
+	if output == "-" {
+		_, err := io.Copy(os.Stdout, progress)
+		return err
+	}
+
+	path := name
+	if output != "" {
+		path = output
+		if info, err := os.Stat(output); several || (err == nil && info.IsDir()) {
+			path = filepath.Join(output, name)
+		}
+	}
+
+	// Write next to the target and rename, so an interrupted download
+	// never leaves a truncated file under the final name
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.part")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	size, err := io.Copy(tmp, progress)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	if a.json {
+		return a.printValue(map[string]interface{}{"id": id, "path": path, "size": size})
+	}
+	fmt.Fprintf(a.stdout, "Downloaded file %d to %s (%s)\n", id, path, formatSize(size))
+	return nil
+}
+
+// runRemove moves files or folders to the trash.
+func runRemove(a *app, args []string) error {
+	fs := newFlagSet("rm", "rm [--folder [-r]] ID...")
+	folder := fs.Bool("folder", false, "The IDs are folders")
+	recursive := fs.Bool("r", false, "Delete non-empty folders with everything inside them")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return usagef("rm needs at least one ID")
+	}
+	if *recursive && !*folder {
+		return usagef("-r only applies to folders, add --folder")
+	}
+	ids, err := parseIDs(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	kind, q := "file", url.Values{}
+	if *folder {
+		kind = "folder"
+		if *recursive {
+			q.Set("recursive", "true")
+		}
+	}
+	for _, id := range ids {
+		req, err := a.client.newRequest(http.MethodDelete, fmt.Sprintf("/%ss/%d", kind, id), q, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := a.client.doJSON(req); err != nil {
+			return fmt.Errorf("%s %d: %w", kind, id, err)
+		}
+		if a.json {
+			if err := a.printValue(map[string]interface{}{"id": id, "type": kind}); err != nil {
+				return err
+			}
+			continue
+		}
+		fmt.Fprintf(a.stdout, "Moved %s %d to the trash\n", kind, id)
+	}
+	return nil
+}
+
+// parseIDs parses file or folder IDs given as arguments.
+func parseIDs(args []string) ([]uint64, error) {
+	ids := make([]uint64, len(args))
+	for i, arg := range args {
+		id, err := strconv.ParseUint(arg, 10, 32)
+		if err != nil || id == 0 {
+			return nil, usagef("invalid ID %q", arg)
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// runLogin exchanges an email and password for a token and stores it. The
+// password is never taken from the command line, where other users could
+// see it.
+func runLogin(a *app, args []string) error {
+	fs := newFlagSet("login", "login [--email EMAIL] [--password-stdin]")
+	email := fs.String("email", a.cfg.Email, "Account email (prompted for if not set)")
+	passwordStdin := fs.Bool("password-stdin", false, "Read the password from stdin instead of prompting")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usagef("login takes no arguments")
+	}
+
+	interactive := term.IsTerminal(int(os.Stdin.Fd()))
+	stdin := bufio.NewReader(os.Stdin)
+	if *email == "" {
+		if !interactive {
+			return usagef("--email is required when stdin is not a terminal")
+		}
+		fmt.Fprint(os.Stderr, "Email: ")
+		line, err := stdin.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		*email = strings.TrimSpace(line)
+	}
+
+	var password string
+	switch {
+	case *passwordStdin:
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return err
+		}
+		password = strings.TrimRight(string(data), "\r\n")
+	case interactive:
+		fmt.Fprint(os.Stderr, "Password: ")
+		data, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return err
+		}
+		password = string(data)
+	default:
+		return usagef("stdin is not a terminal, use --password-stdin")
+	}
+	if *email == "" || password == "" {
+		return usagef("email and password are required")
+	}
+
+	body, err := jsonBody(map[string]string{"email": *email, "password": password})
+	if err != nil {
+		return err
+	}
+	req, err := a.client.newPublicRequest(http.MethodPost, "/auth/login", nil, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	data, err := a.client.doJSON(req)
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil || resp.Token == "" {
+		return fmt.Errorf("unexpected login response from %s", a.cfg.Server)
+	}
+
+	a.cfg.Email = *email
+	a.cfg.Token = resp.Token
+	a.cfg.TokenServer = a.cfg.Server
+	if err := saveConfig(a.cfg); err != nil {
+		return fmt.Errorf("could not save the token: %w", err)
+	}
+
+	// The token itself is not printed, it stays in the config file
+	if a.json {
+		return a.printValue(map[string]string{"server": a.cfg.Server, "email": *email})
+	}
+	fmt.Fprintf(a.stdout, "Logged in to %s as %s\n", a.cfg.Server, *email)
+	return nil
+}
+
+// runLogout removes the stored token.
+func runLogout(a *app, args []string) error {
+	fs := newFlagSet("logout", "logout")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usagef("logout takes no arguments")
+	}
+
+	a.cfg.Token = ""
+	a.cfg.TokenServer = ""
+	if err := saveConfig(a.cfg); err != nil {
+		return err
+	}
+	if a.json {
+		return a.printValue(map[string]string{"server": a.cfg.Server})
+	}
+	fmt.Fprintf(a.stdout, "Logged out of %s\n", a.cfg.Server)
+	return nil
+}
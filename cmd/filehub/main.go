@@ -0,0 +1,233 @@
+// Command filehub is a command-line client for the FileHub REST API.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const usage = `Usage: filehub [--server URL] [--json] <command> [arguments]
+
+Commands:
+  login     Log in and store the token in the config file
+  logout    Forget the stored token
+  ls        List files, or the contents of a folder
+  upload    Upload files
+  download  Download files
+  rm        Move files or folders to the trash
+  share     Share a file or folder with a user, or create a public link
+
+Run "filehub <command> -h" for the options of a command.
+
+Options:
+  --server URL  Server to talk to (default: the one from login, or http://localhost:8080)
+  --json        Print results as JSON, one document per line, for scripts
+
+Environment:
+  FILEHUB_SERVER  Server to talk to
+  FILEHUB_TOKEN   Token to use instead of the stored one
+  FILEHUB_CONFIG  Config file (default: filehub/config.json in the user config directory)
+
+Exit codes:
+  0  Success
+  1  Error, e.g. a network or server failure
+  2  Invalid usage or arguments
+  3  Not logged in, or the login failed
+  4  Not found
+  5  Permission denied
+  6  Conflict, e.g. a name that is already taken
+  7  Upload rejected: too large, type not allowed or quota exceeded
+`
+
+// Exit codes, see usage.
+const (
+	exitOK        = 0
+	exitError     = 1
+	exitUsage     = 2
+	exitAuth      = 3
+	exitNotFound  = 4
+	exitForbidden = 5
+	exitConflict  = 6
+	exitRejected  = 7
+)
+
+// usageError reports invalid command-line arguments.
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+func usagef(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// app is the state shared by the commands.
+type app struct {
+	cfg    *cliConfig
+	client *client
+	json   bool
+	stdout io.Writer
+}
+
+var commands = map[string]func(a *app, args []string) error{
+	"login":    runLogin,
+	"logout":   runLogout,
+	"ls":       runList,
+	"upload":   runUpload,
+	"download": runDownload,
+	"rm":       runRemove,
+	"share":    runShare,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	fs := flag.NewFlagSet("filehub", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	server := fs.String("server", "", "")
+	jsonOutput := fs.Bool("json", false, "")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			fmt.Print(usage)
+			return exitOK
+		}
+		fmt.Fprintf(os.Stderr, "filehub: %v\n\n%s", err, usage)
+		return exitUsage
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return exitUsage
+	}
+	name := fs.Arg(0)
+	if name == "help" {
+		fmt.Print(usage)
+		return exitOK
+	}
+	command, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "filehub: unknown command %q\n\n%s", name, usage)
+		return exitUsage
+	}
+
+	a := &app{json: *jsonOutput, stdout: os.Stdout}
+	cfg, err := loadConfig()
+	if err != nil {
+		return a.fail(err)
+	}
+	if *server != "" {
+		cfg.Server = strings.TrimRight(*server, "/")
+	}
+	a.cfg = cfg
+	a.client = newClient(cfg)
+
+	err = command(a, fs.Args()[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		return a.fail(err)
+	}
+	return exitOK
+}
+
+// fail reports err on stderr, as JSON in JSON mode, and returns the exit
+// code for it.
+func (a *app) fail(err error) int {
+	code := exitCode(err)
+	if a.json {
+		report := struct {
+			Error  string `json:"error"`
+			Status int    `json:"status,omitempty"` // HTTP status of API errors
+		}{Error: err.Error()}
+		var apiErr *apiError
+		if errors.As(err, &apiErr) {
+			report.Status = apiErr.Status
+		}
+		data, _ := json.Marshal(report)
+		fmt.Fprintln(os.Stderr, string(data))
+	} else {
+		fmt.Fprintf(os.Stderr, "filehub: %v\n", err)
+	}
+	return code
+}
+
+// exitCode returns the process exit code for err.
+func exitCode(err error) int {
+	var usageErr *usageError
+	if errors.As(err, &usageErr) {
+		return exitUsage
+	}
+	if errors.Is(err, errNotLoggedIn) {
+		return exitAuth
+	}
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		return exitError
+	}
+	switch apiErr.Status {
+	case http.StatusBadRequest:
+		return exitUsage
+	case http.StatusUnauthorized:
+		return exitAuth
+	case http.StatusNotFound:
+		return exitNotFound
+	case http.StatusForbidden:
+		return exitForbidden
+	case http.StatusConflict:
+		return exitConflict
+	case http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType, http.StatusInsufficientStorage:
+		return exitRejected
+	}
+	return exitError
+}
+
+// newFlagSet creates the flag set of a command. Parse errors are returned
+// as usage errors, after printing the command's usage.
+func newFlagSet(name, synopsis string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: filehub %s\n\nOptions:\n", synopsis)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses the arguments of a command.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return &usageError{msg: err.Error()}
+	}
+	return nil
+}
+
+// printJSON writes a JSON document on its own line.
+func (a *app) printJSON(data []byte) error {
+	if len(data) > 0 && data[len(data)-1] == '\n' {
+		data = data[:len(data)-1]
+	}
+	_, err := fmt.Fprintln(a.stdout, string(data))
+	return err
+}
+
+// printValue writes v as JSON on its own line.
+func (a *app) printValue(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return a.printJSON(data)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// progressInterval limits how often the progress bar is redrawn.
+const progressInterval = 100 * time.Millisecond
+
+const progressWidth = 30
+
+// progressReader wraps a reader and draws a progress bar on stderr while it
+// is read. Total may be -1 if the size is unknown.
+type progressReader struct {
+	r     io.Reader
+	label string
+	total int64
+	done  int64
+	drawn time.Time
+
+	finished bool // Read reached EOF and ended the bar's line
+}
+
+// newProgress returns r with a progress bar, or r without one when stderr
+// is not a terminal or output is meant for scripts. Close it once done
+// reading.
+func newProgress(r io.Reader, label string, total int64, quiet bool) io.ReadCloser {
+	if quiet || !term.IsTerminal(int(os.Stderr.Fd())) {
+		return io.NopCloser(r)
+	}
+	return &progressReader{r: r, label: label, total: total}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.done += int64(n)
+	if err == io.EOF && !p.finished {
+		p.draw()
+		fmt.Fprintln(os.Stderr)
+		p.finished = true
+	} else if err == nil && time.Since(p.drawn) >= progressInterval {
+		p.draw()
+	}
+	return n, err
+}
+
+// Close clears the bar if reading stopped early, so an error message that
+// follows starts on its own line.
+func (p *progressReader) Close() error {
+	if !p.finished && !p.drawn.IsZero() {
+		fmt.Fprintln(os.Stderr)
+		p.finished = true
+	}
+	return nil
+}
+
+func (p *progressReader) draw() {
+	p.drawn = time.Now()
+	if p.total <= 0 {
+		fmt.Fprintf(os.Stderr, "\r%s  %s", p.label, formatSize(p.done))
+		return
+	}
+	filled := int(p.done * progressWidth / p.total)
+	if filled > progressWidth {
+		filled = progressWidth
+	}
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", progressWidth-filled)
+	fmt.Fprintf(os.Stderr, "\r%s  [%s] %3d%%  %s / %s", p.label, bar, p.done*100/p.total, formatSize(p.done), formatSize(p.total))
+}
+
+// formatSize formats a byte count for people, e.g. 1.5 MB.
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -15,6 +15,50 @@ type Config struct {
 	DBName             string `mapstructure:"DB_NAME"`
 	JWTSecretKey       string `mapstructure:"JWT_SECRET_KEY"`
 	JWTExpirationHours int    `mapstructure:"JWT_EXPIRATION_HOURS"`
+
+	StorageDriver    string `mapstructure:"STORAGE_DRIVER"` // "local" (default) or "s3"
+	StorageLocalPath string `mapstructure:"STORAGE_LOCAL_PATH"`
+	S3Endpoint       string `mapstructure:"S3_ENDPOINT"`
+	S3Region         string `mapstructure:"S3_REGION"`
+	S3Bucket         string `mapstructure:"S3_BUCKET"`
+	S3AccessKey      string `mapstructure:"S3_ACCESS_KEY"`
+	S3SecretKey      string `mapstructure:"S3_SECRET_KEY"`
+	S3UseSSL         bool   `mapstructure:"S3_USE_SSL"`
+
+	MaxUploadSize int64  `mapstructure:"MAX_UPLOAD_SIZE"` // Maximum file size in bytes
+	UploadTempDir string `mapstructure:"UPLOAD_TEMP_DIR"` // Staging directory for resumable uploads
+
+	UploadAllowedTypes      string `mapstructure:"UPLOAD_ALLOWED_TYPES"`      // Comma-separated MIME types, e.g. image/*; empty allows all
+	UploadDeniedTypes       string `mapstructure:"UPLOAD_DENIED_TYPES"`       // Comma-separated MIME types that are always rejected
+	UploadAllowedExtensions string `mapstructure:"UPLOAD_ALLOWED_EXTENSIONS"` // Comma-separated extensions; empty allows all
+	UploadDeniedExtensions  string `mapstructure:"UPLOAD_DENIED_EXTENSIONS"`  // Comma-separated extensions that are always rejected
+
+	ExtractMaxEntries int     `mapstructure:"EXTRACT_MAX_ENTRIES"` // Most entries an uploaded archive may hold
+	ExtractMaxSize    int64   `mapstructure:"EXTRACT_MAX_SIZE"`    // Largest total expanded size of an archive in bytes
+	ExtractMaxRatio   float64 `mapstructure:"EXTRACT_MAX_RATIO"`   // Highest expanded-to-compressed size ratio
+
+	DefaultQuotaBytes           int64 `mapstructure:"DEFAULT_QUOTA_BYTES"`            // Per-user quota, 0 for unlimited
+	QuotaReconcileIntervalHours int   `mapstructure:"QUOTA_RECONCILE_INTERVAL_HOURS"` // How often usage counters are recomputed
+
+	SignedURLKeys          string `mapstructure:"SIGNED_URL_KEYS"`            // Comma-separated key-id:secret pairs, the first one signs
+	SignedURLMaxTTLMinutes int    `mapstructure:"SIGNED_URL_MAX_TTL_MINUTES"` // Longest validity a signed URL may be given
+
+	IndexIntervalSeconds     int `mapstructure:"INDEX_INTERVAL_SECONDS"`     // How often new content is indexed for search
+	ThumbnailIntervalSeconds int `mapstructure:"THUMBNAIL_INTERVAL_SECONDS"` // How often thumbnails of new images are rendered
+
+	ClamdAddress        string `mapstructure:"CLAMD_ADDRESS"`         // host:port or unix:/path of a clamd daemon, empty to disable scanning
+	ClamdTimeoutSeconds int    `mapstructure:"CLAMD_TIMEOUT_SECONDS"` // Longest time a single scan may take
+
+	JobWorkers             int `mapstructure:"JOB_WORKERS"`              // Number of concurrent background job workers
+	ShutdownTimeoutSeconds int `mapstructure:"SHUTDOWN_TIMEOUT_SECONDS"` // How long requests and jobs get to finish on shutdown
+
+	S3GatewayPort   string `mapstructure:"S3_GATEWAY_PORT"`   // Port of the S3-compatible API, empty to disable it
+	S3GatewayRegion string `mapstructure:"S3_GATEWAY_REGION"` // Region reported to S3 clients
+
+	GRPCPort string `mapstructure:"GRPC_PORT"` // Port of the gRPC API, empty to disable it
+
+	TrashRetentionDays        int `mapstructure:"TRASH_RETENTION_DAYS"`         // Days before trashed items are purged
+	TrashPurgeIntervalMinutes int `mapstructure:"TRASH_PURGE_INTERVAL_MINUTES"` // How often the purge runs
 }
 
 func LoadConfig() (config Config, err error) {
@@ -0,0 +1,140 @@
+// Package clamd is a client for the clamd INSTREAM protocol, spoken by
+// ClamAV's daemon and compatible scanners.
+package clamd
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// chunkSize is the size of the chunks content is streamed to clamd in.
+const chunkSize = 64 * 1024
+
+// DefaultTimeout is used when no timeout is configured.
+const DefaultTimeout = 2 * time.Minute
+
+// ErrSizeLimit is returned when the content exceeds clamd's StreamMaxLength.
+var ErrSizeLimit = errors.New("clamd: content exceeds the stream size limit")
+
+// Result is the verdict of a scan.
+type Result struct {
+	Infected  bool
+	Signature string // Name of the detected malware, if infected
+}
+
+// Client scans content with a clamd-compatible daemon.
+type Client struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// NewClient creates a client for the daemon at address, either host:port
+// or unix:/path/to/clamd.sock.
+func NewClient(address string, timeout time.Duration) *Client {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	c := &Client{network: "tcp", address: address, timeout: timeout}
+	if path, ok := strings.CutPrefix(address, "unix:"); ok {
+		c.network, c.address = "unix", path
+	}
+	return c
+}
+
+// Scan streams r to the daemon and returns its verdict.
+func (c *Client) Scan(ctx context.Context, r io.Reader) (*Result, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return nil, fmt.Errorf("clamd: %w", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	// The null-terminated form of the command gets a null-terminated reply
+	if _, err := io.WriteString(conn, "zINSTREAM\x00"); err != nil {
+		return nil, fmt.Errorf("clamd: %w", err)
+	}
+	if err := writeChunks(ctx, conn, r); err != nil {
+		// clamd closes the connection once the size limit is exceeded, so
+		// the reply explaining why may still be readable
+		if result, replyErr := readReply(conn); replyErr != nil || result != nil {
+			return result, replyErr
+		}
+		return nil, err
+	}
+	result, err := readReply(conn)
+	if err == nil && result == nil {
+		err = errors.New("clamd: empty reply")
+	}
+	return result, err
+}
+
+// writeChunks sends r as length-prefixed chunks followed by an empty chunk.
+func writeChunks(ctx context.Context, w io.Writer, r io.Reader) error {
+	buf := make([]byte, 4+chunkSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, err := io.ReadFull(r, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if _, werr := w.Write(buf[:4+n]); werr != nil {
+				return fmt.Errorf("clamd: %w", werr)
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err := w.Write([]byte{0, 0, 0, 0})
+	return err
+}
+
+// readReply parses a reply such as "stream: OK" or "stream: Eicar-Signature
+// FOUND". It returns nil without an error if the daemon sent nothing.
+func readReply(r io.Reader) (*Result, error) {
+	reply, err := bufio.NewReader(r).ReadString(0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("clamd: %w", err)
+	}
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	if reply == "" {
+		return nil, nil
+	}
+
+	// Replies are prefixed with the stream name and may carry a session ID
+	_, verdict, ok := strings.Cut(reply, ": ")
+	if !ok {
+		verdict = reply
+	}
+	switch {
+	case verdict == "OK":
+		return &Result{}, nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return &Result{Infected: true, Signature: strings.TrimSuffix(verdict, " FOUND")}, nil
+	case strings.Contains(verdict, "size limit exceeded"):
+		return nil, ErrSizeLimit
+	default:
+		return nil, fmt.Errorf("clamd: %s", verdict)
+	}
+}
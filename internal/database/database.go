@@ -26,7 +26,7 @@ func Connect(cfg config.Config) {
 	)
 
 	// Open a connection to the database
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -34,14 +34,116 @@ func Connect(cfg config.Config) {
 	log.Println("Database connection successfully established.")
 
 	// Auto-migrate the schema
-	// This will create the 'users' and 'files' tables if they don't exist
+	// This will create the application tables if they don't exist
 	err = DB.AutoMigrate(
 		&models.User{},
 		&models.File{},
+		&models.Upload{},
+		&models.Blob{},
+		&models.FileVersion{},
+		&models.Folder{},
+		&models.Share{},
+		&models.PublicLink{},
+		&models.LinkAccess{},
+		&models.SignedURLNonce{},
+		&models.FileContent{},
+		&models.Thumbnail{},
+		&models.Job{},
+		&models.QuarantinedBlob{},
+		&models.AppPassword{},
+		&models.AccessKey{},
+		&models.MultipartUpload{},
+		&models.MultipartPart{},
 	)
 	if err != nil {
 		log.Fatalf("Failed to auto-migrate database: %v", err)
 	}
 
+	// Folder names are unique per parent. Root folders have a NULL parent,
+	// which a plain unique index would treat as distinct, hence the COALESCE.
+	err = DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_folders_owner_parent_name
+		ON folders (owner_id, COALESCE(parent_id, 0), name) WHERE deleted_at IS NULL`).Error
+	if err != nil {
+		log.Fatalf("Failed to create folder name index: %v", err)
+	}
+
+	// The same goes for file names, so concurrent uploads of one name can't
+	// both create a file. Older versions allowed uploading a name twice, so
+	// duplicates are renamed first: all but the oldest get their ID appended,
+	// as in "report (42).pdf".
+	var indexed bool
+	err = DB.Raw(`SELECT EXISTS (SELECT 1 FROM pg_indexes WHERE indexname = 'idx_files_owner_folder_name')`).Scan(&indexed).Error
+	if err != nil {
+		log.Fatalf("Failed to look up file name index: %v", err)
+	}
+	if !indexed {
+		result := DB.Exec(`
+			UPDATE files SET file_name = CASE
+				WHEN files.file_name ~ '.\.[^.]+$'
+					THEN regexp_replace(files.file_name, '(\.[^.]+)$', ' (' || files.id || ')\1')
+				ELSE files.file_name || ' (' || files.id || ')'
+			END
+			FROM (
+				SELECT id, row_number() OVER (PARTITION BY owner_id, COALESCE(folder_id, 0), file_name ORDER BY id) AS n
+				FROM files WHERE deleted_at IS NULL
+			) d
+			WHERE files.id = d.id AND d.n > 1`)
+		if result.Error != nil {
+			log.Fatalf("Failed to rename duplicate file names: %v", result.Error)
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("Renamed %d files whose names were taken in their folder.", result.RowsAffected)
+		}
+	}
+	err = DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_files_owner_folder_name
+		ON files (owner_id, COALESCE(folder_id, 0), file_name) WHERE deleted_at IS NULL`).Error
+	if err != nil {
+		// Names are still checked before files are created, only without
+		// protection against concurrent uploads
+		log.Printf("Warning: could not create file name index: %v", err)
+	}
+
+	// Keyset indexes for sorting file listings, with id as the tie-breaker,
+	// and containment indexes for filtering by tags and properties
+	for _, stmt := range []string{
+		`CREATE INDEX IF NOT EXISTS idx_files_owner_name ON files (owner_id, file_name, id) WHERE deleted_at IS NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_files_owner_size ON files (owner_id, size, id) WHERE deleted_at IS NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_files_owner_created ON files (owner_id, created_at, id) WHERE deleted_at IS NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_files_owner_mime ON files (owner_id, mime_type) WHERE deleted_at IS NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_files_tags ON files USING gin (tags jsonb_path_ops)`,
+		`CREATE INDEX IF NOT EXISTS idx_files_properties ON files USING gin (properties jsonb_path_ops)`,
+	} {
+		if err := DB.Exec(stmt).Error; err != nil {
+			log.Fatalf("Failed to create file listing index: %v", err)
+		}
+	}
+
+	// Substring search on names uses a trigram index. The extension may need
+	// elevated privileges, so search keeps working (slower) without it.
+	err = DB.Exec(`CREATE EXTENSION IF NOT EXISTS pg_trgm`).Error
+	if err == nil {
+		err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_files_name_trgm ON files USING gin (file_name gin_trgm_ops)`).Error
+	}
+	if err != nil {
+		log.Printf("Warning: could not create trigram index for file name search: %v", err)
+	}
+
+	// Full-text search over extracted document text
+	for _, stmt := range []string{
+		`ALTER TABLE file_contents ADD COLUMN IF NOT EXISTS search_vector tsvector
+			GENERATED ALWAYS AS (to_tsvector('english', content)) STORED`,
+		`CREATE INDEX IF NOT EXISTS idx_file_contents_search ON file_contents USING gin (search_vector)`,
+	} {
+		if err := DB.Exec(stmt).Error; err != nil {
+			log.Fatalf("Failed to create full-text search index: %v", err)
+		}
+	}
+
+	// Workers claim the oldest due pending job
+	err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_jobs_pending ON jobs (run_at, id) WHERE status = 'pending'`).Error
+	if err != nil {
+		log.Fatalf("Failed to create job queue index: %v", err)
+	}
+
 	log.Println("Database migrated successfully.")
 }
@@ -0,0 +1,423 @@
+// Package davfs exposes a user's FileHub tree as a WebDAV file system.
+package davfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lskeey/go-filehub/internal/models"
+	"github.com/lskeey/go-filehub/internal/service"
+	"golang.org/x/net/webdav"
+)
+
+type contextKey struct{}
+
+// request is the per-request state the file system works with.
+type request struct {
+	userID uint
+
+	mu      sync.Mutex
+	err     error // Last service error, for a more precise HTTP status
+	bodyErr error // Error reading the request body, see WrapBody
+}
+
+// WithUser returns a context whose file system operations act as userID.
+func WithUser(ctx context.Context, userID uint) context.Context {
+	return context.WithValue(ctx, contextKey{}, &request{userID: userID})
+}
+
+// Failure returns the last service error an operation in ctx ran into.
+// webdav.Handler reduces errors to a few generic statuses, so callers can
+// use it to report what actually went wrong.
+func Failure(ctx context.Context) error {
+	req, ok := ctx.Value(contextKey{}).(*request)
+	if !ok {
+		return nil
+	}
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	return req.err
+}
+
+// WrapBody returns body with read errors recorded in the request of ctx.
+// webdav.Handler closes the file of a PUT even when copying the body failed,
+// and a write is only aborted, rather than saved, if the failure is known
+// by then.
+func WrapBody(ctx context.Context, body io.ReadCloser) io.ReadCloser {
+	req, ok := ctx.Value(contextKey{}).(*request)
+	if !ok {
+		return body
+	}
+	return &recordingBody{ReadCloser: body, req: req}
+}
+
+// recordingBody remembers read errors, such as a client disconnecting or
+// sending less than its Content-Length, in the request state.
+type recordingBody struct {
+	io.ReadCloser
+	req *request
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		b.req.mu.Lock()
+		b.req.bodyErr = err
+		b.req.mu.Unlock()
+	}
+	return n, err
+}
+
+func (req *request) bodyFailure() error {
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	return req.bodyErr
+}
+
+// FS is a webdav.FileSystem over the files and folders of the user in the
+// request context. Paths start at the user's root; items shared with them
+// are not part of it. Writes go through FileService.UploadFile, so
+// overwriting a file adds a new version, and deletes move items to the trash.
+type FS struct {
+	files   *service.FileService
+	folders *service.FolderService
+}
+
+var _ webdav.FileSystem = (*FS)(nil)
+
+// New creates a WebDAV file system backed by the file and folder services.
+func New(files *service.FileService, folders *service.FolderService) *FS {
+	return &FS{files: files, folders: folders}
+}
+
+// Mkdir creates a folder. Its parent must exist.
+func (fsys *FS) Mkdir(ctx context.Context, name string, _ os.FileMode) error {
+	req, err := requestFrom(ctx)
+	if err != nil {
+		return err
+	}
+	parentID, base, err := fsys.parent(req, name)
+	if err != nil {
+		return pathError(req, "mkdir", name, err)
+	}
+	if base == "" {
+		return pathError(req, "mkdir", name, os.ErrExist)
+	}
+	_, err = fsys.folders.CreateFolder(req.userID, base, parentID)
+	return pathError(req, "mkdir", name, err)
+}
+
+// OpenFile opens a file or folder for reading, or a file for writing. A
+// written file replaces the content on Close.
+func (fsys *FS) OpenFile(ctx context.Context, name string, flag int, _ os.FileMode) (webdav.File, error) {
+	req, err := requestFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if flag&(os.O_WRONLY|os.O_RDWR) != 0 {
+		_, file, err := fsys.folders.ResolvePath(req.userID, name)
+		switch {
+		case err == nil && file == nil:
+			return nil, pathError(req, "open", name, errIsDir)
+		case err == nil && flag&os.O_EXCL != 0:
+			return nil, pathError(req, "open", name, os.ErrExist)
+		case err != nil && flag&os.O_CREATE == 0:
+			return nil, pathError(req, "open", name, err)
+		}
+		parentID, base, err := fsys.parent(req, name)
+		if err != nil {
+			return nil, pathError(req, "open", name, err)
+		}
+		return fsys.create(ctx, req, parentID, base), nil
+	}
+
+	folder, file, err := fsys.folders.ResolvePath(req.userID, name)
+	if err != nil {
+		return nil, pathError(req, "open", name, err)
+	}
+	if file == nil {
+		return &dirFile{fsys: fsys, req: req, folder: folder, info: folderInfo(folder)}, nil
+	}
+	content, err := fsys.files.OpenFile(ctx, file)
+	if err != nil {
+		return nil, pathError(req, "open", name, err)
+	}
+	return &readFile{ReadSeekCloser: content, info: fileInfo(file)}, nil
+}
+
+// RemoveAll moves a file or a folder with everything in it to the trash.
+func (fsys *FS) RemoveAll(ctx context.Context, name string) error {
+	req, err := requestFrom(ctx)
+	if err != nil {
+		return err
+	}
+	folder, file, err := fsys.folders.ResolvePath(req.userID, name)
+	switch {
+	case err != nil:
+		return pathError(req, "remove", name, err)
+	case file != nil:
+		err = fsys.files.DeleteFile(file.ID, req.userID)
+	case folder != nil:
+		err = fsys.folders.DeleteFolder(folder.ID, req.userID, true)
+	default:
+		err = os.ErrPermission // The root itself
+	}
+	return pathError(req, "remove", name, err)
+}
+
+// Rename moves and renames a file or folder in one step.
+func (fsys *FS) Rename(ctx context.Context, oldName, newName string) error {
+	req, err := requestFrom(ctx)
+	if err != nil {
+		return err
+	}
+	folder, file, err := fsys.folders.ResolvePath(req.userID, oldName)
+	if err != nil {
+		return pathError(req, "rename", oldName, err)
+	}
+	parentID, base, err := fsys.parent(req, newName)
+	if err != nil {
+		return pathError(req, "rename", newName, err)
+	}
+	switch {
+	case file != nil:
+		_, err = fsys.files.RelocateFile(file.ID, req.userID, parentID, base)
+	case folder != nil && base != "":
+		_, err = fsys.folders.RelocateFolder(folder.ID, req.userID, parentID, base)
+	default:
+		err = os.ErrPermission // The root can't be moved, nor replaced
+	}
+	return pathError(req, "rename", newName, err)
+}
+
+// Stat describes a file or folder.
+func (fsys *FS) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	req, err := requestFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	folder, file, err := fsys.folders.ResolvePath(req.userID, name)
+	if err != nil {
+		return nil, pathError(req, "stat", name, err)
+	}
+	if file != nil {
+		return fileInfo(file), nil
+	}
+	return folderInfo(folder), nil
+}
+
+// parent resolves the folder a path is in (nil for the root) and returns
+// it with the path's last segment, which is empty for the root itself.
+func (fsys *FS) parent(req *request, name string) (*uint, string, error) {
+	dir, base := path.Split(strings.TrimSuffix(name, "/"))
+	folder, file, err := fsys.folders.ResolvePath(req.userID, dir)
+	if err != nil {
+		return nil, "", err
+	}
+	if file != nil {
+		return nil, "", os.ErrNotExist
+	}
+	if folder == nil {
+		return nil, base, nil
+	}
+	return &folder.ID, base, nil
+}
+
+// create starts an upload that the returned file's writes are streamed into.
+func (fsys *FS) create(ctx context.Context, req *request, folderID *uint, name string) *writeFile {
+	pr, pw := io.Pipe()
+	w := &writeFile{pw: pw, req: req, name: name, modTime: time.Now(), done: make(chan struct{})}
+	go func() {
+		defer close(w.done)
+		_, err := fsys.files.UploadFile(ctx, req.userID, folderID, name, mime.TypeByExtension(path.Ext(name)), pr)
+		// Unblock writes if the upload stopped early, e.g. over the quota
+		pr.CloseWithError(err)
+		w.err = pathError(req, "write", name, err)
+	}()
+	return w
+}
+
+var errIsDir = errors.New("is a directory")
+
+func requestFrom(ctx context.Context) (*request, error) {
+	req, ok := ctx.Value(contextKey{}).(*request)
+	if !ok {
+		return nil, os.ErrPermission
+	}
+	return req, nil
+}
+
+// pathError converts a service error into the os error webdav.Handler
+// expects. Errors the handler has no status for, like an exceeded quota,
+// are remembered for Failure.
+func pathError(req *request, op, name string, err error) error {
+	if err == nil {
+		return nil
+	}
+	mapped := err
+	switch {
+	case errors.Is(err, service.ErrPathNotFound),
+		errors.Is(err, service.ErrFileNotFound),
+		errors.Is(err, service.ErrFolderNotFound):
+		mapped = os.ErrNotExist
+	case errors.Is(err, service.ErrFileForbidden),
+		errors.Is(err, service.ErrFolderForbidden),
+		errors.Is(err, service.ErrMoveAcrossOwners),
+		errors.Is(err, service.ErrInvalidMove):
+		mapped = os.ErrPermission
+	case errors.Is(err, service.ErrNameConflict):
+		mapped = os.ErrExist
+	case errors.Is(err, service.ErrInvalidName):
+		mapped = os.ErrInvalid
+	case errors.Is(err, fs.ErrNotExist), errors.Is(err, fs.ErrExist),
+		errors.Is(err, fs.ErrPermission), errors.Is(err, errIsDir):
+	default:
+		req.mu.Lock()
+		req.err = err
+		req.mu.Unlock()
+	}
+	return &os.PathError{Op: op, Path: name, Err: mapped}
+}
+
+// info describes a file or folder. It also tells webdav.Handler the
+// content type, so listing a folder doesn't open every file to sniff it.
+type info struct {
+	name        string
+	size        int64
+	modTime     time.Time
+	dir         bool
+	contentType string
+}
+
+func fileInfo(file *models.File) *info {
+	return &info{name: file.FileName, size: file.Size, modTime: file.UpdatedAt, contentType: file.MimeType}
+}
+
+func folderInfo(folder *models.Folder) *info {
+	if folder == nil {
+		return &info{name: "/", dir: true}
+	}
+	return &info{name: folder.Name, modTime: folder.UpdatedAt, dir: true}
+}
+
+func (i *info) Name() string       { return i.name }
+func (i *info) Size() int64        { return i.size }
+func (i *info) ModTime() time.Time { return i.modTime }
+func (i *info) IsDir() bool        { return i.dir }
+func (i *info) Sys() any           { return nil }
+
+func (i *info) Mode() os.FileMode {
+	if i.dir {
+		return os.ModeDir | 0o755
+	}
+	return 0o644
+}
+
+func (i *info) ContentType(context.Context) (string, error) {
+	if i.contentType == "" {
+		return "", webdav.ErrNotImplemented
+	}
+	return i.contentType, nil
+}
+
+// readFile is a file opened for reading.
+type readFile struct {
+	io.ReadSeekCloser
+	info *info
+}
+
+func (f *readFile) Readdir(int) ([]fs.FileInfo, error) { return nil, errors.New("not a directory") }
+func (f *readFile) Stat() (fs.FileInfo, error)         { return f.info, nil }
+func (f *readFile) Write([]byte) (int, error)          { return 0, os.ErrPermission }
+
+// dirFile is an opened folder. Its children are loaded on the first Readdir.
+type dirFile struct {
+	fsys     *FS
+	req      *request
+	folder   *models.Folder // nil for the root
+	info     *info
+	children []fs.FileInfo
+	loaded   bool
+	pos      int
+}
+
+func (d *dirFile) Readdir(count int) ([]fs.FileInfo, error) {
+	if !d.loaded {
+		var folderID *uint
+		if d.folder != nil {
+			folderID = &d.folder.ID
+		}
+		folders, files, err := d.fsys.folders.ListChildren(d.req.userID, folderID)
+		if err != nil {
+			return nil, pathError(d.req, "readdir", d.info.name, err)
+		}
+		for i := range folders {
+			d.children = append(d.children, folderInfo(&folders[i]))
+		}
+		for i := range files {
+			d.children = append(d.children, fileInfo(&files[i]))
+		}
+		d.loaded = true
+	}
+
+	rest := d.children[d.pos:]
+	if count <= 0 {
+		d.pos = len(d.children)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	n := min(count, len(rest))
+	d.pos += n
+	return rest[:n], nil
+}
+
+func (d *dirFile) Stat() (fs.FileInfo, error)     { return d.info, nil }
+func (d *dirFile) Read([]byte) (int, error)       { return 0, errIsDir }
+func (d *dirFile) Write([]byte) (int, error)      { return 0, errIsDir }
+func (d *dirFile) Seek(int64, int) (int64, error) { return 0, nil }
+func (d *dirFile) Close() error                   { return nil }
+
+// writeFile is a file opened for writing. Written bytes are piped into an
+// upload running in the background; Close waits for it to finish.
+type writeFile struct {
+	pw      *io.PipeWriter
+	req     *request
+	name    string
+	size    int64
+	modTime time.Time
+	done    chan struct{}
+	err     error
+}
+
+func (w *writeFile) Write(p []byte) (int, error) {
+	n, err := w.pw.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *writeFile) Close() error {
+	// An incomplete body must abort the upload instead of saving what
+	// arrived as a new version
+	w.pw.CloseWithError(w.req.bodyFailure())
+	<-w.done
+	return w.err
+}
+
+func (w *writeFile) Stat() (fs.FileInfo, error) {
+	return &info{name: w.name, size: w.size, modTime: w.modTime}, nil
+}
+
+func (w *writeFile) Read([]byte) (int, error)           { return 0, os.ErrPermission }
+func (w *writeFile) Seek(int64, int) (int64, error)     { return 0, os.ErrPermission }
+func (w *writeFile) Readdir(int) ([]fs.FileInfo, error) { return nil, errors.New("not a directory") }
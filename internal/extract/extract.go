@@ -0,0 +1,108 @@
+// Package extract pulls searchable plain text out of uploaded documents.
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxTextSize caps the text kept per document. Postgres limits a tsvector
+// to 1MB, so longer documents are only searchable by their beginning.
+const MaxTextSize = 256 * 1024
+
+// MaxDocumentSize is the largest document text is extracted from.
+const MaxDocumentSize = 64 * 1024 * 1024
+
+var (
+	ErrUnsupported = errors.New("unsupported document type")
+	ErrTooLarge    = errors.New("document too large for text extraction")
+)
+
+type extractor func(r io.ReadSeeker, size int64) (string, error)
+
+// extractors by MIME type. Types are also derived from file extensions,
+// since browsers often upload documents as application/octet-stream.
+var extractors = map[string]extractor{
+	"text/plain":      plainText,
+	"text/markdown":   plainText,
+	"text/x-markdown": plainText,
+	"text/csv":        plainText,
+	"text/html":       htmlText,
+	"application/pdf": pdfText,
+}
+
+var extensionTypes = map[string]string{
+	".txt":      "text/plain",
+	".log":      "text/plain",
+	".md":       "text/markdown",
+	".markdown": "text/markdown",
+	".csv":      "text/csv",
+	".html":     "text/html",
+	".htm":      "text/html",
+	".pdf":      "application/pdf",
+}
+
+// Supported reports whether text can be extracted from a document with the
+// given MIME type or file name.
+func Supported(mimeType, fileName string) bool {
+	return lookup(mimeType, fileName) != nil
+}
+
+// Text extracts the plain text of a document of size bytes. The result is
+// valid UTF-8 and at most MaxTextSize bytes long.
+func Text(r io.ReadSeeker, size int64, mimeType, fileName string) (text string, err error) {
+	extract := lookup(mimeType, fileName)
+	if extract == nil {
+		return "", ErrUnsupported
+	}
+	if size > MaxDocumentSize {
+		return "", ErrTooLarge
+	}
+
+	// Parsers of untrusted documents may panic on malformed input
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("extracting text: %v", p)
+		}
+	}()
+
+	text, err = extract(r, size)
+	if err != nil {
+		return "", err
+	}
+	return truncate(strings.ToValidUTF8(text, ""), MaxTextSize), nil
+}
+
+func lookup(mimeType, fileName string) extractor {
+	// Drop parameters such as "; charset=utf-8"
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	if extract, ok := extractors[strings.ToLower(strings.TrimSpace(mimeType))]; ok {
+		return extract
+	}
+	if t, ok := extensionTypes[strings.ToLower(path.Ext(fileName))]; ok {
+		return extractors[t]
+	}
+	return nil
+}
+
+// truncate shortens s to at most n bytes without splitting a character.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// plainText covers text, Markdown and CSV, whose markup needs no parsing
+// for search: the full-text parser already splits on punctuation.
+func plainText(r io.ReadSeeker, _ int64) (string, error) {
+	b, err := io.ReadAll(io.LimitReader(r, MaxTextSize+utf8.UTFMax))
+	return string(b), err
+}
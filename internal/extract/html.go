@@ -0,0 +1,49 @@
+package extract
+
+import (
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// htmlText collects the text nodes of an HTML document, skipping scripts and styles.
+func htmlText(r io.ReadSeeker, _ int64) (string, error) {
+	var sb strings.Builder
+	z := html.NewTokenizer(r)
+	skip := 0
+	for sb.Len() < MaxTextSize {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return sb.String(), nil
+			}
+			return sb.String(), z.Err()
+		case html.StartTagToken:
+			if isHiddenElement(z) {
+				skip++
+			}
+		case html.EndTagToken:
+			if isHiddenElement(z) && skip > 0 {
+				skip--
+			}
+		case html.TextToken:
+			if skip == 0 {
+				if text := strings.TrimSpace(string(z.Text())); text != "" {
+					sb.WriteString(text)
+					sb.WriteByte(' ')
+				}
+			}
+		}
+	}
+	return sb.String(), nil
+}
+
+func isHiddenElement(z *html.Tokenizer) bool {
+	name, _ := z.TagName()
+	switch string(name) {
+	case "script", "style", "noscript", "template":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package extract
+
+import (
+	"io"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// pdfText extracts the text of all pages of a PDF document.
+func pdfText(r io.ReadSeeker, size int64) (string, error) {
+	ra, ok := r.(io.ReaderAt)
+	if !ok {
+		ra = &seekReaderAt{r: r}
+	}
+	doc, err := pdf.NewReader(ra, size)
+	if err != nil {
+		return "", err
+	}
+	text, err := doc.GetPlainText()
+	if err != nil {
+		return "", err
+	}
+	b, err := io.ReadAll(io.LimitReader(text, MaxTextSize+4))
+	return string(b), err
+}
+
+// seekReaderAt implements io.ReaderAt on top of a seeker, for storage
+// backends whose readers do not support random access directly.
+type seekReaderAt struct {
+	r io.ReadSeeker
+}
+
+func (s *seekReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if _, err := s.r.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := io.ReadFull(s.r, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
@@ -0,0 +1,167 @@
+// Package filetype identifies file types from their content, the way
+// browsers sniff responses, with the file name as a fallback.
+package filetype
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// SniffLen is the number of leading bytes Detect looks at.
+const SniffLen = 512
+
+// Generic types mean the content alone didn't identify the type.
+const (
+	OctetStream = "application/octet-stream"
+	PlainText   = "text/plain"
+)
+
+// signature identifies a type by bytes at a fixed offset.
+type signature struct {
+	offset   int
+	magic    []byte
+	mimeType string
+}
+
+// signatures of types http.DetectContentType doesn't know about. Executables
+// are included so they can't pass as something harmless.
+var signatures = []signature{
+	{0, []byte("MZ"), "application/vnd.microsoft.portable-executable"},
+	{0, []byte("\x7fELF"), "application/x-executable"},
+	{0, []byte("\xcf\xfa\xed\xfe"), "application/x-mach-binary"},
+	{0, []byte("\xca\xfe\xba\xbe"), "application/x-mach-binary"},
+	{0, []byte("7z\xbc\xaf\x27\x1c"), "application/x-7z-compressed"},
+	{0, []byte("BZh"), "application/x-bzip2"},
+	{0, []byte("\xfd7zXZ\x00"), "application/x-xz"},
+	{0, []byte("SQLite format 3\x00"), "application/vnd.sqlite3"},
+	{257, []byte("ustar"), "application/x-tar"},
+}
+
+// sniffable types are recognized from content, so a file whose content
+// doesn't match isn't of that type, whatever its extension says.
+var sniffable = map[string]bool{
+	"image/jpeg":         true,
+	"image/png":          true,
+	"image/gif":          true,
+	"image/webp":         true,
+	"image/bmp":          true,
+	"application/pdf":    true,
+	"application/zip":    true,
+	"application/x-gzip": true,
+	"application/gzip":   true,
+	"audio/mpeg":         true,
+	"audio/wave":         true,
+	"video/mp4":          true,
+	"video/webm":         true,
+}
+
+// zipBased types are ZIP archives underneath; the extension tells them apart.
+var zipBased = map[string]bool{
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   true,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         true,
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": true,
+	"application/vnd.oasis.opendocument.text":                                   true,
+	"application/vnd.oasis.opendocument.spreadsheet":                            true,
+	"application/vnd.oasis.opendocument.presentation":                           true,
+	"application/epub+zip":     true,
+	"application/java-archive": true,
+}
+
+// textual types are plain text underneath.
+var textual = map[string]bool{
+	"application/json":       true,
+	"application/xml":        true,
+	"application/javascript": true,
+	"application/x-yaml":     true,
+	"application/yaml":       true,
+	"image/svg+xml":          true,
+}
+
+// extraTypes complements the system MIME table, which varies by platform.
+var extraTypes = map[string]string{
+	".md":       "text/markdown",
+	".markdown": "text/markdown",
+	".csv":      "text/csv",
+	".yaml":     "application/yaml",
+	".yml":      "application/yaml",
+	".webp":     "image/webp",
+	".docx":     "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".xlsx":     "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".pptx":     "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	".odt":      "application/vnd.oasis.opendocument.text",
+	".ods":      "application/vnd.oasis.opendocument.spreadsheet",
+	".odp":      "application/vnd.oasis.opendocument.presentation",
+	".epub":     "application/epub+zip",
+	".jar":      "application/java-archive",
+}
+
+// Detect returns the MIME type of content starting with head (at least the
+// first SniffLen bytes, if there are that many). The extension of fileName is
+// only used to refine a type the content leaves open, e.g. plain text as CSV
+// or a ZIP archive as a Word document. Parameters such as charset are dropped.
+func Detect(head []byte, fileName string) string {
+	detected := sniff(head)
+	byName := ByExtension(fileName)
+
+	switch {
+	case byName == "":
+		return detected
+	case detected == OctetStream && !sniffable[byName] && !zipBased[byName]:
+		return byName
+	case detected == PlainText && (strings.HasPrefix(byName, "text/") || textual[byName]):
+		return byName
+	case detected == "application/zip" && zipBased[byName]:
+		return byName
+	}
+	return detected
+}
+
+// ByExtension returns the MIME type registered for the extension of
+// fileName, without parameters, or "" if there is none.
+func ByExtension(fileName string) string {
+	ext := Extension(fileName)
+	if ext == "" {
+		return ""
+	}
+	if t, ok := extraTypes[ext]; ok {
+		return t
+	}
+	return Base(mime.TypeByExtension(ext))
+}
+
+// Extension returns the lower-cased extension of fileName, including the dot.
+func Extension(fileName string) string {
+	return strings.ToLower(path.Ext(fileName))
+}
+
+// Base strips parameters from a MIME type and lower-cases it.
+func Base(mimeType string) string {
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	return strings.ToLower(strings.TrimSpace(mimeType))
+}
+
+// Match reports whether mimeType matches pattern, which is a full type like
+// "image/png", a wildcard like "image/*", or "*/*".
+func Match(pattern, mimeType string) bool {
+	pattern, mimeType = Base(pattern), Base(mimeType)
+	if pattern == "*/*" || pattern == mimeType {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(pattern, "/*")
+	return ok && strings.HasPrefix(mimeType, prefix+"/")
+}
+
+func sniff(head []byte) string {
+	for _, sig := range signatures {
+		if len(head) >= sig.offset+len(sig.magic) && bytes.Equal(head[sig.offset:sig.offset+len(sig.magic)], sig.magic) {
+			return sig.mimeType
+		}
+	}
+	if len(head) == 0 {
+		return OctetStream
+	}
+	return Base(http.DetectContentType(head))
+}
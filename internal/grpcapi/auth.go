@@ -0,0 +1,88 @@
+package grpcapi
+
+import (
+	"context"
+	"strings"
+
+	"github.com/lskeey/go-filehub/pkg/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type userIDKey struct{}
+
+// publicMethods can be called without a token.
+var publicMethods = map[string]bool{
+	"/filehub.v1.FileHub/Login": true,
+}
+
+// UnaryAuthInterceptor authenticates unary calls with the JWT in the
+// "authorization: Bearer <token>" metadata, like the REST API's
+// AuthMiddleware.
+func UnaryAuthInterceptor(secretKey string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if publicMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+		ctx, err := authenticate(ctx, secretKey)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuthInterceptor is the streaming counterpart of UnaryAuthInterceptor.
+func StreamAuthInterceptor(secretKey string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if publicMethods[info.FullMethod] {
+			return handler(srv, ss)
+		}
+		ctx, err := authenticate(ss.Context(), secretKey)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authenticate returns ctx carrying the ID of the user the call's token was
+// issued for.
+func authenticate(ctx context.Context, secretKey string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "authorization metadata is missing")
+	}
+
+	// The value should be in the format "Bearer <token>"
+	parts := strings.Split(values[0], " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return nil, status.Error(codes.Unauthenticated, "authorization metadata format must be Bearer {token}")
+	}
+
+	userID, err := utils.ParseJWT(parts[1], secretKey)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	return context.WithValue(ctx, userIDKey{}, userID), nil
+}
+
+// userID returns the authenticated user of a call.
+func userID(ctx context.Context) uint {
+	id, _ := ctx.Value(userIDKey{}).(uint)
+	return id
+}
+
+// authenticatedStream replaces the context of a stream with one carrying
+// the user ID.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
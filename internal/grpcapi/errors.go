@@ -0,0 +1,54 @@
+package grpcapi
+
+import (
+	"errors"
+	"log"
+
+	"github.com/lskeey/go-filehub/internal/service"
+	"github.com/lskeey/go-filehub/internal/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorCodes maps service errors to gRPC status codes. Errors are matched
+// in order with errors.Is.
+var errorCodes = []struct {
+	err  error
+	code codes.Code
+}{
+	{service.ErrInvalidCredentials, codes.Unauthenticated},
+	{service.ErrFileNotFound, codes.NotFound},
+	{service.ErrFolderNotFound, codes.NotFound},
+	{storage.ErrNotFound, codes.NotFound},
+	{service.ErrFileForbidden, codes.PermissionDenied},
+	{service.ErrFolderForbidden, codes.PermissionDenied},
+	{service.ErrFileInfected, codes.PermissionDenied},
+	{service.ErrFileTypeNotAllowed, codes.PermissionDenied},
+	{service.ErrNameConflict, codes.AlreadyExists},
+	{service.ErrFileNotScanned, codes.FailedPrecondition},
+	{service.ErrInvalidName, codes.InvalidArgument},
+	{service.ErrInvalidSearch, codes.InvalidArgument},
+	{service.ErrInvalidCursor, codes.InvalidArgument},
+	{service.ErrInvalidTag, codes.InvalidArgument},
+	{service.ErrInvalidProperty, codes.InvalidArgument},
+	{errUnexpectedInfo, codes.InvalidArgument},
+	{service.ErrFileTooLarge, codes.ResourceExhausted},
+	{service.ErrQuotaExceeded, codes.ResourceExhausted},
+}
+
+// toStatus converts a service error into a gRPC status error. Errors
+// without a specific mapping are logged and reported as Internal with the
+// fallback message, so internal details are not leaked.
+func toStatus(err error, fallback string) error {
+	for _, e := range errorCodes {
+		if errors.Is(err, e.err) {
+			return status.Error(e.code, err.Error())
+		}
+	}
+	// Errors of the stream itself, e.g. a cancelled upload
+	if s, ok := status.FromError(err); ok && s.Code() != codes.Unknown {
+		return s.Err()
+	}
+	log.Printf("grpc: %s: %v", fallback, err)
+	return status.Error(codes.Internal, fallback)
+}
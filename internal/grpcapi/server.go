@@ -0,0 +1,211 @@
+// Package grpcapi implements the FileHub gRPC service defined in
+// proto/filehub/v1 on top of the same services as the REST API.
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/lskeey/go-filehub/internal/models"
+	"github.com/lskeey/go-filehub/internal/service"
+	filehubv1 "github.com/lskeey/go-filehub/proto/filehub/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// downloadChunkSize is the size of the content chunks DownloadFile sends,
+// well below gRPC's default 4 MiB message limit.
+const downloadChunkSize = 64 << 10
+
+// errUnexpectedInfo is returned when an upload sends its file info anywhere
+// but in the first message.
+var errUnexpectedInfo = errors.New("file info must only be sent in the first message")
+
+// Server implements filehubv1.FileHubServer.
+type Server struct {
+	filehubv1.UnimplementedFileHubServer
+
+	authService *service.AuthService
+	fileService *service.FileService
+}
+
+// NewServer creates a new FileHub gRPC server.
+func NewServer(authService *service.AuthService, fileService *service.FileService) *Server {
+	return &Server{authService: authService, fileService: fileService}
+}
+
+// Login exchanges an email and password for a JWT.
+func (s *Server) Login(ctx context.Context, req *filehubv1.LoginRequest) (*filehubv1.LoginResponse, error) {
+	if req.GetEmail() == "" || req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "email and password are required")
+	}
+
+	token, err := s.authService.Login(req.GetEmail(), req.GetPassword())
+	if err != nil {
+		return nil, toStatus(err, "failed to log in")
+	}
+	return &filehubv1.LoginResponse{Token: token}, nil
+}
+
+// ListFiles returns one page of the user's files.
+func (s *Server) ListFiles(ctx context.Context, req *filehubv1.ListFilesRequest) (*filehubv1.ListFilesResponse, error) {
+	search := service.FileSearch{
+		Query:      req.GetQuery(),
+		Prefix:     req.GetPrefix(),
+		MimeType:   req.GetMimeType(),
+		MinSize:    req.MinSize,
+		MaxSize:    req.MaxSize,
+		Tags:       req.GetTags(),
+		Properties: req.GetProperties(),
+		Sort:       req.GetSort(),
+		Order:      req.GetOrder(),
+		Limit:      int(req.GetLimit()),
+		Cursor:     req.GetCursor(),
+	}
+	if req.CreatedAfter != nil {
+		t := req.CreatedAfter.AsTime()
+		search.CreatedAfter = &t
+	}
+	if req.CreatedBefore != nil {
+		t := req.CreatedBefore.AsTime()
+		search.CreatedBefore = &t
+	}
+
+	files, nextCursor, err := s.fileService.SearchFiles(userID(ctx), search)
+	if err != nil {
+		return nil, toStatus(err, "failed to list files")
+	}
+
+	resp := &filehubv1.ListFilesResponse{Files: make([]*filehubv1.File, len(files)), NextCursor: nextCursor}
+	for i := range files {
+		resp.Files[i] = toFile(&files[i])
+	}
+	return resp, nil
+}
+
+// UploadFile stores the content streamed after the file info.
+func (s *Server) UploadFile(stream filehubv1.FileHub_UploadFileServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return toStatus(err, "failed to upload file")
+	}
+	info := first.GetInfo()
+	if info == nil {
+		return status.Error(codes.InvalidArgument, "the first message must carry the file info")
+	}
+	if info.GetName() == "" {
+		return status.Error(codes.InvalidArgument, "file name is required")
+	}
+	var folderID *uint
+	if info.FolderId != nil {
+		id := uint(info.GetFolderId())
+		folderID = &id
+	}
+
+	ctx := stream.Context()
+	file, err := s.fileService.UploadFile(ctx, userID(ctx), folderID, info.GetName(), info.GetContentType(), &uploadReader{stream: stream})
+	if err != nil {
+		return toStatus(err, "failed to upload file")
+	}
+	return stream.SendAndClose(&filehubv1.UploadFileResponse{File: toFile(file)})
+}
+
+// DownloadFile streams the file info followed by the file's content.
+func (s *Server) DownloadFile(req *filehubv1.DownloadFileRequest, stream filehubv1.FileHub_DownloadFileServer) error {
+	ctx := stream.Context()
+
+	// The user must own the file or have it shared with them
+	file, err := s.fileService.GetFile(uint(req.GetId()), userID(ctx))
+	if err != nil {
+		return toStatus(err, "failed to read file")
+	}
+
+	content, err := s.fileService.OpenFile(ctx, file)
+	if err != nil {
+		return toStatus(err, "failed to read file")
+	}
+	defer content.Close()
+
+	if err := stream.Send(&filehubv1.DownloadFileResponse{Data: &filehubv1.DownloadFileResponse_Info{Info: toFile(file)}}); err != nil {
+		return err
+	}
+
+	buf := make([]byte, downloadChunkSize)
+	for {
+		n, err := content.Read(buf)
+		if n > 0 {
+			chunk := &filehubv1.DownloadFileResponse{Data: &filehubv1.DownloadFileResponse_Chunk{Chunk: buf[:n]}}
+			if err := stream.Send(chunk); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return toStatus(err, "failed to read file")
+		}
+	}
+}
+
+// DeleteFile moves a file to the trash.
+func (s *Server) DeleteFile(ctx context.Context, req *filehubv1.DeleteFileRequest) (*filehubv1.DeleteFileResponse, error) {
+	if err := s.fileService.DeleteFile(uint(req.GetId()), userID(ctx)); err != nil {
+		return nil, toStatus(err, "failed to delete file")
+	}
+	return &filehubv1.DeleteFileResponse{}, nil
+}
+
+// uploadReader reads the content chunks of an upload stream.
+type uploadReader struct {
+	stream filehubv1.FileHub_UploadFileServer
+	buf    []byte
+}
+
+func (r *uploadReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		req, err := r.stream.Recv()
+		if err != nil {
+			return 0, err // io.EOF once the client closes the stream
+		}
+		if req.GetInfo() != nil {
+			return 0, errUnexpectedInfo
+		}
+		r.buf = req.GetChunk()
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// toFile converts a File record into its protobuf message.
+func toFile(file *models.File) *filehubv1.File {
+	msg := &filehubv1.File{
+		Id:          uint64(file.ID),
+		Name:        file.FileName,
+		Size:        file.Size,
+		MimeType:    file.MimeType,
+		Version:     int32(file.Version),
+		ScanStatus:  file.ScanStatus,
+		Description: file.Description,
+		Tags:        file.Tags,
+		Properties:  file.Properties,
+		CreatedAt:   timestamp(file.CreatedAt),
+		UpdatedAt:   timestamp(file.UpdatedAt),
+	}
+	if file.FolderID != nil {
+		id := uint64(*file.FolderID)
+		msg.FolderId = &id
+	}
+	return msg
+}
+
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lskeey/go-filehub/internal/service"
+)
+
+// AccessKeyHandler manages the authenticated user's S3 access keys.
+type AccessKeyHandler struct {
+	accessKeyService *service.AccessKeyService
+}
+
+// NewAccessKeyHandler creates a new access key handler.
+func NewAccessKeyHandler(accessKeyService *service.AccessKeyService) *AccessKeyHandler {
+	return &AccessKeyHandler{accessKeyService: accessKeyService}
+}
+
+// CreateAccessKeyRequest defines the structure for the create access key request body.
+type CreateAccessKeyRequest struct {
+	Name string `json:"name" validate:"required,max=100"` // What the key is for, e.g. "Backup script"
+}
+
+// CreateAccessKey handles creating an S3 access key.
+//
+// @Summary Create an S3 access key
+// @Description Creates a key pair for the S3-compatible API. Configure S3 tools and SDKs with the access key ID and the secret access key to sign requests (AWS Signature Version 4). The secret is only shown in this response.
+// @Tags me
+// @Accept  json
+// @Produce  json
+// @Param   body  body      CreateAccessKeyRequest  true  "Access key info"
+// @Success 201   {object}  AccessKeyCreatedResponse
+// @Failure 400   {object}  ErrorResponse
+// @Failure 401   {object}  ErrorResponse
+// @Failure 500   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /me/access-keys [post]
+func (h *AccessKeyHandler) CreateAccessKey(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req CreateAccessKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	accessKey, err := h.accessKeyService.CreateAccessKey(userID.(uint), req.Name)
+	if err != nil {
+		respondError(c, err, "Failed to create access key")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":           "Access key created successfully",
+		"data":              accessKey,
+		"secret_access_key": accessKey.SecretKey,
+	})
+}
+
+// ListAccessKeys handles listing the user's S3 access keys.
+//
+// @Summary List S3 access keys
+// @Description Retrieves the authenticated user's S3 access keys, newest first, without their secrets.
+// @Tags me
+// @Produce  json
+// @Success 200   {object}  ListAccessKeysResponse
+// @Failure 401   {object}  ErrorResponse
+// @Failure 500   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /me/access-keys [get]
+func (h *AccessKeyHandler) ListAccessKeys(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	accessKeys, err := h.accessKeyService.ListAccessKeys(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not retrieve access keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": accessKeys})
+}
+
+// DeleteAccessKey handles revoking an S3 access key.
+//
+// @Summary Revoke an S3 access key
+// @Description Deletes one of the authenticated user's S3 access keys. Requests signed with it are rejected from then on.
+// @Tags me
+// @Produce  json
+// @Param   id    path      int  true  "Access key ID"
+// @Success 200   {object}  SuccessResponse
+// @Failure 400   {object}  ErrorResponse
+// @Failure 401   {object}  ErrorResponse
+// @Failure 404   {object}  ErrorResponse
+// @Failure 500   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /me/access-keys/{id} [delete]
+func (h *AccessKeyHandler) DeleteAccessKey(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid access key ID"})
+		return
+	}
+
+	if err := h.accessKeyService.DeleteAccessKey(uint(id), userID.(uint)); err != nil {
+		respondError(c, err, "Failed to revoke access key")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Access key revoked successfully"})
+}
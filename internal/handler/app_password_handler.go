@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lskeey/go-filehub/internal/service"
+)
+
+// AppPasswordHandler manages the authenticated user's app passwords.
+type AppPasswordHandler struct {
+	appPasswordService *service.AppPasswordService
+}
+
+// NewAppPasswordHandler creates a new app password handler.
+func NewAppPasswordHandler(appPasswordService *service.AppPasswordService) *AppPasswordHandler {
+	return &AppPasswordHandler{appPasswordService: appPasswordService}
+}
+
+// CreateAppPasswordRequest defines the structure for the create app password request body.
+type CreateAppPasswordRequest struct {
+	Name string `json:"name" validate:"required,max=100"` // What the password is for, e.g. "Laptop WebDAV"
+}
+
+// CreateAppPassword handles creating an app password.
+//
+// @Summary Create an app password
+// @Description Creates a random password for clients that only support Basic auth, such as WebDAV drives (/webdav). Log in with your email and this password. The password is only shown in this response; it can be revoked without changing the account password.
+// @Tags me
+// @Accept  json
+// @Produce  json
+// @Param   body  body      CreateAppPasswordRequest  true  "App password info"
+// @Success 201   {object}  AppPasswordCreatedResponse
+// @Failure 400   {object}  ErrorResponse
+// @Failure 401   {object}  ErrorResponse
+// @Failure 500   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /me/app-passwords [post]
+func (h *AppPasswordHandler) CreateAppPassword(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req CreateAppPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	appPassword, secret, err := h.appPasswordService.CreateAppPassword(userID.(uint), req.Name)
+	if err != nil {
+		respondError(c, err, "Failed to create app password")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "App password created successfully",
+		"data":     appPassword,
+		"password": secret,
+	})
+}
+
+// ListAppPasswords handles listing the user's app passwords.
+//
+// @Summary List app passwords
+// @Description Retrieves the authenticated user's app passwords, newest first. The passwords themselves are not stored and can't be shown again.
+// @Tags me
+// @Produce  json
+// @Success 200   {object}  ListAppPasswordsResponse
+// @Failure 401   {object}  ErrorResponse
+// @Failure 500   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /me/app-passwords [get]
+func (h *AppPasswordHandler) ListAppPasswords(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	appPasswords, err := h.appPasswordService.ListAppPasswords(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not retrieve app passwords"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": appPasswords})
+}
+
+// DeleteAppPassword handles revoking an app password.
+//
+// @Summary Revoke an app password
+// @Description Deletes one of the authenticated user's app passwords. Clients using it are logged out.
+// @Tags me
+// @Produce  json
+// @Param   id    path      int  true  "App password ID"
+// @Success 200   {object}  SuccessResponse
+// @Failure 400   {object}  ErrorResponse
+// @Failure 401   {object}  ErrorResponse
+// @Failure 404   {object}  ErrorResponse
+// @Failure 500   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /me/app-passwords/{id} [delete]
+func (h *AppPasswordHandler) DeleteAppPassword(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid app password ID"})
+		return
+	}
+
+	if err := h.appPasswordService.DeleteAppPassword(uint(id), userID.(uint)); err != nil {
+		respondError(c, err, "Failed to revoke app password")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "App password revoked successfully"})
+}
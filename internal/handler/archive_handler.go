@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"archive/zip"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lskeey/go-filehub/internal/models"
+	"github.com/lskeey/go-filehub/internal/service"
+)
+
+// ArchiveRequest defines the structure for the archive download request body.
+type ArchiveRequest struct {
+	FileIDs   []uint `json:"file_ids"`
+	FolderIDs []uint `json:"folder_ids"`
+	Name      string `json:"name" validate:"omitempty,max=200,excludesall=/\\"` // Archive name without .zip, defaults to "download"
+}
+
+// DownloadArchive handles downloading several files and folders as one ZIP archive.
+//
+// @Summary Download files and folders as a ZIP
+// @Description Streams a ZIP archive of the given files and folders, including everything inside the folders. The archive is built on the fly, so its size isn't known in advance. Requested files come first, then each folder sorted by name. Files and folders the user cannot view, and files that may not be downloaded because of the virus scan, are left out; X-Archive-Skipped tells how many. Duplicate names within a directory get a " (1)", " (2)", ... suffix.
+// @Tags files
+// @Accept  json
+// @Produce  application/zip
+// @Param   body  body      ArchiveRequest  true  "Files and folders to include"
+// @Success 200   {file}    file
+// @Failure 400   {object}  ErrorResponse
+// @Failure 401   {object}  ErrorResponse
+// @Failure 404   {object}  ErrorResponse
+// @Failure 413   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /files/archive [post]
+func (h *FileHandler) DownloadArchive(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req ArchiveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entries, skipped, err := h.fileService.Archive(userID.(uint), req.FileIDs, req.FolderIDs)
+	if err != nil {
+		respondError(c, err, "Failed to create archive")
+		return
+	}
+
+	name := req.Name
+	if name == "" {
+		name = "download"
+	}
+	header := c.Writer.Header()
+	header.Set("Content-Type", "application/zip")
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name + ".zip"}))
+	header.Set("X-Archive-Skipped", strconv.Itoa(skipped))
+	header.Set("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	// Once streaming has started the status can't change anymore. On errors
+	// the archive is cut off without its central directory, so clients see
+	// it as broken instead of silently missing files.
+	zw := zip.NewWriter(c.Writer)
+	for _, entry := range entries {
+		if entry.File == nil {
+			if _, err := zw.CreateHeader(&zip.FileHeader{Name: entry.Path, Method: zip.Store}); err != nil {
+				log.Printf("Failed to write archive: %v", err)
+				return
+			}
+			continue
+		}
+		if err := h.writeArchiveFile(c, zw, entry); err != nil {
+			log.Printf("Failed to write %s to archive: %v", entry.Path, err)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		log.Printf("Failed to finish archive: %v", err)
+	}
+}
+
+// writeArchiveFile streams a file's content into the archive.
+func (h *FileHandler) writeArchiveFile(c *gin.Context, zw *zip.Writer, entry service.ArchiveEntry) error {
+	content, err := h.fileService.OpenFile(c.Request.Context(), entry.File)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     entry.Path,
+		Method:   archiveMethod(entry.File),
+		Modified: entry.File.UpdatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, content)
+	return err
+}
+
+// archiveMethod stores content that is compressed already and deflates the rest.
+func archiveMethod(file *models.File) uint16 {
+	t := file.MimeType
+	switch {
+	case strings.HasPrefix(t, "image/") && t != "image/svg+xml" && t != "image/bmp",
+		strings.HasPrefix(t, "video/"),
+		strings.HasPrefix(t, "audio/") && t != "audio/wave",
+		strings.Contains(t, "zip"),
+		strings.Contains(t, "compressed"),
+		t == "application/x-bzip2", t == "application/x-xz":
+		return zip.Store
+	}
+	return zip.Deflate
+}
@@ -1,12 +1,24 @@
 package handler
 
+import "time"
+
 type FileResponse struct {
-	ID       uint   `json:"id"`
-	FileName string `json:"file_name"`
-	Size     int64  `json:"size"`
-	MimeType string `json:"mime_type"`
-	S3Path   string `json:"s3_path"`
-	OwnerID  uint   `json:"owner_id"`
+	ID               uint   `json:"id"`
+	FileName         string `json:"file_name"`
+	Size             int64  `json:"size"`
+	MimeType         string `json:"mime_type"`
+	DeclaredMimeType string `json:"declared_mime_type"`
+	S3Path           string `json:"s3_path"`
+	OwnerID          uint   `json:"owner_id"`
+	FolderID         *uint  `json:"folder_id"`
+	Version          int    `json:"version"`
+
+	Description string            `json:"description"`
+	Tags        []string          `json:"tags"`
+	Properties  map[string]string `json:"properties"`
+
+	ScanStatus string     `json:"scan_status" enums:"pending,clean,infected,error,too_large"`
+	ScannedAt  *time.Time `json:"scanned_at"`
 }
 
 type ErrorResponse struct {
@@ -26,6 +38,204 @@ type UploadSuccessResponse struct {
 	Data    FileResponse `json:"data"`
 }
 
+type ExtractResultResponse struct {
+	Path   string        `json:"path"`
+	Status string        `json:"status" enums:"created,updated,folder,skipped,failed"`
+	Error  string        `json:"error"` // Only for failed and some skipped entries
+	File   *FileResponse `json:"file"`  // Only for created and updated entries
+}
+
+type ExtractReportResponse struct {
+	Created int                     `json:"created"`
+	Updated int                     `json:"updated"`
+	Folders int                     `json:"folders"`
+	Skipped int                     `json:"skipped"`
+	Failed  int                     `json:"failed"`
+	Aborted string                  `json:"aborted"` // Set if extraction stopped early
+	Entries []ExtractResultResponse `json:"entries"`
+}
+
+type ExtractResponse struct {
+	Message string                `json:"message"`
+	Data    ExtractReportResponse `json:"data"`
+}
+
 type ListFilesResponse struct {
-	Data []FileResponse `json:"data"`
+	Data       []FileResponse `json:"data"`
+	NextCursor string         `json:"next_cursor"` // Empty on the last page
+}
+
+type FileVersionResponse struct {
+	ID         uint   `json:"id"`
+	FileID     uint   `json:"file_id"`
+	Version    int    `json:"version"`
+	Size       int64  `json:"size"`
+	MimeType   string `json:"mime_type"`
+	UploadedBy uint   `json:"uploaded_by"`
+	CreatedAt  string `json:"created_at"`
+	ScanStatus string `json:"scan_status" enums:"pending,clean,infected,error,too_large"`
+}
+
+type ListFileVersionsResponse struct {
+	Data []FileVersionResponse `json:"data"`
+}
+
+type PruneVersionsResponse struct {
+	Message string `json:"message"`
+	Pruned  int    `json:"pruned"`
+}
+
+type FolderResponse struct {
+	ID       uint   `json:"id"`
+	Name     string `json:"name"`
+	ParentID *uint  `json:"parent_id"`
+	OwnerID  uint   `json:"owner_id"`
+}
+
+type FolderSuccessResponse struct {
+	Message string         `json:"message"`
+	Data    FolderResponse `json:"data"`
+}
+
+type FolderChildren struct {
+	Folders []FolderResponse `json:"folders"`
+	Files   []FileResponse   `json:"files"`
+}
+
+type FolderChildrenResponse struct {
+	Data FolderChildren `json:"data"`
+}
+
+type ResolvePathResponse struct {
+	Type string      `json:"type"` // "file", "folder" or "root"
+	Data interface{} `json:"data"`
+}
+
+type UsageResponse struct {
+	Data struct {
+		UsedBytes      int64 `json:"used_bytes"`
+		QuotaBytes     int64 `json:"quota_bytes"`
+		RemainingBytes int64 `json:"remaining_bytes"`
+	} `json:"data"`
+}
+
+type ShareResponse struct {
+	ID         uint   `json:"id"`
+	OwnerID    uint   `json:"owner_id"`
+	GranteeID  uint   `json:"grantee_id"`
+	FileID     *uint  `json:"file_id"`
+	FolderID   *uint  `json:"folder_id"`
+	Permission string `json:"permission"` // "viewer" or "editor"
+}
+
+type ShareSuccessResponse struct {
+	Message string        `json:"message"`
+	Data    ShareResponse `json:"data"`
+}
+
+type SharedItemResponse struct {
+	Share        ShareResponse   `json:"share"`
+	OwnerEmail   string          `json:"owner_email"`
+	GranteeEmail string          `json:"grantee_email"`
+	File         *FileResponse   `json:"file,omitempty"`
+	Folder       *FolderResponse `json:"folder,omitempty"`
+}
+
+type ListSharesResponse struct {
+	Data []SharedItemResponse `json:"data"`
+}
+
+type LinkResponse struct {
+	ID            uint    `json:"id"`
+	Token         string  `json:"token"`
+	OwnerID       uint    `json:"owner_id"`
+	FileID        *uint   `json:"file_id"`
+	FolderID      *uint   `json:"folder_id"`
+	HasPassword   bool    `json:"has_password"`
+	ExpiresAt     *string `json:"expires_at"`
+	MaxDownloads  int     `json:"max_downloads"`
+	DownloadCount int     `json:"download_count"`
+	RevokedAt     *string `json:"revoked_at"`
+}
+
+type LinkSuccessResponse struct {
+	Message string       `json:"message"`
+	Data    LinkResponse `json:"data"`
+	URL     string       `json:"url"`
+}
+
+type ListLinksResponse struct {
+	Data []LinkResponse `json:"data"`
+}
+
+type LinkAccessResponse struct {
+	ID        uint   `json:"id"`
+	CreatedAt string `json:"created_at"`
+	FileID    *uint  `json:"file_id"`
+	Action    string `json:"action"` // "view" or "download"
+	Status    int    `json:"status"`
+	IP        string `json:"ip"`
+	UserAgent string `json:"user_agent"`
+}
+
+type ListLinkAccessesResponse struct {
+	Data []LinkAccessResponse `json:"data"`
+}
+
+type PublicLinkResponse struct {
+	Type string      `json:"type"` // "file" or "folder"
+	Data interface{} `json:"data"`
+}
+
+type SignedURLResponse struct {
+	Data struct {
+		URL       string `json:"url"`
+		ExpiresAt string `json:"expires_at"`
+	} `json:"data"`
+}
+
+type SearchResultResponse struct {
+	File    FileResponse `json:"file"`
+	Rank    float64      `json:"rank"`
+	Snippet string       `json:"snippet"`
+}
+
+type SearchResponse struct {
+	Data []SearchResultResponse `json:"data"`
+}
+
+type AppPasswordResponse struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Hint       string     `json:"hint"` // Last characters of the password
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+type AppPasswordCreatedResponse struct {
+	Message  string              `json:"message"`
+	Data     AppPasswordResponse `json:"data"`
+	Password string              `json:"password"` // Only returned once
+}
+
+type ListAppPasswordsResponse struct {
+	Data []AppPasswordResponse `json:"data"`
+}
+
+type AccessKeyResponse struct {
+	ID          uint       `json:"id"`
+	Name        string     `json:"name"`
+	AccessKeyID string     `json:"access_key_id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+}
+
+type AccessKeyCreatedResponse struct {
+	Message         string            `json:"message"`
+	Data            AccessKeyResponse `json:"data"`
+	SecretAccessKey string            `json:"secret_access_key"` // Only returned once
+}
+
+type ListAccessKeysResponse struct {
+	Data []AccessKeyResponse `json:"data"`
 }
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lskeey/go-filehub/internal/service"
+)
+
+// respondError maps service errors to HTTP responses. Errors without a
+// specific mapping are reported as a 500 with the fallback message, so
+// internal details are not leaked.
+func respondError(c *gin.Context, err error, fallback string) {
+	status := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		c.JSON(status, gin.H{"error": fallback})
+		return
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
+
+// errorStatus returns the HTTP status for a service error, or 500 for
+// errors without a specific mapping.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrFileNotFound),
+		errors.Is(err, service.ErrVersionNotFound),
+		errors.Is(err, service.ErrFolderNotFound),
+		errors.Is(err, service.ErrPathNotFound),
+		errors.Is(err, service.ErrShareNotFound),
+		errors.Is(err, service.ErrUserNotFound),
+		errors.Is(err, service.ErrLinkNotFound),
+		errors.Is(err, service.ErrNotInTrash),
+		errors.Is(err, service.ErrArchiveEmpty),
+		errors.Is(err, service.ErrAppPasswordNotFound),
+		errors.Is(err, service.ErrAccessKeyNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrFileForbidden),
+		errors.Is(err, service.ErrFolderForbidden),
+		errors.Is(err, service.ErrShareForbidden),
+		errors.Is(err, service.ErrLinkForbidden),
+		errors.Is(err, service.ErrSignedURLInvalid),
+		errors.Is(err, service.ErrFileInfected):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrNameConflict),
+		errors.Is(err, service.ErrFolderNotEmpty),
+		errors.Is(err, service.ErrFileNotScanned):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrInvalidPruneRule),
+		errors.Is(err, service.ErrInvalidName),
+		errors.Is(err, service.ErrInvalidMove),
+		errors.Is(err, service.ErrMoveAcrossOwners),
+		errors.Is(err, service.ErrInvalidShare),
+		errors.Is(err, service.ErrInvalidPermission),
+		errors.Is(err, service.ErrShareWithSelf),
+		errors.Is(err, service.ErrInvalidLink),
+		errors.Is(err, service.ErrNotAFileLink),
+		errors.Is(err, service.ErrInvalidSignedURLTTL),
+		errors.Is(err, service.ErrInvalidDisposition),
+		errors.Is(err, service.ErrInvalidSignedRoute),
+		errors.Is(err, service.ErrInvalidSearch),
+		errors.Is(err, service.ErrInvalidCursor),
+		errors.Is(err, service.ErrInvalidContentSearch),
+		errors.Is(err, service.ErrInvalidThumbnailSize),
+		errors.Is(err, service.ErrInvalidTag),
+		errors.Is(err, service.ErrInvalidDescription),
+		errors.Is(err, service.ErrInvalidProperty),
+		errors.Is(err, service.ErrInvalidArchive),
+		errors.Is(err, service.ErrArchiveCorrupt),
+		errors.Is(err, service.ErrInvalidAppPassword),
+		errors.Is(err, service.ErrInvalidAccessKey):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrLinkPasswordRequired):
+		return http.StatusUnauthorized
+	case errors.Is(err, service.ErrLinkExpired),
+		errors.Is(err, service.ErrLinkLimitReached),
+		errors.Is(err, service.ErrSignedURLExpired),
+		errors.Is(err, service.ErrSignedURLUsed):
+		return http.StatusGone
+	case errors.Is(err, service.ErrFileTooLarge),
+		errors.Is(err, service.ErrArchiveTooLarge),
+		errors.Is(err, service.ErrArchiveLimit):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, service.ErrFileTypeNotAllowed),
+		errors.Is(err, service.ErrArchiveUnsupported):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, service.ErrQuotaExceeded):
+		return http.StatusInsufficientStorage
+	case errors.Is(err, service.ErrSignedURLDisabled):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
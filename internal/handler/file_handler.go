@@ -1,43 +1,61 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lskeey/go-filehub/internal/models"
 	"github.com/lskeey/go-filehub/internal/service"
+	"github.com/lskeey/go-filehub/internal/storage"
 )
 
 type FileHandler struct {
-	fileService *service.FileService
+	fileService    *service.FileService
+	extractService *service.ExtractService
 }
 
-func NewFileHandler(s *service.FileService) *FileHandler {
-	return &FileHandler{fileService: s}
+func NewFileHandler(s *service.FileService, extractService *service.ExtractService) *FileHandler {
+	return &FileHandler{fileService: s, extractService: extractService}
 }
 
+// multipartOverhead is the allowance for multipart boundaries and part headers
+// on top of the file size when checking the request's Content-Length.
+const multipartOverhead = 64 * 1024
+
 // UploadFile handles the file upload request.
+// The multipart body is streamed straight to storage without being buffered.
 //
 // @Summary Upload a file
-// @Description Uploads a file for the authenticated user. Max file size is 10MB.
+// @Description Uploads a file for the authenticated user. The maximum file size is configured per deployment (MAX_UPLOAD_SIZE). Editors of a shared folder can upload into it; the file then belongs to the folder's owner. The MIME type is detected from the content (MimeType), next to the one the client declared (DeclaredMimeType). Types or extensions blocked by the upload policy are rejected with 415.
+// @Description
+// @Description With extract=true the upload must be a ZIP or tar.gz archive, which is expanded into files and folders inside folder_id instead of being stored itself. Archives over the configured entry count, expanded size or compression ratio are rejected with 413 before anything is created, and entries with absolute paths or ".." are refused. Each file is uploaded like a single upload, so the type policy, quota and versioning apply per entry; the response reports the outcome of every entry (ExtractResponse).
 // @Tags files
 // @Accept  multipart/form-data
 // @Produce  json
-// @Param   file  formData  file  true  "File to upload"
+// @Param   file       formData  file  true   "File to upload"
+// @Param   folder_id  query     int   false  "Folder to upload into (root if omitted). Uploading an existing name adds a new version."
+// @Param   extract    query     bool  false  "Expand a ZIP or tar.gz archive into files and folders"
 // @Success 200   {object}  UploadSuccessResponse
 // @Failure 400   {object}  ErrorResponse
 // @Failure 401   {object}  ErrorResponse
+// @Failure 403   {object}  ErrorResponse
+// @Failure 404   {object}  ErrorResponse
+// @Failure 409   {object}  ErrorResponse
+// @Failure 413   {object}  ErrorResponse
+// @Failure 415   {object}  ErrorResponse
+// @Failure 507   {object}  ErrorResponse
 // @Failure 500   {object}  ErrorResponse
 // @Security BearerAuth
 // @Router /files/upload [post]
 func (h *FileHandler) UploadFile(c *gin.Context) {
-	// Retrieve the file from the form data
-	fileHeader, err := c.FormFile("file")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
-		return
-	}
-
 	// Retrieve userID from the context (set by the AuthMiddleware)
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -45,18 +63,49 @@ func (h *FileHandler) UploadFile(c *gin.Context) {
 		return
 	}
 
-	// Here you can add validation for file size and type
-	// For example: max size 10MB
-	const maxFileSize = 10 * 1024 * 1024
-	if fileHeader.Size > maxFileSize {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "File size exceeds the limit of 10MB"})
+	folderID, err := parseOptionalID(c.Query("folder_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid folder ID"})
+		return
+	}
+
+	extract, err := strconv.ParseBool(c.DefaultQuery("extract", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid extract flag"})
+		return
+	}
+
+	part, ok := h.openFilePart(c)
+	if !ok {
 		return
 	}
+	defer part.Close()
 
-	// Call the service to handle the file upload
-	fileMetadata, err := h.fileService.UploadFile(c, fileHeader, userID.(uint))
+	if extract {
+		report, err := h.extractService.Extract(c.Request.Context(), userID.(uint), folderID, part)
+		if err != nil {
+			if isTooLarge(err) {
+				h.respondTooLarge(c)
+				return
+			}
+			respondError(c, err, "Failed to extract archive")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Archive extracted",
+			"data":    report,
+		})
+		return
+	}
+
+	// Call the service to stream the file to storage
+	fileMetadata, err := h.fileService.UploadFile(c.Request.Context(), userID.(uint), folderID, part.FileName(), part.Header.Get("Content-Type"), part)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload file"})
+		if isTooLarge(err) {
+			h.respondTooLarge(c)
+			return
+		}
+		respondError(c, err, "Failed to upload file")
 		return
 	}
 
@@ -66,13 +115,87 @@ func (h *FileHandler) UploadFile(c *gin.Context) {
 	})
 }
 
-// ListFiles handles listing all files for the authenticated user.
+// openFilePart limits the request body to the upload size and returns the
+// multipart part holding the file. It writes the error response itself.
+func (h *FileHandler) openFilePart(c *gin.Context) (*multipart.Part, bool) {
+	// Reject oversized requests before reading the body
+	maxBodySize := h.fileService.MaxUploadSize() + multipartOverhead
+	if c.Request.ContentLength > maxBodySize {
+		h.respondTooLarge(c)
+		return nil, false
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBodySize)
+
+	part, err := nextFilePart(c.Request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+		return nil, false
+	}
+	return part, true
+}
+
+func (h *FileHandler) respondTooLarge(c *gin.Context) {
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("File size exceeds the limit of %d bytes", h.fileService.MaxUploadSize())})
+}
+
+// isTooLarge reports whether an upload failed because it exceeded the size limit.
+func isTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.Is(err, service.ErrFileTooLarge) || errors.As(err, &maxBytesErr)
+}
+
+// parseOptionalID parses an optional numeric ID. An empty value yields nil.
+func parseOptionalID(value string) (*uint, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	result := uint(id)
+	return &result, nil
+}
+
+// nextFilePart advances a multipart request to the part holding the "file" field.
+func nextFilePart(r *http.Request) (*multipart.Part, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == "file" && part.FileName() != "" {
+			return part, nil
+		}
+		part.Close()
+	}
+}
+
+// ListFiles handles listing and searching the authenticated user's files.
 //
 // @Summary List user's files
-// @Description Retrieves a list of all files uploaded by the authenticated user.
+// @Description Retrieves one page of the files owned by the authenticated user, optionally filtered and sorted. Pass next_cursor from a response as cursor to get the following page, keeping the other parameters unchanged.
 // @Tags files
 // @Produce  json
+// @Param   q               query     string  false  "Case-insensitive substring of the name"
+// @Param   prefix          query     string  false  "Case-insensitive prefix of the name"
+// @Param   mime_type       query     string  false  "Exact MIME type, or a wildcard like image/*"
+// @Param   min_size        query     int     false  "Minimum size in bytes"
+// @Param   max_size        query     int     false  "Maximum size in bytes"
+// @Param   created_after   query     string  false  "Only files created at or after this time (RFC 3339)"
+// @Param   created_before  query     string  false  "Only files created before this time (RFC 3339)"
+// @Param   tag             query     []string  false  "Only files with this tag; repeat to require several"  collectionFormat(multi)
+// @Param   property        query     []string  false  "key:value for files with that property value, or key for files with the property; repeatable"  collectionFormat(multi)
+// @Param   sort            query     string  false  "name, size or created_at (default)"
+// @Param   order           query     string  false  "asc or desc (default)"
+// @Param   limit           query     int     false  "Page size, 1-200 (default 50)"
+// @Param   cursor          query     string  false  "Cursor from the previous page"
 // @Success 200   {object}  ListFilesResponse
+// @Failure 400   {object}  ErrorResponse
 // @Failure 401   {object}  ErrorResponse
 // @Failure 500   {object}  ErrorResponse
 // @Security BearerAuth
@@ -80,26 +203,111 @@ func (h *FileHandler) UploadFile(c *gin.Context) {
 func (h *FileHandler) ListFiles(c *gin.Context) {
 	userID, _ := c.Get("userID")
 
-	files, err := h.fileService.ListUserFiles(userID.(uint))
+	search, ok := parseFileSearch(c)
+	if !ok {
+		return
+	}
+
+	files, next, err := h.fileService.SearchFiles(userID.(uint), search)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not retrieve files"})
+		respondError(c, err, "Could not retrieve files")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": files})
+	c.JSON(http.StatusOK, gin.H{"data": files, "next_cursor": next})
+}
+
+// parseFileSearch reads the search parameters of a file listing. It writes
+// the error response itself.
+func parseFileSearch(c *gin.Context) (service.FileSearch, bool) {
+	search := service.FileSearch{
+		Query:      c.Query("q"),
+		Prefix:     c.Query("prefix"),
+		MimeType:   c.Query("mime_type"),
+		Sort:       c.Query("sort"),
+		Order:      c.Query("order"),
+		Cursor:     c.Query("cursor"),
+		Tags:       c.QueryArray("tag"),
+		Properties: c.QueryArray("property"),
+	}
+
+	var err error
+	if search.MinSize, err = parseOptionalInt64(c.Query("min_size")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid min_size"})
+		return search, false
+	}
+	if search.MaxSize, err = parseOptionalInt64(c.Query("max_size")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid max_size"})
+		return search, false
+	}
+	if search.CreatedAfter, err = parseOptionalTime(c.Query("created_after")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid created_after, expected RFC 3339"})
+		return search, false
+	}
+	if search.CreatedBefore, err = parseOptionalTime(c.Query("created_before")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid created_before, expected RFC 3339"})
+		return search, false
+	}
+	if limit := c.Query("limit"); limit != "" {
+		if search.Limit, err = strconv.Atoi(limit); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return search, false
+		}
+	}
+	return search, true
+}
+
+// parseOptionalInt64 parses an optional integer. An empty value yields nil.
+func parseOptionalInt64(value string) (*int64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// parseOptionalTime parses an optional RFC 3339 time. An empty value yields nil.
+func parseOptionalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
 }
 
 // DownloadFile handles serving a specific file for download.
+// Range requests, If-Range and conditional requests are supported.
 //
 // @Summary Download a file
-// @Description Downloads a specific file by its ID. The user must own the file.
+// @Description Downloads a specific file by its ID. The user must own the file or have it shared with them. Instead of a JWT, the parameters of a signed download URL (see POST /files/{id}/signed-url) are accepted; a single-use URL is only used up once the content is served. Files in which malware was found are refused, and while virus scanning is enabled so are files not scanned clean yet. Supports Range (single and multiple ranges), If-Range, If-None-Match and If-Modified-Since. Content is served with X-Content-Type-Options: nosniff and, except for PDFs, a sandboxing Content-Security-Policy; HTML, SVG, XML and JavaScript are always served as attachments, even for inline signed URLs.
 // @Tags files
 // @Produce  application/octet-stream
-// @Param   id    path      int  true  "File ID"
+// @Param   id                 path      int     true   "File ID"
+// @Param   Range              header    string  false  "Byte range(s), e.g. bytes=0-1023"
+// @Param   If-None-Match      header    string  false  "ETag of a cached copy"
+// @Param   If-Modified-Since  header    string  false  "Last-Modified of a cached copy"
+// @Param   If-Range           header    string  false  "ETag or date the Range is conditional on"
+// @Param   uid                query     int     false  "Signed URL: signing user"
+// @Param   expires            query     int     false  "Signed URL: expiry (Unix time)"
+// @Param   nonce              query     string  false  "Signed URL: single-use nonce"
+// @Param   disposition        query     string  false  "Signed URL: attachment or inline"
+// @Param   kid                query     string  false  "Signed URL: signing key ID"
+// @Param   sig                query     string  false  "Signed URL: signature"
 // @Success 200   {file}    file
+// @Success 206   {file}    file
+// @Success 304
 // @Failure 400   {object}  ErrorResponse
 // @Failure 403   {object}  ErrorResponse
 // @Failure 404   {object}  ErrorResponse
+// @Failure 409   {object}  ErrorResponse
+// @Failure 410   {object}  ErrorResponse
+// @Failure 416
 // @Security BearerAuth
 // @Router /files/{id}/download [get]
 func (h *FileHandler) DownloadFile(c *gin.Context) {
@@ -111,26 +319,94 @@ func (h *FileHandler) DownloadFile(c *gin.Context) {
 		return
 	}
 
-	file, err := h.fileService.GetFileByID(uint(fileID))
+	// The user must own the file or have it shared with them
+	file, err := h.fileService.GetFile(uint(fileID), userID.(uint))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		respondError(c, err, "Failed to read file")
 		return
 	}
 
-	// Authorization check
-	if file.OwnerID != userID.(uint) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You are not authorized to download this file"})
+	content, err := h.fileService.OpenFile(c.Request.Context(), file)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "File content not found"})
+			return
+		}
+		respondError(c, err, "Failed to read file")
 		return
 	}
+	defer content.Close()
+	if !consumeSignedURL(c) {
+		return
+	}
+
+	// Signed URLs may ask for the file to be shown inline
+	disposition := c.GetString("disposition")
+	if disposition == "" {
+		disposition = "attachment"
+	}
+	serveFileContent(c, file, content, disposition)
+}
+
+// serveFileContent writes a file's content with validators derived from the
+// File record. http.ServeContent takes care of Range, If-Range and the
+// conditional request headers, independent of the storage backend.
+//
+// Content is user-controlled and served from the API's origin, so browsers
+// must neither sniff it nor run scripts in it: types that can carry script
+// are always downloaded, and everything else is sandboxed.
+func serveFileContent(c *gin.Context, file *models.File, content io.ReadSeeker, disposition string) {
+	header := c.Writer.Header()
+	contentType := file.MimeType
+	if contentType == "" {
+		// ServeContent would guess from the name and content instead
+		contentType = "application/octet-stream"
+	}
+	if activeContent(contentType) {
+		disposition = "attachment"
+	}
+	header.Set("Content-Type", contentType)
+	header.Set("X-Content-Type-Options", "nosniff")
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != "application/pdf" {
+		// Browsers refuse to show PDFs in a sandbox; their viewer doesn't
+		// run script in the page's origin
+		header.Set("Content-Security-Policy", "sandbox; default-src 'none'; img-src 'self' data:; style-src 'unsafe-inline'; media-src 'self'")
+	}
+	header.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": file.FileName}))
+	header.Set("ETag", fileETag(file))
+	header.Set("Cache-Control", "private, no-cache")
+
+	http.ServeContent(c.Writer, c.Request, file.FileName, file.UpdatedAt, content)
+}
+
+// activeContent reports whether a content type can run script when a
+// browser displays it, such as HTML, SVG and other XML documents.
+func activeContent(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return true
+	}
+	switch mediaType {
+	case "text/html", "text/xml", "application/xml", "text/xsl",
+		"text/javascript", "application/javascript", "application/ecmascript":
+		return true
+	}
+	return strings.HasSuffix(mediaType, "+xml")
+}
 
-	// Serve the file for download
-	c.FileAttachment(file.S3Path, file.FileName)
+// fileETag builds a strong entity tag from the content digest, or from the
+// record's identity for files stored before content addressing.
+func fileETag(file *models.File) string {
+	if file.BlobDigest != "" {
+		return `"` + file.BlobDigest + `"`
+	}
+	return fmt.Sprintf(`"%d-%x-%x"`, file.ID, file.UpdatedAt.UnixNano(), file.Size)
 }
 
 // DeleteFile handles the deletion of a specific file.
 //
 // @Summary Delete a file
-// @Description Deletes a specific file by its ID. The user must own the file.
+// @Description Moves a specific file to the trash. The user must own the file or be an editor of it.
 // @Tags files
 // @Produce  json
 // @Param   id    path      int  true  "File ID"
@@ -151,13 +427,158 @@ func (h *FileHandler) DeleteFile(c *gin.Context) {
 
 	err = h.fileService.DeleteFile(uint(fileID), userID.(uint))
 	if err != nil {
-		if err.Error() == "unauthorized: you do not own this file" {
+		if errors.Is(err, service.ErrFileForbidden) {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
+		if errors.Is(err, service.ErrFileNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete file"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "File deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "File moved to trash"})
+}
+
+// MoveFileRequest defines the structure for the move file request body.
+type MoveFileRequest struct {
+	FolderID *uint `json:"folder_id"` // null moves the file to the root
+}
+
+// RenameFile handles renaming a file.
+//
+// @Summary Rename a file
+// @Description Changes a file's name. Names must be unique within the folder. The user must own the file or be an editor of it.
+// @Tags files
+// @Accept  json
+// @Produce  json
+// @Param   id    path      int            true  "File ID"
+// @Param   body  body      RenameRequest  true  "New name"
+// @Success 200   {object}  UploadSuccessResponse
+// @Failure 400   {object}  ErrorResponse
+// @Failure 403   {object}  ErrorResponse
+// @Failure 404   {object}  ErrorResponse
+// @Failure 409   {object}  ErrorResponse
+// @Failure 415   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /files/{id}/rename [patch]
+func (h *FileHandler) RenameFile(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	fileID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file ID"})
+		return
+	}
+
+	var req RenameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	file, err := h.fileService.RenameFile(uint(fileID), userID.(uint), req.Name)
+	if err != nil {
+		respondError(c, err, "Failed to rename file")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "File renamed successfully", "data": file})
+}
+
+// MoveFile handles moving a file into another folder.
+//
+// @Summary Move a file
+// @Description Moves a file into a folder, or to the root when folder_id is null. The user must own the file or be an editor of it and of the target folder; files cannot be moved to another user's folders.
+// @Tags files
+// @Accept  json
+// @Produce  json
+// @Param   id    path      int              true  "File ID"
+// @Param   body  body      MoveFileRequest  true  "Target folder"
+// @Success 200   {object}  UploadSuccessResponse
+// @Failure 400   {object}  ErrorResponse
+// @Failure 403   {object}  ErrorResponse
+// @Failure 404   {object}  ErrorResponse
+// @Failure 409   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /files/{id}/move [patch]
+func (h *FileHandler) MoveFile(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	fileID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file ID"})
+		return
+	}
+
+	var req MoveFileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	file, err := h.fileService.MoveFile(uint(fileID), userID.(uint), req.FolderID)
+	if err != nil {
+		respondError(c, err, "Failed to move file")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "File moved successfully", "data": file})
+}
+
+// UpdateMetadataRequest defines the structure for the metadata update body.
+// Omitted fields are left unchanged.
+type UpdateMetadataRequest struct {
+	Tags        *[]string          `json:"tags"`        // Replaces all tags; [] removes them
+	Description *string            `json:"description"` // Replaces the description
+	Properties  map[string]*string `json:"properties"`  // Merged into the properties; null removes a key
+}
+
+// UpdateMetadata handles changing the tags, description and properties of a file.
+//
+// @Summary Update file metadata
+// @Description Sets the tags, description and custom key/value properties of a file. Omitted fields are left unchanged. Tags replace the current ones and are stored lower-cased; properties are merged into the current ones, and a null value removes a key. The user must own the file or be an editor of it.
+// @Tags files
+// @Accept  json
+// @Produce  json
+// @Param   id    path      int                    true  "File ID"
+// @Param   body  body      UpdateMetadataRequest  true  "Metadata changes"
+// @Success 200   {object}  UploadSuccessResponse
+// @Failure 400   {object}  ErrorResponse
+// @Failure 403   {object}  ErrorResponse
+// @Failure 404   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /files/{id}/metadata [patch]
+func (h *FileHandler) UpdateMetadata(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	fileID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file ID"})
+		return
+	}
+
+	var req UpdateMetadataRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	file, err := h.fileService.UpdateMetadata(uint(fileID), userID.(uint), service.FileMetadataUpdate{
+		Tags:        req.Tags,
+		Description: req.Description,
+		Properties:  req.Properties,
+	})
+	if err != nil {
+		respondError(c, err, "Failed to update file metadata")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "File metadata updated successfully", "data": file})
 }
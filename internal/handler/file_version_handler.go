@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lskeey/go-filehub/internal/storage"
+)
+
+// PruneVersionsRequest defines the structure for the prune versions request body.
+type PruneVersionsRequest struct {
+	Keep          int `json:"keep" validate:"omitempty,min=1"`            // Keep at least this many newest versions
+	OlderThanDays int `json:"older_than_days" validate:"omitempty,min=1"` // Only prune versions older than this
+}
+
+// ListVersions handles listing the version history of a file.
+//
+// @Summary List file versions
+// @Description Retrieves all versions of a file, newest first. The user must own the file or have it shared with them.
+// @Tags versions
+// @Produce  json
+// @Param   id    path      int  true  "File ID"
+// @Success 200   {object}  ListFileVersionsResponse
+// @Failure 400   {object}  ErrorResponse
+// @Failure 403   {object}  ErrorResponse
+// @Failure 404   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /files/{id}/versions [get]
+func (h *FileHandler) ListVersions(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	fileID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file ID"})
+		return
+	}
+
+	versions, err := h.fileService.ListVersions(uint(fileID), userID.(uint))
+	if err != nil {
+		respondError(c, err, "Could not retrieve versions")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": versions})
+}
+
+// UploadVersion handles uploading new content for an existing file.
+//
+// @Summary Upload a new version
+// @Description Uploads new content for an existing file and makes it the current version. The user must own the file or be an editor of it.
+// @Tags versions
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param   id    path      int   true  "File ID"
+// @Param   file  formData  file  true  "New content"
+// @Success 200   {object}  UploadSuccessResponse
+// @Failure 400   {object}  ErrorResponse
+// @Failure 403   {object}  ErrorResponse
+// @Failure 404   {object}  ErrorResponse
+// @Failure 413   {object}  ErrorResponse
+// @Failure 415   {object}  ErrorResponse
+// @Failure 507   {object}  ErrorResponse
+// @Failure 500   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /files/{id}/versions [post]
+func (h *FileHandler) UploadVersion(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	fileID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file ID"})
+		return
+	}
+
+	part, ok := h.openFilePart(c)
+	if !ok {
+		return
+	}
+	defer part.Close()
+
+	file, err := h.fileService.UploadVersion(c.Request.Context(), uint(fileID), userID.(uint), part.Header.Get("Content-Type"), part)
+	if err != nil {
+		if isTooLarge(err) {
+			h.respondTooLarge(c)
+			return
+		}
+		respondError(c, err, "Failed to upload version")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Version uploaded successfully",
+		"data":    file,
+	})
+}
+
+// DownloadVersion handles serving a specific version of a file.
+//
+// @Summary Download a file version
+// @Description Downloads the content of a specific version. Versions whose content is quarantined because malware was found in it are refused, and while virus scanning is enabled so are versions not scanned clean yet. Supports the same Range and conditional headers as the file download.
+// @Tags versions
+// @Produce  application/octet-stream
+// @Param   id       path      int  true  "File ID"
+// @Param   version  path      int  true  "Version number"
+// @Success 200      {file}    file
+// @Success 206      {file}    file
+// @Failure 400      {object}  ErrorResponse
+// @Failure 403      {object}  ErrorResponse
+// @Failure 404      {object}  ErrorResponse
+// @Failure 409      {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /files/{id}/versions/{version}/download [get]
+func (h *FileHandler) DownloadVersion(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	fileID, version, ok := parseVersionParams(c)
+	if !ok {
+		return
+	}
+
+	file, err := h.fileService.GetFile(fileID, userID.(uint))
+	if err != nil {
+		respondError(c, err, "Failed to read version")
+		return
+	}
+
+	v, err := h.fileService.GetVersion(fileID, userID.(uint), version)
+	if err != nil {
+		respondError(c, err, "Failed to read version")
+		return
+	}
+
+	content, err := h.fileService.OpenVersion(c.Request.Context(), file, v)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Version content not found"})
+			return
+		}
+		respondError(c, err, "Failed to read version")
+		return
+	}
+	defer content.Close()
+
+	// Serve the version with the file's name and the version's own validators
+	versionFile := *file
+	versionFile.Size = v.Size
+	versionFile.MimeType = v.MimeType
+	versionFile.BlobDigest = v.BlobDigest
+	versionFile.UpdatedAt = v.CreatedAt
+	serveFileContent(c, &versionFile, content, "attachment")
+}
+
+// RestoreVersion handles making an older version current again.
+//
+// @Summary Restore a file version
+// @Description Makes the given version current by recording a copy of it as a new version. The user must own the file or be an editor of it.
+// @Tags versions
+// @Produce  json
+// @Param   id       path      int  true  "File ID"
+// @Param   version  path      int  true  "Version number"
+// @Success 200      {object}  UploadSuccessResponse
+// @Failure 400      {object}  ErrorResponse
+// @Failure 403      {object}  ErrorResponse
+// @Failure 404      {object}  ErrorResponse
+// @Failure 507      {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /files/{id}/versions/{version}/restore [post]
+func (h *FileHandler) RestoreVersion(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	fileID, version, ok := parseVersionParams(c)
+	if !ok {
+		return
+	}
+
+	file, err := h.fileService.RestoreVersion(c.Request.Context(), fileID, userID.(uint), version)
+	if err != nil {
+		respondError(c, err, "Failed to restore version")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Version restored successfully",
+		"data":    file,
+	})
+}
+
+// PruneVersions handles deleting old versions of a file.
+//
+// @Summary Prune file versions
+// @Description Permanently deletes old versions. With "keep", all but the newest N versions are pruned; with "older_than_days", only versions older than that are pruned; with both, a version must match both rules. The current version is never pruned. Only the owner can prune versions.
+// @Tags versions
+// @Accept  json
+// @Produce  json
+// @Param   id     path      int                   true  "File ID"
+// @Param   rules  body      PruneVersionsRequest  true  "Prune rules"
+// @Success 200    {object}  PruneVersionsResponse
+// @Failure 400    {object}  ErrorResponse
+// @Failure 403    {object}  ErrorResponse
+// @Failure 404    {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /files/{id}/versions/prune [post]
+func (h *FileHandler) PruneVersions(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	fileID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file ID"})
+		return
+	}
+
+	var req PruneVersionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	olderThan := time.Duration(req.OlderThanDays) * 24 * time.Hour
+	pruned, err := h.fileService.PruneVersions(c.Request.Context(), uint(fileID), userID.(uint), req.Keep, olderThan)
+	if err != nil {
+		respondError(c, err, "Failed to prune versions")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Versions pruned successfully", "pruned": pruned})
+}
+
+// parseVersionParams reads the file ID and version number from the path.
+func parseVersionParams(c *gin.Context) (uint, int, bool) {
+	fileID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file ID"})
+		return 0, 0, false
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+		return 0, 0, false
+	}
+	return uint(fileID), version, true
+}
@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lskeey/go-filehub/internal/service"
+)
+
+// FolderHandler handles folder-related HTTP requests.
+type FolderHandler struct {
+	folderService *service.FolderService
+}
+
+// NewFolderHandler creates a new folder handler.
+func NewFolderHandler(s *service.FolderService) *FolderHandler {
+	return &FolderHandler{folderService: s}
+}
+
+// CreateFolderRequest defines the structure for the create folder request body.
+type CreateFolderRequest struct {
+	Name     string `json:"name" validate:"required"`
+	ParentID *uint  `json:"parent_id"` // Omit or null for the root
+}
+
+// RenameRequest defines the structure for the rename request body of files and folders.
+type RenameRequest struct {
+	Name string `json:"name" validate:"required"`
+}
+
+// MoveFolderRequest defines the structure for the move folder request body.
+type MoveFolderRequest struct {
+	ParentID *uint `json:"parent_id"` // null moves the folder to the root
+}
+
+// CreateFolder handles creating a new folder.
+//
+// @Summary Create a folder
+// @Description Creates a folder inside the given parent folder, or at the root. Names must be unique within a folder.
+// @Tags folders
+// @Accept  json
+// @Produce  json
+// @Param   folder  body      CreateFolderRequest  true  "Folder Info"
+// @Success 201     {object}  FolderSuccessResponse
+// @Failure 400     {object}  ErrorResponse
+// @Failure 403     {object}  ErrorResponse
+// @Failure 404     {object}  ErrorResponse
+// @Failure 409     {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /folders [post]
+func (h *FolderHandler) CreateFolder(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req CreateFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	folder, err := h.folderService.CreateFolder(userID.(uint), req.Name, req.ParentID)
+	if err != nil {
+		respondError(c, err, "Failed to create folder")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Folder created successfully", "data": folder})
+}
+
+// ListChildren handles listing the content of a folder.
+//
+// @Summary List a folder's children
+// @Description Retrieves the folders and files directly inside a folder. Use "root" as the ID for the top level. Folders shared with the user can be listed too.
+// @Tags folders
+// @Produce  json
+// @Param   id    path      string  true  "Folder ID or \"root\""
+// @Success 200   {object}  FolderChildrenResponse
+// @Failure 400   {object}  ErrorResponse
+// @Failure 403   {object}  ErrorResponse
+// @Failure 404   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /folders/{id}/children [get]
+func (h *FolderHandler) ListChildren(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var parentID *uint
+	if c.Param("id") != "root" {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid folder ID"})
+			return
+		}
+		folderID := uint(id)
+		parentID = &folderID
+	}
+
+	folders, files, err := h.folderService.ListChildren(userID.(uint), parentID)
+	if err != nil {
+		respondError(c, err, "Could not retrieve folder content")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"folders": folders, "files": files}})
+}
+
+// RenameFolder handles renaming a folder.
+//
+// @Summary Rename a folder
+// @Description Changes a folder's name. Names must be unique within the parent folder.
+// @Tags folders
+// @Accept  json
+// @Produce  json
+// @Param   id    path      int            true  "Folder ID"
+// @Param   body  body      RenameRequest  true  "New name"
+// @Success 200   {object}  FolderSuccessResponse
+// @Failure 400   {object}  ErrorResponse
+// @Failure 403   {object}  ErrorResponse
+// @Failure 404   {object}  ErrorResponse
+// @Failure 409   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /folders/{id}/rename [patch]
+func (h *FolderHandler) RenameFolder(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	folderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid folder ID"})
+		return
+	}
+
+	var req RenameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	folder, err := h.folderService.RenameFolder(uint(folderID), userID.(uint), req.Name)
+	if err != nil {
+		respondError(c, err, "Failed to rename folder")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Folder renamed successfully", "data": folder})
+}
+
+// MoveFolder handles moving a folder to another parent.
+//
+// @Summary Move a folder
+// @Description Moves a folder into another folder, or to the root when parent_id is null. Folders cannot be moved to another user's folders.
+// @Tags folders
+// @Accept  json
+// @Produce  json
+// @Param   id    path      int                true  "Folder ID"
+// @Param   body  body      MoveFolderRequest  true  "New parent"
+// @Success 200   {object}  FolderSuccessResponse
+// @Failure 400   {object}  ErrorResponse
+// @Failure 403   {object}  ErrorResponse
+// @Failure 404   {object}  ErrorResponse
+// @Failure 409   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /folders/{id}/move [patch]
+func (h *FolderHandler) MoveFolder(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	folderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid folder ID"})
+		return
+	}
+
+	var req MoveFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	folder, err := h.folderService.MoveFolder(uint(folderID), userID.(uint), req.ParentID)
+	if err != nil {
+		respondError(c, err, "Failed to move folder")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Folder moved successfully", "data": folder})
+}
+
+// DeleteFolder handles deleting a folder.
+//
+// @Summary Delete a folder
+// @Description Moves a folder to the trash. Non-empty folders are only deleted, together with everything inside them, when recursive=true.
+// @Tags folders
+// @Produce  json
+// @Param   id         path      int   true   "Folder ID"
+// @Param   recursive  query     bool  false  "Delete the folder's content as well"
+// @Success 200        {object}  SuccessResponse
+// @Failure 400        {object}  ErrorResponse
+// @Failure 403        {object}  ErrorResponse
+// @Failure 404        {object}  ErrorResponse
+// @Failure 409        {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /folders/{id} [delete]
+func (h *FolderHandler) DeleteFolder(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	folderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid folder ID"})
+		return
+	}
+
+	recursive := c.Query("recursive") == "true"
+	if err := h.folderService.DeleteFolder(uint(folderID), userID.(uint), recursive); err != nil {
+		respondError(c, err, "Failed to delete folder")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Folder moved to trash"})
+}
+
+// ResolvePath handles looking up a file or folder by its path.
+//
+// @Summary Resolve a path
+// @Description Looks up a slash-separated path such as /projects/2024/report.pdf and returns the folder or file it points to.
+// @Tags folders
+// @Produce  json
+// @Param   path  query     string  true  "Path to resolve"
+// @Success 200   {object}  ResolvePathResponse
+// @Failure 400   {object}  ErrorResponse
+// @Failure 404   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /folders/resolve [get]
+func (h *FolderHandler) ResolvePath(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	path, ok := c.GetQuery("path")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Path is required"})
+		return
+	}
+
+	folder, file, err := h.folderService.ResolvePath(userID.(uint), path)
+	if err != nil {
+		respondError(c, err, "Failed to resolve path")
+		return
+	}
+
+	switch {
+	case file != nil:
+		c.JSON(http.StatusOK, gin.H{"type": "file", "data": file})
+	case folder != nil:
+		c.JSON(http.StatusOK, gin.H{"type": "folder", "data": folder})
+	default:
+		c.JSON(http.StatusOK, gin.H{"type": "root", "data": nil})
+	}
+}
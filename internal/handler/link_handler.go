@@ -0,0 +1,387 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lskeey/go-filehub/internal/models"
+	"github.com/lskeey/go-filehub/internal/service"
+	"github.com/lskeey/go-filehub/internal/storage"
+)
+
+// linkPasswordHeader carries the password of a password-protected public link.
+const linkPasswordHeader = "X-Link-Password"
+
+// LinkHandler handles public links: managing them for their owner and
+// serving them to anyone holding the token.
+type LinkHandler struct {
+	linkService *service.LinkService
+	fileService *service.FileService
+}
+
+// NewLinkHandler creates a new public link handler.
+func NewLinkHandler(linkService *service.LinkService, fileService *service.FileService) *LinkHandler {
+	return &LinkHandler{linkService: linkService, fileService: fileService}
+}
+
+// CreateLinkRequest defines the structure for the create link request body.
+// Exactly one of FileID and FolderID must be set; everything else is optional.
+type CreateLinkRequest struct {
+	FileID       *uint      `json:"file_id"`
+	FolderID     *uint      `json:"folder_id"`
+	ExpiresAt    *time.Time `json:"expires_at"` // RFC 3339, omit for no expiry
+	Password     string     `json:"password"`
+	MaxDownloads int        `json:"max_downloads" validate:"gte=0"` // 0 for unlimited
+}
+
+// CreateLink handles creating a public link.
+//
+// @Summary Create a public link
+// @Description Creates a link that gives anyone holding it access to a file or folder owned by the authenticated user, with an optional expiry time, password and download limit.
+// @Tags links
+// @Accept  json
+// @Produce  json
+// @Param   link  body      CreateLinkRequest  true  "Link Info"
+// @Success 201   {object}  LinkSuccessResponse
+// @Failure 400   {object}  ErrorResponse
+// @Failure 403   {object}  ErrorResponse
+// @Failure 404   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /links [post]
+func (h *LinkHandler) CreateLink(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req CreateLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	link, err := h.linkService.CreateLink(userID.(uint), req.FileID, req.FolderID, req.ExpiresAt, req.Password, req.MaxDownloads)
+	if err != nil {
+		respondError(c, err, "Failed to create link")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Link created successfully",
+		"data":    link,
+		"url":     "/api/v1/public/links/" + link.Token,
+	})
+}
+
+// ListLinks handles listing the user's public links.
+//
+// @Summary List public links
+// @Description Retrieves the public links created by the authenticated user, including expired and revoked ones, optionally limited to one file or folder.
+// @Tags links
+// @Produce  json
+// @Param   file_id    query     int  false  "Only links to this file"
+// @Param   folder_id  query     int  false  "Only links to this folder"
+// @Success 200        {object}  ListLinksResponse
+// @Failure 400        {object}  ErrorResponse
+// @Failure 500        {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /links [get]
+func (h *LinkHandler) ListLinks(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	fileID, err := parseOptionalID(c.Query("file_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file ID"})
+		return
+	}
+	folderID, err := parseOptionalID(c.Query("folder_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid folder ID"})
+		return
+	}
+
+	links, err := h.linkService.ListLinks(userID.(uint), fileID, folderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not retrieve links"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": links})
+}
+
+// RevokeLink handles revoking a public link.
+//
+// @Summary Revoke a public link
+// @Description Disables a public link. Its access log is kept.
+// @Tags links
+// @Produce  json
+// @Param   id    path      int  true  "Link ID"
+// @Success 200   {object}  SuccessResponse
+// @Failure 400   {object}  ErrorResponse
+// @Failure 403   {object}  ErrorResponse
+// @Failure 404   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /links/{id} [delete]
+func (h *LinkHandler) RevokeLink(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	linkID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid link ID"})
+		return
+	}
+
+	if err := h.linkService.RevokeLink(uint(linkID), userID.(uint)); err != nil {
+		respondError(c, err, "Failed to revoke link")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Link revoked"})
+}
+
+// ListAccesses handles listing the access log of a public link.
+//
+// @Summary List link accesses
+// @Description Retrieves every request made through a public link, newest first.
+// @Tags links
+// @Produce  json
+// @Param   id    path      int  true  "Link ID"
+// @Success 200   {object}  ListLinkAccessesResponse
+// @Failure 400   {object}  ErrorResponse
+// @Failure 403   {object}  ErrorResponse
+// @Failure 404   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /links/{id}/accesses [get]
+func (h *LinkHandler) ListAccesses(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	linkID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid link ID"})
+		return
+	}
+
+	accesses, err := h.linkService.ListAccesses(uint(linkID), userID.(uint))
+	if err != nil {
+		respondError(c, err, "Could not retrieve link accesses")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": accesses})
+}
+
+// ViewLink handles showing what a public link points to.
+//
+// @Summary View a public link
+// @Description Describes the file behind a file link, or lists a folder behind a folder link. Subfolders of a folder link are listed with folder_id. No account is needed.
+// @Tags public
+// @Produce  json
+// @Param   token            path      string  true   "Link token"
+// @Param   folder_id        query     int     false  "Subfolder of a folder link"
+// @Param   X-Link-Password  header    string  false  "Password of a protected link"
+// @Success 200              {object}  PublicLinkResponse
+// @Failure 400              {object}  ErrorResponse
+// @Failure 401              {object}  ErrorResponse
+// @Failure 404              {object}  ErrorResponse
+// @Failure 410              {object}  ErrorResponse
+// @Router /public/links/{token} [get]
+func (h *LinkHandler) ViewLink(c *gin.Context) {
+	link, err := h.linkService.FindLink(c.Param("token"))
+	if err != nil {
+		respondError(c, err, "Failed to open link")
+		return
+	}
+	defer h.recordAccess(c, link, nil, service.LinkActionView)
+
+	if err := h.linkService.CheckLink(link, c.GetHeader(linkPasswordHeader)); err != nil {
+		respondError(c, err, "Failed to open link")
+		return
+	}
+
+	if link.FileID != nil {
+		file, err := h.linkService.LinkFile(link, nil)
+		if err != nil {
+			respondError(c, err, "Failed to open link")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"type": "file", "data": publicFile(file)})
+		return
+	}
+
+	folderID, err := parseOptionalID(c.Query("folder_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid folder ID"})
+		return
+	}
+	folder, folders, files, err := h.linkService.LinkFolder(link, folderID)
+	if err != nil {
+		respondError(c, err, "Failed to open link")
+		return
+	}
+
+	childFolders := make([]gin.H, 0, len(folders))
+	for i := range folders {
+		childFolders = append(childFolders, publicFolder(&folders[i]))
+	}
+	childFiles := make([]gin.H, 0, len(files))
+	for i := range files {
+		childFiles = append(childFiles, publicFile(&files[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"type": "folder", "data": gin.H{
+		"folder":  publicFolder(folder),
+		"folders": childFolders,
+		"files":   childFiles,
+	}})
+}
+
+// DownloadLink handles downloading a file through a public link.
+// Range and conditional requests are supported like on regular downloads.
+//
+// @Summary Download through a public link
+// @Description Streams the file behind a file link, or the file selected with file_id inside a folder link. Every GET request that serves content (200 or 206, including single Range requests) counts towards the link's download limit; HEAD and requests answered with 304 do not. No account is needed. Files are refused like on the authenticated download while they are quarantined or not scanned yet.
+// @Tags public
+// @Produce  application/octet-stream
+// @Param   token            path      string  true   "Link token"
+// @Param   file_id          query     int     false  "File inside a folder link"
+// @Param   X-Link-Password  header    string  false  "Password of a protected link"
+// @Param   Range            header    string  false  "Byte range(s), e.g. bytes=0-1023"
+// @Success 200              {file}    file
+// @Success 206              {file}    file
+// @Failure 400              {object}  ErrorResponse
+// @Failure 401              {object}  ErrorResponse
+// @Failure 403              {object}  ErrorResponse
+// @Failure 404              {object}  ErrorResponse
+// @Failure 409              {object}  ErrorResponse
+// @Failure 410              {object}  ErrorResponse
+// @Router /public/links/{token}/download [get]
+func (h *LinkHandler) DownloadLink(c *gin.Context) {
+	link, err := h.linkService.FindLink(c.Param("token"))
+	if err != nil {
+		respondError(c, err, "Failed to open link")
+		return
+	}
+	var file *models.File
+	defer func() {
+		var fileID *uint
+		if file != nil {
+			fileID = &file.ID
+		}
+		h.recordAccess(c, link, fileID, service.LinkActionDownload)
+	}()
+
+	if err := h.linkService.CheckLink(link, c.GetHeader(linkPasswordHeader)); err != nil {
+		respondError(c, err, "Failed to open link")
+		return
+	}
+
+	fileID, err := parseOptionalID(c.Query("file_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file ID"})
+		return
+	}
+	file, err = h.linkService.LinkFile(link, fileID)
+	if err != nil {
+		respondError(c, err, "Failed to open link")
+		return
+	}
+
+	content, err := h.fileService.OpenFile(c.Request.Context(), file)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "File content not found"})
+			return
+		}
+		respondError(c, err, "Failed to read file")
+		return
+	}
+	defer content.Close()
+
+	// Only the response status tells whether content is served, so the
+	// download is counted when it is written
+	if c.Request.Method == http.MethodGet {
+		c.Writer = &downloadCounter{ResponseWriter: c.Writer, count: func() error { return h.linkService.CountDownload(link) }}
+	}
+
+	serveFileContent(c, file, content, "attachment")
+}
+
+// recordAccess logs the finished request in the link's access log.
+func (h *LinkHandler) recordAccess(c *gin.Context, link *models.PublicLink, fileID *uint, action string) {
+	h.linkService.RecordAccess(link, fileID, action, c.Writer.Status(), c.ClientIP(), c.Request.UserAgent())
+}
+
+// downloadCounter counts a link download when a response starts serving
+// content, i.e. with a 200 or 206, so every range of a file counts and
+// 304s and errors don't. Once the link's limit is reached, the error is
+// sent instead and the content is dropped.
+type downloadCounter struct {
+	gin.ResponseWriter
+	count   func() error
+	err     error
+	counted bool
+}
+
+func (w *downloadCounter) WriteHeader(status int) {
+	if !w.counted {
+		w.counted = true
+		if status == http.StatusOK || status == http.StatusPartialContent {
+			w.err = w.count()
+		}
+		if w.err != nil {
+			message := w.err.Error()
+			status = errorStatus(w.err)
+			if status == http.StatusInternalServerError {
+				message = "Failed to open link"
+			}
+			header := w.Header()
+			for _, name := range []string{"Content-Range", "Content-Length", "Content-Disposition", "ETag", "Last-Modified", "Accept-Ranges"} {
+				header.Del(name)
+			}
+			header.Set("Content-Type", "application/json; charset=utf-8")
+			w.ResponseWriter.WriteHeader(status)
+			_ = json.NewEncoder(w.ResponseWriter).Encode(gin.H{"error": message})
+			return
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *downloadCounter) Write(b []byte) (int, error) {
+	if !w.counted {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.err != nil {
+		return 0, w.err
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *downloadCounter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// publicFile describes a file to visitors of a public link without
+// exposing storage details or its owner.
+func publicFile(file *models.File) gin.H {
+	return gin.H{
+		"id":         file.ID,
+		"file_name":  file.FileName,
+		"size":       file.Size,
+		"mime_type":  file.MimeType,
+		"updated_at": file.UpdatedAt,
+	}
+}
+
+// publicFolder describes a folder to visitors of a public link.
+func publicFolder(folder *models.Folder) gin.H {
+	return gin.H{
+		"id":         folder.ID,
+		"name":       folder.Name,
+		"updated_at": folder.UpdatedAt,
+	}
+}
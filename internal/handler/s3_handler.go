@@ -0,0 +1,621 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lskeey/go-filehub/internal/service"
+	"github.com/lskeey/go-filehub/internal/sigv4"
+	"github.com/lskeey/go-filehub/internal/storage"
+)
+
+// S3Methods lists the HTTP methods the S3-compatible API handles.
+var S3Methods = []string{http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPost, http.MethodDelete}
+
+// maxS3XMLBody bounds the XML request bodies of DeleteObjects and
+// CompleteMultipartUpload.
+const maxS3XMLBody = 2 << 20
+
+// maxDeleteObjects is the most keys a single DeleteObjects request may name.
+const maxDeleteObjects = 1000
+
+// S3Handler serves a subset of the S3 REST API over the authenticated
+// user's files, so S3 tools and SDKs can use FileHub. Only path-style
+// requests (http://host/bucket/key) are supported.
+type S3Handler struct {
+	s3Service        *service.S3Service
+	accessKeyService *service.AccessKeyService
+	region           string
+}
+
+// NewS3Handler creates a new S3 handler reporting region as the location of
+// every bucket.
+func NewS3Handler(s3Service *service.S3Service, accessKeyService *service.AccessKeyService, region string) *S3Handler {
+	if region == "" {
+		region = "us-east-1"
+	}
+	return &S3Handler{s3Service: s3Service, accessKeyService: accessKeyService, region: region}
+}
+
+// s3Error is an error response of the S3 API.
+type s3Error struct {
+	status  int
+	code    string
+	message string
+}
+
+// s3Errors maps service and signing errors to S3 error codes. Errors not
+// listed here fall back to the status of errorStatus.
+var s3Errors = []struct {
+	err error
+	s3Error
+}{
+	{service.ErrNoSuchBucket, s3Error{http.StatusNotFound, "NoSuchBucket", ""}},
+	{service.ErrNoSuchKey, s3Error{http.StatusNotFound, "NoSuchKey", ""}},
+	{service.ErrNoSuchUpload, s3Error{http.StatusNotFound, "NoSuchUpload", ""}},
+	{service.ErrInvalidBucketName, s3Error{http.StatusBadRequest, "InvalidBucketName", ""}},
+	{service.ErrBucketAlreadyOwned, s3Error{http.StatusConflict, "BucketAlreadyOwnedByYou", ""}},
+	{service.ErrBucketNotEmpty, s3Error{http.StatusConflict, "BucketNotEmpty", ""}},
+	{service.ErrInvalidPart, s3Error{http.StatusBadRequest, "InvalidPart", ""}},
+	{service.ErrInvalidPartOrder, s3Error{http.StatusBadRequest, "InvalidPartOrder", ""}},
+	{service.ErrFileTooLarge, s3Error{http.StatusBadRequest, "EntityTooLarge", ""}},
+	{service.ErrQuotaExceeded, s3Error{http.StatusForbidden, "QuotaExceeded", ""}},
+	{service.ErrFileInfected, s3Error{http.StatusForbidden, "InvalidObjectState", ""}},
+	{service.ErrFileNotScanned, s3Error{http.StatusForbidden, "InvalidObjectState", ""}},
+	{service.ErrFileTypeNotAllowed, s3Error{http.StatusForbidden, "AccessDenied", ""}},
+	{service.ErrUnknownAccessKey, s3Error{http.StatusForbidden, "InvalidAccessKeyId", ""}},
+	{sigv4.ErrMissingAuth, s3Error{http.StatusForbidden, "AccessDenied", ""}},
+	{sigv4.ErrSignatureMismatch, s3Error{http.StatusForbidden, "SignatureDoesNotMatch", ""}},
+	{sigv4.ErrRequestExpired, s3Error{http.StatusForbidden, "RequestTimeTooSkewed", ""}},
+	{sigv4.ErrMalformedAuth, s3Error{http.StatusBadRequest, "AuthorizationHeaderMalformed", ""}},
+	{sigv4.ErrUnsupported, s3Error{http.StatusBadRequest, "InvalidRequest", ""}},
+	{sigv4.ErrPayloadMismatch, s3Error{http.StatusBadRequest, "BadDigest", ""}},
+	{storage.ErrNotFound, s3Error{http.StatusNotFound, "NoSuchKey", "The object's content was not found"}},
+}
+
+var (
+	errS3NotImplemented   = s3Error{http.StatusNotImplemented, "NotImplemented", "A header or query parameter you provided implies functionality that is not implemented"}
+	errS3MethodNotAllowed = s3Error{http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed against this resource"}
+)
+
+// Authenticate verifies the AWS Signature Version 4 of a request, signed in
+// the Authorization header or as a pre-signed URL, with one of the user's
+// access keys. The request body is replaced by one that is checked against
+// the signed payload hash while it is read.
+func (h *S3Handler) Authenticate(c *gin.Context) {
+	var userID uint
+	auth, err := sigv4.Verify(c.Request, time.Now(), func(accessKeyID string) (string, error) {
+		key, err := h.accessKeyService.LookupAccessKey(accessKeyID)
+		if err != nil {
+			return "", err
+		}
+		userID = key.UserID
+		return key.SecretKey, nil
+	})
+	if err != nil {
+		h.abort(c, err)
+		return
+	}
+	payload, err := auth.Body(c.Request)
+	if err != nil {
+		h.abort(c, err)
+		return
+	}
+
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{payload, c.Request.Body}
+	c.Set("userID", userID)
+	c.Set("s3Payload", payload)
+	c.Next()
+}
+
+// Serve dispatches an S3 request by method, path and query parameters.
+func (h *S3Handler) Serve(c *gin.Context) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(c.Param("path"), "/"), "/")
+	query := c.Request.URL.Query()
+
+	switch {
+	case bucket == "":
+		if c.Request.Method != http.MethodGet {
+			h.respond(c, errS3MethodNotAllowed)
+			return
+		}
+		h.listBuckets(c)
+	case key == "":
+		if !supportedQuery(query, "location", "list-type", "prefix", "delimiter", "max-keys", "marker",
+			"continuation-token", "start-after", "encoding-type", "fetch-owner", "delete") {
+			h.respond(c, errS3NotImplemented)
+			return
+		}
+		switch c.Request.Method {
+		case http.MethodGet:
+			if query.Has("location") {
+				h.getBucketLocation(c, bucket)
+			} else {
+				h.listObjects(c, bucket)
+			}
+		case http.MethodHead:
+			h.headBucket(c, bucket)
+		case http.MethodPut:
+			h.createBucket(c, bucket)
+		case http.MethodDelete:
+			h.deleteBucket(c, bucket)
+		case http.MethodPost:
+			if !query.Has("delete") {
+				h.respond(c, errS3NotImplemented)
+				return
+			}
+			h.deleteObjects(c, bucket)
+		}
+	default:
+		if !supportedQuery(query, "uploads", "uploadId", "partNumber",
+			"response-content-type", "response-content-disposition", "response-cache-control") ||
+			c.GetHeader("X-Amz-Copy-Source") != "" {
+			h.respond(c, errS3NotImplemented)
+			return
+		}
+		uploadID := query.Get("uploadId")
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead:
+			if query.Has("uploadId") || query.Has("partNumber") {
+				h.respond(c, errS3NotImplemented)
+				return
+			}
+			h.getObject(c, bucket, key)
+		case http.MethodPut:
+			if uploadID != "" {
+				h.uploadPart(c, bucket, key, uploadID)
+			} else {
+				h.putObject(c, bucket, key)
+			}
+		case http.MethodPost:
+			switch {
+			case query.Has("uploads"):
+				h.createMultipartUpload(c, bucket, key)
+			case uploadID != "":
+				h.completeMultipartUpload(c, bucket, key, uploadID)
+			default:
+				h.respond(c, errS3NotImplemented)
+			}
+		case http.MethodDelete:
+			if uploadID != "" {
+				h.abortMultipartUpload(c, bucket, key, uploadID)
+			} else {
+				h.deleteObject(c, bucket, key)
+			}
+		}
+	}
+}
+
+func (h *S3Handler) listBuckets(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	folders, err := h.s3Service.ListBuckets(userID.(uint))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	id := strconv.FormatUint(uint64(userID.(uint)), 10)
+	result := s3ListBucketsResult{Xmlns: s3Namespace, Owner: s3Owner{ID: id, DisplayName: id}, Buckets: []s3Bucket{}}
+	for _, folder := range folders {
+		result.Buckets = append(result.Buckets, s3Bucket{Name: folder.Name, CreationDate: folder.CreatedAt.UTC().Format(s3TimeFormat)})
+	}
+	h.respondXML(c, http.StatusOK, result)
+}
+
+func (h *S3Handler) getBucketLocation(c *gin.Context, bucket string) {
+	userID, _ := c.Get("userID")
+
+	if err := h.s3Service.HeadBucket(userID.(uint), bucket); err != nil {
+		h.respondError(c, err)
+		return
+	}
+	// S3 reports its original region as an empty constraint
+	region := h.region
+	if region == "us-east-1" {
+		region = ""
+	}
+	h.respondXML(c, http.StatusOK, s3LocationConstraint{Xmlns: s3Namespace, Region: region})
+}
+
+func (h *S3Handler) headBucket(c *gin.Context, bucket string) {
+	userID, _ := c.Get("userID")
+
+	if err := h.s3Service.HeadBucket(userID.(uint), bucket); err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.Header("X-Amz-Bucket-Region", h.region)
+	c.Status(http.StatusOK)
+}
+
+func (h *S3Handler) createBucket(c *gin.Context, bucket string) {
+	userID, _ := c.Get("userID")
+
+	if _, err := h.s3Service.CreateBucket(userID.(uint), bucket); err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.Header("Location", "/"+bucket)
+	c.Status(http.StatusOK)
+}
+
+func (h *S3Handler) deleteBucket(c *gin.Context, bucket string) {
+	userID, _ := c.Get("userID")
+
+	if err := h.s3Service.DeleteBucket(userID.(uint), bucket); err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// listObjects serves ListObjectsV2 (list-type=2) and the original ListObjects.
+func (h *S3Handler) listObjects(c *gin.Context, bucket string) {
+	userID, _ := c.Get("userID")
+	query := c.Request.URL.Query()
+	v2 := query.Get("list-type") == "2"
+
+	opts := service.S3ListOptions{
+		Prefix:    query.Get("prefix"),
+		Delimiter: query.Get("delimiter"),
+		MaxKeys:   service.MaxListKeys,
+	}
+	if v := query.Get("max-keys"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			h.respond(c, s3Error{http.StatusBadRequest, "InvalidArgument", "max-keys must be a non-negative integer"})
+			return
+		}
+		// S3 silently caps larger pages
+		opts.MaxKeys = min(n, service.MaxListKeys)
+	}
+	encodingType := query.Get("encoding-type")
+	if encodingType != "" && encodingType != "url" {
+		h.respond(c, s3Error{http.StatusBadRequest, "InvalidArgument", "Invalid Encoding Method specified in Request"})
+		return
+	}
+
+	token := query.Get("continuation-token")
+	if v2 {
+		opts.StartAfter = query.Get("start-after")
+		if token != "" {
+			after, err := base64.RawURLEncoding.DecodeString(token)
+			if err != nil {
+				h.respond(c, s3Error{http.StatusBadRequest, "InvalidArgument", "The continuation token provided is incorrect"})
+				return
+			}
+			opts.StartAfter = string(after)
+		}
+	} else {
+		opts.StartAfter = query.Get("marker")
+	}
+
+	list, err := h.s3Service.ListObjects(userID.(uint), bucket, opts)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	encode := func(s string) string {
+		if encodingType == "url" {
+			return sigv4.Escape(s, true)
+		}
+		return s
+	}
+	result := s3ListBucketResult{
+		Xmlns:        s3Namespace,
+		Name:         bucket,
+		Prefix:       encode(opts.Prefix),
+		Delimiter:    encode(opts.Delimiter),
+		MaxKeys:      opts.MaxKeys,
+		EncodingType: encodingType,
+		IsTruncated:  list.IsTruncated,
+	}
+	for _, object := range list.Objects {
+		result.Contents = append(result.Contents, s3Contents{
+			Key:          encode(object.Key),
+			LastModified: object.LastModified().UTC().Format(s3TimeFormat),
+			ETag:         object.ETag(),
+			Size:         object.Size(),
+			StorageClass: "STANDARD",
+		})
+	}
+	for _, prefix := range list.CommonPrefixes {
+		result.CommonPrefixes = append(result.CommonPrefixes, s3CommonPrefix{Prefix: encode(prefix)})
+	}
+	if v2 {
+		keyCount := len(list.Objects) + len(list.CommonPrefixes)
+		result.KeyCount = &keyCount
+		result.ContinuationToken = token
+		result.StartAfter = encode(query.Get("start-after"))
+		if list.IsTruncated {
+			result.NextContinuationToken = base64.RawURLEncoding.EncodeToString([]byte(list.LastKey))
+		}
+	} else {
+		marker := encode(opts.StartAfter)
+		result.Marker = &marker
+		if list.IsTruncated {
+			result.NextMarker = encode(list.LastKey)
+		}
+	}
+	h.respondXML(c, http.StatusOK, result)
+}
+
+// getObject serves GetObject and HeadObject, including Range and
+// conditional requests.
+func (h *S3Handler) getObject(c *gin.Context, bucket, key string) {
+	userID, _ := c.Get("userID")
+
+	object, err := h.s3Service.GetObject(userID.(uint), bucket, key)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	content, err := h.s3Service.OpenObject(c.Request.Context(), object)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	defer content.Close()
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", object.ContentType())
+	header.Set("ETag", object.ETag())
+	for name, value := range object.Metadata() {
+		header.Set("X-Amz-Meta-"+name, value)
+	}
+	// Pre-signed URLs may override some response headers
+	query := c.Request.URL.Query()
+	for param, name := range map[string]string{
+		"response-content-type":        "Content-Type",
+		"response-content-disposition": "Content-Disposition",
+		"response-cache-control":       "Cache-Control",
+	} {
+		if v := query.Get(param); v != "" {
+			header.Set(name, v)
+		}
+	}
+
+	http.ServeContent(c.Writer, c.Request, "", object.LastModified(), content)
+}
+
+func (h *S3Handler) putObject(c *gin.Context, bucket, key string) {
+	userID, _ := c.Get("userID")
+
+	object, err := h.s3Service.PutObject(c.Request.Context(), userID.(uint), bucket, key,
+		c.GetHeader("Content-Type"), objectMetadata(c.Request.Header), c.Request.Body)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.Header("ETag", object.ETag())
+	c.Status(http.StatusOK)
+}
+
+func (h *S3Handler) deleteObject(c *gin.Context, bucket, key string) {
+	userID, _ := c.Get("userID")
+
+	if err := h.s3Service.DeleteObject(userID.(uint), bucket, key); err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *S3Handler) deleteObjects(c *gin.Context, bucket string) {
+	userID, _ := c.Get("userID")
+
+	var req s3DeleteRequest
+	if !h.bindXML(c, &req) {
+		return
+	}
+	if len(req.Objects) > maxDeleteObjects {
+		h.respond(c, s3Error{http.StatusBadRequest, "MalformedXML", "At most 1000 keys can be deleted at once"})
+		return
+	}
+	if err := h.s3Service.HeadBucket(userID.(uint), bucket); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	result := s3DeleteResult{Xmlns: s3Namespace}
+	for _, object := range req.Objects {
+		if err := h.s3Service.DeleteObject(userID.(uint), bucket, object.Key); err != nil {
+			s3Err := h.toS3Error(c, err)
+			result.Errors = append(result.Errors, s3DeleteError{Key: object.Key, Code: s3Err.code, Message: s3Err.message})
+			continue
+		}
+		if !req.Quiet {
+			result.Deleted = append(result.Deleted, s3Deleted{Key: object.Key})
+		}
+	}
+	h.respondXML(c, http.StatusOK, result)
+}
+
+func (h *S3Handler) createMultipartUpload(c *gin.Context, bucket, key string) {
+	userID, _ := c.Get("userID")
+
+	upload, err := h.s3Service.CreateMultipartUpload(userID.(uint), bucket, key,
+		c.GetHeader("Content-Type"), objectMetadata(c.Request.Header))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	h.respondXML(c, http.StatusOK, s3InitiateMultipartUploadResult{Xmlns: s3Namespace, Bucket: bucket, Key: key, UploadID: upload.ID})
+}
+
+func (h *S3Handler) uploadPart(c *gin.Context, bucket, key, uploadID string) {
+	userID, _ := c.Get("userID")
+
+	partNumber, err := strconv.Atoi(c.Query("partNumber"))
+	if err != nil || partNumber < 1 || partNumber > service.MaxPartNumber {
+		h.respond(c, s3Error{http.StatusBadRequest, "InvalidArgument", "Part number must be an integer between 1 and 10000, inclusive"})
+		return
+	}
+
+	etag, err := h.s3Service.UploadPart(userID.(uint), uploadID, bucket, key, partNumber, c.Request.Body)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.Header("ETag", etag)
+	c.Status(http.StatusOK)
+}
+
+func (h *S3Handler) completeMultipartUpload(c *gin.Context, bucket, key, uploadID string) {
+	userID, _ := c.Get("userID")
+
+	var req s3CompleteMultipartUpload
+	if !h.bindXML(c, &req) {
+		return
+	}
+	parts := make([]service.CompletedPart, len(req.Parts))
+	for i, part := range req.Parts {
+		parts[i] = service.CompletedPart{PartNumber: part.PartNumber, ETag: part.ETag}
+	}
+
+	object, err := h.s3Service.CompleteMultipartUpload(c.Request.Context(), userID.(uint), uploadID, bucket, key, parts)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	h.respondXML(c, http.StatusOK, s3CompleteMultipartUploadResult{
+		Xmlns:    s3Namespace,
+		Location: "/" + bucket + "/" + sigv4.Escape(key, true),
+		Bucket:   bucket,
+		Key:      key,
+		ETag:     object.ETag(),
+	})
+}
+
+func (h *S3Handler) abortMultipartUpload(c *gin.Context, bucket, key, uploadID string) {
+	userID, _ := c.Get("userID")
+
+	if err := h.s3Service.AbortMultipartUpload(userID.(uint), uploadID, bucket, key); err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// bindXML decodes a small XML request body, responding with MalformedXML if
+// it can't be read.
+func (h *S3Handler) bindXML(c *gin.Context, v interface{}) bool {
+	err := xml.NewDecoder(io.LimitReader(c.Request.Body, maxS3XMLBody)).Decode(v)
+	if err == nil {
+		return true
+	}
+	if payload, ok := c.Get("s3Payload"); ok && payload.(*sigv4.Payload).Err() != nil {
+		h.respondError(c, payload.(*sigv4.Payload).Err())
+		return false
+	}
+	h.respond(c, s3Error{http.StatusBadRequest, "MalformedXML", "The XML you provided was not well-formed or did not validate against our published schema"})
+	return false
+}
+
+// abort rejects a request that could not be authenticated.
+func (h *S3Handler) abort(c *gin.Context, err error) {
+	h.respondError(c, err)
+	c.Abort()
+}
+
+// respondError writes the S3 error response for err. A body that failed
+// its integrity check takes precedence over whatever error the read caused.
+func (h *S3Handler) respondError(c *gin.Context, err error) {
+	h.respond(c, h.toS3Error(c, err))
+}
+
+func (h *S3Handler) toS3Error(c *gin.Context, err error) s3Error {
+	if payload, ok := c.Get("s3Payload"); ok && payload.(*sigv4.Payload).Err() != nil {
+		err = payload.(*sigv4.Payload).Err()
+	}
+	for _, mapping := range s3Errors {
+		if errors.Is(err, mapping.err) {
+			if mapping.message == "" {
+				mapping.message = err.Error()
+			}
+			return mapping.s3Error
+		}
+	}
+
+	switch status := errorStatus(err); status {
+	case http.StatusBadRequest:
+		return s3Error{status, "InvalidArgument", err.Error()}
+	case http.StatusForbidden:
+		return s3Error{status, "AccessDenied", err.Error()}
+	case http.StatusNotFound:
+		return s3Error{status, "NoSuchKey", err.Error()}
+	case http.StatusConflict:
+		return s3Error{status, "OperationAborted", err.Error()}
+	case http.StatusRequestEntityTooLarge:
+		return s3Error{http.StatusBadRequest, "EntityTooLarge", err.Error()}
+	case http.StatusInternalServerError:
+		log.Printf("S3 %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		return s3Error{status, "InternalError", "We encountered an internal error. Please try again."}
+	default:
+		return s3Error{status, "InvalidRequest", err.Error()}
+	}
+}
+
+func (h *S3Handler) respond(c *gin.Context, e s3Error) {
+	if c.Request.Method == http.MethodHead {
+		c.Status(e.status)
+		return
+	}
+	h.respondXML(c, e.status, s3ErrorResponse{Code: e.code, Message: e.message, Resource: c.Request.URL.Path})
+}
+
+func (h *S3Handler) respondXML(c *gin.Context, status int, v interface{}) {
+	body, err := xml.Marshal(v)
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	c.Data(status, "application/xml", append([]byte(xml.Header), body...))
+}
+
+// objectMetadata collects the x-amz-meta-* headers of a request, keyed by
+// the lower-case name without the prefix.
+func objectMetadata(header http.Header) map[string]string {
+	metadata := make(map[string]string)
+	for name, values := range header {
+		if name, ok := strings.CutPrefix(strings.ToLower(name), "x-amz-meta-"); ok && len(values) > 0 {
+			metadata[name] = values[0]
+		}
+	}
+	return metadata
+}
+
+// supportedQuery reports whether every query parameter, apart from those of
+// pre-signed URLs, is one of the given ones. Other parameters name S3
+// features that aren't implemented, such as ACLs or versioning.
+func supportedQuery(query map[string][]string, allowed ...string) bool {
+	for name := range query {
+		// SDKs add x-id, naming the operation, to some requests
+		if strings.HasPrefix(name, "X-Amz-") || name == "x-id" {
+			continue
+		}
+		supported := false
+		for _, a := range allowed {
+			if name == a {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return false
+		}
+	}
+	return true
+}
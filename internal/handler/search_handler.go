@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lskeey/go-filehub/internal/service"
+)
+
+// SearchHandler handles full-text search over file content.
+type SearchHandler struct {
+	searchService *service.ContentSearchService
+}
+
+// NewSearchHandler creates a new search handler.
+func NewSearchHandler(s *service.ContentSearchService) *SearchHandler {
+	return &SearchHandler{searchService: s}
+}
+
+// Search handles searching the text inside files.
+//
+// @Summary Search file content
+// @Description Searches the text of plain text, Markdown, CSV, HTML and PDF files the user owns or that are shared with them. Results are ranked by relevance and include snippets with matches wrapped in <mark>. The query supports "quoted phrases", or and -exclusion. New uploads become searchable shortly after they are stored.
+// @Tags search
+// @Produce  json
+// @Param   q       query     string  true   "Search query"
+// @Param   limit   query     int     false  "Page size, 1-100 (default 20)"
+// @Param   offset  query     int     false  "Number of results to skip"
+// @Success 200     {object}  SearchResponse
+// @Failure 400     {object}  ErrorResponse
+// @Failure 401     {object}  ErrorResponse
+// @Failure 500     {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /search [get]
+func (h *SearchHandler) Search(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var limit, offset int
+	var err error
+	if value := c.Query("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+	}
+	if value := c.Query("offset"); value != "" {
+		if offset, err = strconv.Atoi(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset"})
+			return
+		}
+	}
+
+	results, err := h.searchService.Search(userID.(uint), c.Query("q"), limit, offset)
+	if err != nil {
+		respondError(c, err, "Search failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": results})
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lskeey/go-filehub/internal/service"
+)
+
+// ShareHandler handles sharing files and folders with other users.
+type ShareHandler struct {
+	shareService *service.ShareService
+}
+
+// NewShareHandler creates a new share handler.
+func NewShareHandler(s *service.ShareService) *ShareHandler {
+	return &ShareHandler{shareService: s}
+}
+
+// CreateShareRequest defines the structure for the create share request body.
+// Exactly one of FileID and FolderID must be set.
+type CreateShareRequest struct {
+	FileID     *uint  `json:"file_id"`
+	FolderID   *uint  `json:"folder_id"`
+	Email      string `json:"email" validate:"required,email"`
+	Permission string `json:"permission" validate:"required,oneof=viewer editor"`
+}
+
+// CreateShare handles sharing a file or folder with another user.
+//
+// @Summary Share a file or folder
+// @Description Grants the user with the given email viewer or editor access to a file or folder owned by the authenticated user. A folder share covers everything inside it. Sharing again with the same user changes the permission.
+// @Tags shares
+// @Accept  json
+// @Produce  json
+// @Param   share  body      CreateShareRequest  true  "Share Info"
+// @Success 201    {object}  ShareSuccessResponse
+// @Failure 400    {object}  ErrorResponse
+// @Failure 403    {object}  ErrorResponse
+// @Failure 404    {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /shares [post]
+func (h *ShareHandler) CreateShare(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req CreateShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	share, err := h.shareService.ShareItem(userID.(uint), req.FileID, req.FolderID, req.Email, req.Permission)
+	if err != nil {
+		respondError(c, err, "Failed to share item")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Item shared successfully", "data": share})
+}
+
+// ListShares handles listing the shares of the user's items.
+//
+// @Summary List shares of my items
+// @Description Retrieves the shares the authenticated user has granted, optionally limited to one file or folder.
+// @Tags shares
+// @Produce  json
+// @Param   file_id    query     int  false  "Only shares of this file"
+// @Param   folder_id  query     int  false  "Only shares of this folder"
+// @Success 200        {object}  ListSharesResponse
+// @Failure 400        {object}  ErrorResponse
+// @Failure 403        {object}  ErrorResponse
+// @Failure 404        {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /shares [get]
+func (h *ShareHandler) ListShares(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	fileID, err := parseOptionalID(c.Query("file_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file ID"})
+		return
+	}
+	folderID, err := parseOptionalID(c.Query("folder_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid folder ID"})
+		return
+	}
+
+	items, err := h.shareService.ListShares(userID.(uint), fileID, folderID)
+	if err != nil {
+		respondError(c, err, "Could not retrieve shares")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// ListSharedWithMe handles listing the items shared with the user.
+//
+// @Summary List items shared with me
+// @Description Retrieves the files and folders other users have shared with the authenticated user. Shared folders can be browsed with the folder endpoints.
+// @Tags shares
+// @Produce  json
+// @Success 200   {object}  ListSharesResponse
+// @Failure 401   {object}  ErrorResponse
+// @Failure 500   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /shares/with-me [get]
+func (h *ShareHandler) ListSharedWithMe(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	items, err := h.shareService.ListSharedWithMe(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not retrieve shared items"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// RevokeShare handles deleting a share.
+//
+// @Summary Revoke a share
+// @Description Deletes a share. The owner can revoke any share of their items; the grantee can remove a share from their own list.
+// @Tags shares
+// @Produce  json
+// @Param   id    path      int  true  "Share ID"
+// @Success 200   {object}  SuccessResponse
+// @Failure 400   {object}  ErrorResponse
+// @Failure 403   {object}  ErrorResponse
+// @Failure 404   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /shares/{id} [delete]
+func (h *ShareHandler) RevokeShare(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	shareID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid share ID"})
+		return
+	}
+
+	if err := h.shareService.RevokeShare(uint(shareID), userID.(uint)); err != nil {
+		respondError(c, err, "Failed to revoke share")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Share revoked"})
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lskeey/go-filehub/internal/service"
+)
+
+// SignedURLHandler mints pre-signed download URLs and accepts them in place
+// of a JWT on the download route.
+type SignedURLHandler struct {
+	signedURLService *service.SignedURLService
+	fileService      *service.FileService
+}
+
+// NewSignedURLHandler creates a new signed URL handler.
+func NewSignedURLHandler(signedURLService *service.SignedURLService, fileService *service.FileService) *SignedURLHandler {
+	return &SignedURLHandler{signedURLService: signedURLService, fileService: fileService}
+}
+
+// SignedURLRequest defines the structure for the signed URL request body.
+type SignedURLRequest struct {
+	ExpiresIn   int    `json:"expires_in" validate:"required,gt=0"` // Validity in seconds
+	SingleUse   bool   `json:"single_use"`
+	Disposition string `json:"disposition" validate:"omitempty,oneof=attachment inline"` // Defaults to attachment; HTML, SVG, XML and JavaScript are always attachments
+	Route       string `json:"route" validate:"omitempty,oneof=download thumbnail"`      // Endpoint the URL is for, defaults to download
+}
+
+// CreateSignedURL handles minting a pre-signed download URL.
+//
+// @Summary Create a signed download URL
+// @Description Returns a download URL that works without an Authorization header until it expires, e.g. for <a href> or <img src>. The URL acts on behalf of the authenticated user, so it stops working if they lose access to the file. A URL only works for the route it was created for (download by default, or thumbnail). A single-use URL works for one successful GET request only.
+// @Tags files
+// @Accept  json
+// @Produce  json
+// @Param   id    path      int               true  "File ID"
+// @Param   body  body      SignedURLRequest  true  "URL options"
+// @Success 200   {object}  SignedURLResponse
+// @Failure 400   {object}  ErrorResponse
+// @Failure 403   {object}  ErrorResponse
+// @Failure 404   {object}  ErrorResponse
+// @Failure 503   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /files/{id}/signed-url [post]
+func (h *SignedURLHandler) CreateSignedURL(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	fileID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file ID"})
+		return
+	}
+
+	var req SignedURLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Only files the user can download may be signed
+	if _, err := h.fileService.GetFile(uint(fileID), userID.(uint)); err != nil {
+		respondError(c, err, "Failed to sign URL")
+		return
+	}
+
+	route := req.Route
+	if route == "" {
+		route = service.SignedRouteDownload
+	}
+	ttl := time.Duration(req.ExpiresIn) * time.Second
+	query, expiresAt, err := h.signedURLService.Sign(uint(fileID), userID.(uint), route, ttl, req.SingleUse, req.Disposition)
+	if err != nil {
+		respondError(c, err, "Failed to sign URL")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"url":        fmt.Sprintf("/api/v1/files/%d/%s?%s", fileID, route, query.Encode()),
+		"expires_at": expiresAt,
+	}})
+}
+
+// SignedOrAuth lets requests carrying a URL signature through as the user
+// who signed them, limited to the file in the URL and the route the URL was
+// signed for. All other requests are passed to auth.
+func (h *SignedURLHandler) SignedOrAuth(route string, auth gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Query("sig") == "" {
+			auth(c)
+			return
+		}
+
+		fileID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid file ID"})
+			return
+		}
+		download, err := h.signedURLService.Verify(uint(fileID), route, c.Request.URL.Query())
+		if err != nil {
+			respondError(c, err, "Failed to verify URL")
+			c.Abort()
+			return
+		}
+		// A single-use URL is used up once the handler has the content to
+		// serve; probing with HEAD does not use it up
+		if c.Request.Method == http.MethodGet {
+			c.Set("consumeSignedURL", func() error { return h.signedURLService.Consume(download) })
+		}
+
+		c.Set("userID", download.UserID)
+		c.Set("disposition", download.Disposition)
+		c.Next()
+	}
+}
+
+// consumeSignedURL uses up the single-use URL the request was let through
+// with, if any. Handlers call it once they are about to serve the content,
+// so a failed request doesn't burn the URL. It responds and returns false
+// if the URL can't be used.
+func consumeSignedURL(c *gin.Context) bool {
+	consume, ok := c.Get("consumeSignedURL")
+	if !ok {
+		return true
+	}
+	if err := consume.(func() error)(); err != nil {
+		respondError(c, err, "Failed to verify URL")
+		return false
+	}
+	return true
+}
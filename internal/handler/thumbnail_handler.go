@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lskeey/go-filehub/internal/service"
+	"github.com/lskeey/go-filehub/internal/storage"
+)
+
+// ThumbnailHandler serves image previews.
+type ThumbnailHandler struct {
+	thumbnailService *service.ThumbnailService
+	fileService      *service.FileService
+}
+
+// NewThumbnailHandler creates a new thumbnail handler.
+func NewThumbnailHandler(thumbnailService *service.ThumbnailService, fileService *service.FileService) *ThumbnailHandler {
+	return &ThumbnailHandler{thumbnailService: thumbnailService, fileService: fileService}
+}
+
+// GetThumbnail handles serving the thumbnail of a file.
+//
+// @Summary Get a file thumbnail
+// @Description Returns a preview of a JPEG, PNG, GIF or WebP file that fits into a square of the requested size (small 128px, medium 256px, large 512px), keeping the aspect ratio. Opaque images are served as JPEG, transparent ones as PNG. Other files, and images that can't be decoded, get a placeholder icon and the X-Thumbnail-Placeholder header. The user must own the file or have it shared with them, and it is refused like a download while the file is quarantined or not scanned yet; the parameters of a signed URL minted for the thumbnail route are accepted as well.
+// @Tags files
+// @Produce  image/jpeg,image/png
+// @Param   id             path      int     true   "File ID"
+// @Param   size           query     string  false  "Thumbnail size (default medium)"  Enums(small, medium, large)
+// @Param   If-None-Match  header    string  false  "ETag of a cached copy"
+// @Success 200   {file}    file
+// @Success 304
+// @Failure 400   {object}  ErrorResponse
+// @Failure 403   {object}  ErrorResponse
+// @Failure 404   {object}  ErrorResponse
+// @Failure 409   {object}  ErrorResponse
+// @Failure 410   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /files/{id}/thumbnail [get]
+func (h *ThumbnailHandler) GetThumbnail(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	fileID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file ID"})
+		return
+	}
+
+	// The user must own the file or have it shared with them
+	file, err := h.fileService.GetFile(uint(fileID), userID.(uint))
+	if err != nil {
+		respondError(c, err, "Failed to read file")
+		return
+	}
+
+	thumb, err := h.thumbnailService.Thumbnail(c.Request.Context(), file, c.Query("size"))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "File content not found"})
+			return
+		}
+		respondError(c, err, "Failed to read thumbnail")
+		return
+	}
+	defer thumb.Content.Close()
+	if !consumeSignedURL(c) {
+		return
+	}
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", thumb.ContentType)
+	header.Set("ETag", thumb.ETag)
+	header.Set("Cache-Control", "private, max-age=3600")
+	if thumb.Placeholder {
+		header.Set("X-Thumbnail-Placeholder", "true")
+	}
+	http.ServeContent(c.Writer, c.Request, "", thumb.ModTime, thumb.Content)
+}
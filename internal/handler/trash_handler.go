@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lskeey/go-filehub/internal/service"
+)
+
+// TrashHandler handles trash-related HTTP requests.
+type TrashHandler struct {
+	trashService *service.TrashService
+}
+
+// NewTrashHandler creates a new trash handler.
+func NewTrashHandler(s *service.TrashService) *TrashHandler {
+	return &TrashHandler{trashService: s}
+}
+
+// ListTrash handles listing the items in the trash.
+//
+// @Summary List trash
+// @Description Retrieves the trashed folders and files of the authenticated user. Items deleted together with a folder are listed through that folder.
+// @Tags trash
+// @Produce  json
+// @Success 200   {object}  FolderChildrenResponse
+// @Failure 401   {object}  ErrorResponse
+// @Failure 500   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /trash [get]
+func (h *TrashHandler) ListTrash(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	folders, files, err := h.trashService.ListTrash(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not retrieve trash"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"folders": folders, "files": files}})
+}
+
+// RestoreFile handles taking a file out of the trash.
+//
+// @Summary Restore a file
+// @Description Restores a trashed file into its original folder, or the root if that folder is gone.
+// @Tags trash
+// @Produce  json
+// @Param   id    path      int  true  "File ID"
+// @Success 200   {object}  UploadSuccessResponse
+// @Failure 400   {object}  ErrorResponse
+// @Failure 403   {object}  ErrorResponse
+// @Failure 404   {object}  ErrorResponse
+// @Failure 409   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /trash/files/{id}/restore [post]
+func (h *TrashHandler) RestoreFile(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	fileID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file ID"})
+		return
+	}
+
+	file, err := h.trashService.RestoreFile(uint(fileID), userID.(uint))
+	if err != nil {
+		respondError(c, err, "Failed to restore file")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "File restored successfully", "data": file})
+}
+
+// RestoreFolder handles taking a folder out of the trash.
+//
+// @Summary Restore a folder
+// @Description Restores a trashed folder with everything deleted together with it, into its original parent or the root if that parent is gone.
+// @Tags trash
+// @Produce  json
+// @Param   id    path      int  true  "Folder ID"
+// @Success 200   {object}  FolderSuccessResponse
+// @Failure 400   {object}  ErrorResponse
+// @Failure 403   {object}  ErrorResponse
+// @Failure 404   {object}  ErrorResponse
+// @Failure 409   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /trash/folders/{id}/restore [post]
+func (h *TrashHandler) RestoreFolder(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	folderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid folder ID"})
+		return
+	}
+
+	folder, err := h.trashService.RestoreFolder(uint(folderID), userID.(uint))
+	if err != nil {
+		respondError(c, err, "Failed to restore folder")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Folder restored successfully", "data": folder})
+}
+
+// DeleteFile handles permanently deleting a trashed file.
+//
+// @Summary Permanently delete a file
+// @Description Permanently deletes a trashed file and its versions.
+// @Tags trash
+// @Produce  json
+// @Param   id    path      int  true  "File ID"
+// @Success 200   {object}  SuccessResponse
+// @Failure 400   {object}  ErrorResponse
+// @Failure 403   {object}  ErrorResponse
+// @Failure 404   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /trash/files/{id} [delete]
+func (h *TrashHandler) DeleteFile(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	fileID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file ID"})
+		return
+	}
+
+	if err := h.trashService.DeleteFilePermanently(c.Request.Context(), uint(fileID), userID.(uint)); err != nil {
+		respondError(c, err, "Failed to delete file")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "File deleted permanently"})
+}
+
+// DeleteFolder handles permanently deleting a trashed folder.
+//
+// @Summary Permanently delete a folder
+// @Description Permanently deletes a trashed folder with everything deleted together with it.
+// @Tags trash
+// @Produce  json
+// @Param   id    path      int  true  "Folder ID"
+// @Success 200   {object}  SuccessResponse
+// @Failure 400   {object}  ErrorResponse
+// @Failure 403   {object}  ErrorResponse
+// @Failure 404   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /trash/folders/{id} [delete]
+func (h *TrashHandler) DeleteFolder(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	folderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid folder ID"})
+		return
+	}
+
+	if err := h.trashService.DeleteFolderPermanently(c.Request.Context(), uint(folderID), userID.(uint)); err != nil {
+		respondError(c, err, "Failed to delete folder")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Folder deleted permanently"})
+}
+
+// EmptyTrash handles permanently deleting everything in the trash.
+//
+// @Summary Empty trash
+// @Description Permanently deletes every trashed file and folder of the authenticated user.
+// @Tags trash
+// @Produce  json
+// @Success 200   {object}  SuccessResponse
+// @Failure 401   {object}  ErrorResponse
+// @Failure 500   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /trash [delete]
+func (h *TrashHandler) EmptyTrash(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	if err := h.trashService.EmptyTrash(c.Request.Context(), userID.(uint)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to empty trash"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Trash emptied successfully"})
+}
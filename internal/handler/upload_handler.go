@@ -0,0 +1,264 @@
+package handler
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lskeey/go-filehub/internal/models"
+	"github.com/lskeey/go-filehub/internal/service"
+)
+
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,termination"
+)
+
+// UploadHandler implements the tus 1.0 resumable upload protocol.
+// See https://tus.io/protocols/resumable-upload
+type UploadHandler struct {
+	uploadService *service.UploadService
+}
+
+// NewUploadHandler creates a new resumable upload handler.
+func NewUploadHandler(s *service.UploadService) *UploadHandler {
+	return &UploadHandler{uploadService: s}
+}
+
+// TusResumable rejects requests that do not speak a supported tus version
+// and adds the Tus-Resumable header to every response.
+func (h *UploadHandler) TusResumable(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+	if c.Request.Method == http.MethodOptions {
+		c.Next()
+		return
+	}
+	if c.GetHeader("Tus-Resumable") != tusVersion {
+		c.Header("Tus-Version", tusVersion)
+		c.AbortWithStatusJSON(http.StatusPreconditionFailed, gin.H{"error": "Unsupported tus version"})
+		return
+	}
+	c.Next()
+}
+
+// Options reports the server's tus capabilities.
+//
+// @Summary Resumable upload capabilities
+// @Description Returns the supported tus version and extensions.
+// @Tags uploads
+// @Success 204
+// @Header  204  {int}  Tus-Max-Size  "Largest accepted Upload-Length"
+// @Security BearerAuth
+// @Router /files/uploads [options]
+func (h *UploadHandler) Options(c *gin.Context) {
+	c.Header("Tus-Version", tusVersion)
+	c.Header("Tus-Extension", tusExtensions)
+	c.Header("Tus-Max-Size", strconv.FormatInt(h.uploadService.MaxUploadSize(), 10))
+	c.Status(http.StatusNoContent)
+}
+
+// CreateUpload starts a new resumable upload.
+//
+// @Summary Create a resumable upload
+// @Description Starts a tus upload. The file name and type are taken from the "filename" and "filetype" keys of Upload-Metadata, the target folder from the optional "folder_id" key.
+// @Tags uploads
+// @Param   Tus-Resumable    header  string  true   "tus protocol version (1.0.0)"
+// @Param   Upload-Length    header  int     true   "Total size of the file in bytes"
+// @Param   Upload-Metadata  header  string  false  "Comma-separated key/base64-value pairs"
+// @Success 201
+// @Header  201  {string}  Location  "URL of the created upload"
+// @Failure 400  {object}  ErrorResponse
+// @Failure 401  {object}  ErrorResponse
+// @Failure 403  {object}  ErrorResponse
+// @Failure 404  {object}  ErrorResponse
+// @Failure 412  {object}  ErrorResponse
+// @Failure 413  {object}  ErrorResponse
+// @Failure 415  {object}  ErrorResponse
+// @Failure 507  {object}  ErrorResponse
+// @Failure 500  {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /files/uploads [post]
+func (h *UploadHandler) CreateUpload(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Upload-Length header"})
+		return
+	}
+
+	metadata, err := parseUploadMetadata(c.GetHeader("Upload-Metadata"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Upload-Metadata header"})
+		return
+	}
+	fileName := metadata["filename"]
+	if fileName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Metadata must contain a filename"})
+		return
+	}
+	mimeType := metadata["filetype"]
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	folderID, err := parseOptionalID(metadata["folder_id"])
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid folder_id in Upload-Metadata"})
+		return
+	}
+
+	upload, err := h.uploadService.CreateUpload(c.Request.Context(), userID.(uint), folderID, fileName, mimeType, length)
+	if err != nil {
+		respondError(c, err, "Failed to create upload")
+		return
+	}
+
+	setUploadHeaders(c, upload)
+	c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/")+"/"+upload.ID)
+	c.Status(http.StatusCreated)
+}
+
+// GetUploadOffset reports how many bytes of an upload have been received.
+//
+// @Summary Get resumable upload offset
+// @Description Returns the current Upload-Offset so the client can resume.
+// @Tags uploads
+// @Param   Tus-Resumable  header  string  true  "tus protocol version (1.0.0)"
+// @Param   uploadId       path    string  true  "Upload ID"
+// @Success 200
+// @Header  200  {int}  Upload-Offset  "Bytes received so far"
+// @Header  200  {int}  Upload-Length  "Total size of the file"
+// @Failure 403
+// @Failure 404
+// @Security BearerAuth
+// @Router /files/uploads/{uploadId} [head]
+func (h *UploadHandler) GetUploadOffset(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	upload, err := h.uploadService.GetUpload(c.Param("uploadId"), userID.(uint))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	setUploadHeaders(c, upload)
+	c.Status(http.StatusOK)
+}
+
+// PatchUpload appends a chunk to an upload.
+//
+// @Summary Upload a chunk
+// @Description Appends the request body at Upload-Offset. The file is created once all bytes are received.
+// @Tags uploads
+// @Accept  application/offset+octet-stream
+// @Param   Tus-Resumable  header  string  true  "tus protocol version (1.0.0)"
+// @Param   Upload-Offset  header  int     true  "Offset the chunk starts at"
+// @Param   uploadId       path    string  true  "Upload ID"
+// @Success 204
+// @Header  204  {int}  Upload-Offset  "New offset after the chunk"
+// @Failure 400  {object}  ErrorResponse
+// @Failure 403  {object}  ErrorResponse
+// @Failure 404  {object}  ErrorResponse
+// @Failure 409  {object}  ErrorResponse
+// @Failure 413  {object}  ErrorResponse
+// @Failure 415  {object}  ErrorResponse
+// @Failure 507  {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /files/uploads/{uploadId} [patch]
+func (h *UploadHandler) PatchUpload(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	if c.ContentType() != "application/offset+octet-stream" {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be application/offset+octet-stream"})
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Upload-Offset header"})
+		return
+	}
+
+	upload, err := h.uploadService.WriteChunk(c.Request.Context(), c.Param("uploadId"), userID.(uint), offset, c.Request.Body)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	setUploadHeaders(c, upload)
+	c.Status(http.StatusNoContent)
+}
+
+// TerminateUpload cancels an upload and discards the received bytes.
+//
+// @Summary Terminate a resumable upload
+// @Description Discards an unfinished upload.
+// @Tags uploads
+// @Param   Tus-Resumable  header  string  true  "tus protocol version (1.0.0)"
+// @Param   uploadId       path    string  true  "Upload ID"
+// @Success 204
+// @Failure 403  {object}  ErrorResponse
+// @Failure 404  {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /files/uploads/{uploadId} [delete]
+func (h *UploadHandler) TerminateUpload(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	if err := h.uploadService.TerminateUpload(c.Param("uploadId"), userID.(uint)); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *UploadHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUploadNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrUploadForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrUploadOffsetMismatch),
+		errors.Is(err, service.ErrUploadComplete):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrUploadExceedsLength):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	default:
+		respondError(c, err, "Failed to process upload")
+	}
+}
+
+// setUploadHeaders writes the offset/length headers and, once the upload has
+// completed, the ID of the resulting file.
+func setUploadHeaders(c *gin.Context, upload *models.Upload) {
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	if upload.FileID != nil {
+		c.Header("X-File-ID", strconv.FormatUint(uint64(*upload.FileID), 10))
+	}
+}
+
+// parseUploadMetadata decodes a tus Upload-Metadata header
+// ("key base64value,key2 base64value2").
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, errors.New("empty metadata key")
+		}
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, err
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lskeey/go-filehub/internal/service"
+)
+
+// UserHandler handles requests about the authenticated user.
+type UserHandler struct {
+	quotaService *service.QuotaService
+}
+
+// NewUserHandler creates a new user handler.
+func NewUserHandler(quotaService *service.QuotaService) *UserHandler {
+	return &UserHandler{quotaService: quotaService}
+}
+
+// GetUsage handles reporting the user's storage usage.
+//
+// @Summary Get storage usage
+// @Description Returns the bytes used by the authenticated user (all versions, including trash) and their quota. A quota of 0 means unlimited.
+// @Tags me
+// @Produce  json
+// @Success 200   {object}  UsageResponse
+// @Failure 401   {object}  ErrorResponse
+// @Failure 500   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /me/usage [get]
+func (h *UserHandler) GetUsage(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	usage, err := h.quotaService.GetUsage(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not retrieve usage"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": usage})
+}
+
+// ReconcileUsage handles recomputing the user's storage usage.
+//
+// @Summary Recompute storage usage
+// @Description Recomputes the authenticated user's usage from their files, fixing a drifted counter.
+// @Tags me
+// @Produce  json
+// @Success 200   {object}  UsageResponse
+// @Failure 401   {object}  ErrorResponse
+// @Failure 500   {object}  ErrorResponse
+// @Security BearerAuth
+// @Router /me/usage/reconcile [post]
+func (h *UserHandler) ReconcileUsage(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	if _, err := h.quotaService.Reconcile(userID.(uint)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not recompute usage"})
+		return
+	}
+
+	usage, err := h.quotaService.GetUsage(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not retrieve usage"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": usage})
+}
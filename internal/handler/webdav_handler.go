@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lskeey/go-filehub/internal/davfs"
+	"github.com/lskeey/go-filehub/internal/service"
+	"golang.org/x/net/webdav"
+)
+
+// WebDAVMethods lists the HTTP methods the WebDAV endpoint handles.
+var WebDAVMethods = []string{
+	http.MethodOptions, http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete,
+	"PROPFIND", "PROPPATCH", "MKCOL", "COPY", "MOVE", "LOCK", "UNLOCK",
+}
+
+// WebDAVHandler serves the authenticated user's files over WebDAV, so they
+// can be mounted as a network drive.
+type WebDAVHandler struct {
+	appPasswordService *service.AppPasswordService
+	fs                 *davfs.FS
+	prefix             string
+	locks              sync.Map // user ID -> webdav.LockSystem
+}
+
+// NewWebDAVHandler creates a new WebDAV handler for requests under prefix.
+func NewWebDAVHandler(appPasswordService *service.AppPasswordService, fs *davfs.FS, prefix string) *WebDAVHandler {
+	return &WebDAVHandler{appPasswordService: appPasswordService, fs: fs, prefix: prefix}
+}
+
+// BasicAuth authenticates WebDAV clients, which generally can't send bearer
+// tokens, with the user's email and either an app password or the account
+// password.
+func (h *WebDAVHandler) BasicAuth(c *gin.Context) {
+	if email, password, ok := c.Request.BasicAuth(); ok {
+		if userID, err := h.appPasswordService.AuthenticateBasic(email, password); err == nil {
+			c.Set("userID", userID)
+			c.Next()
+			return
+		}
+	}
+	c.Header("WWW-Authenticate", `Basic realm="FileHub", charset="UTF-8"`)
+	c.AbortWithStatus(http.StatusUnauthorized)
+}
+
+// ServeDAV handles a WebDAV request (PROPFIND, GET, PUT, DELETE, MOVE, COPY,
+// MKCOL and locking).
+func (h *WebDAVHandler) ServeDAV(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	ctx := davfs.WithUser(c.Request.Context(), userID.(uint))
+	c.Request.Body = davfs.WrapBody(ctx, c.Request.Body)
+	dav := &webdav.Handler{
+		Prefix:     h.prefix,
+		FileSystem: h.fs,
+		LockSystem: h.lockSystem(userID.(uint)),
+		Logger: func(r *http.Request, err error) {
+			if err != nil && !os.IsNotExist(err) {
+				log.Printf("WebDAV %s %s: %v", r.Method, r.URL.Path, err)
+			}
+		},
+	}
+	dav.ServeHTTP(&davResponseWriter{ResponseWriter: c.Writer, ctx: ctx}, c.Request.WithContext(ctx))
+}
+
+// lockSystem returns the user's lock system. Every user sees their own tree
+// under the same paths, so locks can't be shared between users. Locks are
+// kept in memory and only hold within one API instance.
+func (h *WebDAVHandler) lockSystem(userID uint) webdav.LockSystem {
+	if ls, ok := h.locks.Load(userID); ok {
+		return ls.(webdav.LockSystem)
+	}
+	ls, _ := h.locks.LoadOrStore(userID, webdav.NewMemLS())
+	return ls.(webdav.LockSystem)
+}
+
+// davResponseWriter replaces the generic error statuses of webdav.Handler,
+// such as 405 for any failed PUT, with the status of the service error
+// behind them, e.g. 507 when the quota is exceeded.
+type davResponseWriter struct {
+	http.ResponseWriter
+	ctx          context.Context
+	failure      error
+	wroteFailure bool
+}
+
+func (w *davResponseWriter) WriteHeader(status int) {
+	if status >= http.StatusBadRequest {
+		if err := davfs.Failure(w.ctx); err != nil {
+			if mapped := errorStatus(err); mapped != http.StatusInternalServerError {
+				w.failure, status = err, mapped
+			}
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *davResponseWriter) Write(p []byte) (int, error) {
+	if w.failure == nil {
+		return w.ResponseWriter.Write(p)
+	}
+	// The body is the text of the replaced status; describe the failure instead
+	if !w.wroteFailure {
+		w.wroteFailure = true
+		if _, err := w.ResponseWriter.Write([]byte(w.failure.Error())); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
@@ -1,11 +1,12 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/lskeey/go-filehub/pkg/utils"
 )
 
 // AuthMiddleware creates a Gin middleware for JWT authentication.
@@ -24,30 +25,19 @@ func AuthMiddleware(secretKey string) gin.HandlerFunc {
 			return
 		}
 
-		tokenString := parts[1]
-
 		// Parse and validate the token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Validate the alg is what you expect:
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, http.ErrAbortHandler
-			}
-			return []byte(secretKey), nil
-		})
-
+		userID, err := utils.ParseJWT(parts[1], secretKey)
+		if errors.Is(err, utils.ErrInvalidClaims) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+			return
+		}
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			return
 		}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			// Extract user ID from claims and set it in the context
-			userID := uint(claims["user_id"].(float64))
-			c.Set("userID", userID)
-		} else {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			return
-		}
+		// Set the user ID in the context
+		c.Set("userID", userID)
 
 		c.Next()
 	}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// AccessKey is a key pair for the S3-compatible API. Requests are signed
+// with AWS Signature Version 4, which needs the secret on both sides, so
+// unlike passwords the secret is stored as it is.
+type AccessKey struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+
+	UserID      uint   `gorm:"not null;index"`
+	Name        string `gorm:"size:100;not null"`            // What the key is used for, e.g. "Backup script"
+	AccessKeyID string `gorm:"size:20;uniqueIndex;not null"` // Public identifier sent with every request
+	SecretKey   string `gorm:"size:40;not null" json:"-"`    // Shared secret the requests are signed with
+	LastUsedAt  *time.Time
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// AppPassword is an extra password for clients that can only send Basic
+// auth, such as WebDAV drives. Each one can be revoked on its own without
+// changing the account password.
+type AppPassword struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+
+	UserID     uint   `gorm:"not null;index"`
+	Name       string `gorm:"size:100;not null"`                     // What the password is used for, e.g. "Laptop WebDAV"
+	Hash       string `gorm:"size:64;uniqueIndex;not null" json:"-"` // Hex SHA-256 of the password; it is random, so no salt is needed
+	Hint       string `gorm:"size:8;not null"`                       // Last characters of the password, to tell them apart
+	LastUsedAt *time.Time
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Blob is a piece of content stored once, addressed by its SHA-256 digest.
+// Files with identical content share a blob; RefCount tracks how many do.
+type Blob struct {
+	Digest     string `gorm:"primaryKey;size:64"` // Hex-encoded SHA-256 of the content
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	Size       int64  `gorm:"not null"`
+	StorageKey string `gorm:"unique;not null"` // Key of the content in the storage backend
+	RefCount   int64  `gorm:"not null;default:0"`
+}
@@ -1,14 +1,39 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Virus scan states of a File's current content.
+const (
+	ScanPending  = "pending"   // Not scanned yet
+	ScanClean    = "clean"     // No malware found
+	ScanInfected = "infected"  // Malware found; the content is quarantined
+	ScanError    = "error"     // The scan failed and is retried
+	ScanTooLarge = "too_large" // Larger than the scanner accepts; not retried, can be downloaded
+)
 
 // File represents the file metadata model in the database
 type File struct {
 	gorm.Model // Includes fields ID, CreatedAt, UpdatedAt, DeletedAt
 
-	FileName string `gorm:"not null"`
-	Size     int64  `gorm:"not null"`
-	MimeType string `gorm:"not null"`
-	S3Path   string `gorm:"unique;not null"` // Path to the file in the S3 bucket
-	OwnerID  uint   `gorm:"not null"`        // The ID of the user who owns the file
+	FileName   string `gorm:"not null"`
+	Size       int64  `gorm:"not null"`
+	MimeType   string `gorm:"not null"`           // Detected from the content, see DeclaredMimeType
+	S3Path     string `gorm:"not null;index"`     // Storage key of the file's content, shared by files with identical content
+	BlobDigest string `gorm:"size:64;index"`      // SHA-256 of the content, see Blob
+	OwnerID    uint   `gorm:"not null"`           // The ID of the user who owns the file
+	FolderID   *uint  `gorm:"index"`              // nil for files at the root
+	Version    int    `gorm:"not null;default:1"` // Current version number, see FileVersion
+
+	DeclaredMimeType string // Content-Type sent by the client, which may be wrong or spoofed
+
+	Description string     `gorm:"type:text;not null;default:''"`
+	Tags        Tags       `gorm:"type:jsonb;not null;default:'[]'"` // Lower-case labels, sorted
+	Properties  Properties `gorm:"type:jsonb;not null;default:'{}'"` // Custom key/value metadata
+
+	ScanStatus string     `gorm:"size:16;not null;default:pending;index"` // ScanPending, ScanClean, ScanInfected, ScanError or ScanTooLarge
+	ScannedAt  *time.Time // When the current content was last scanned
 }
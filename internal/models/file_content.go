@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Indexing states of a FileContent.
+const (
+	ContentIndexed     = "indexed"     // Text was extracted and is searchable
+	ContentUnsupported = "unsupported" // The file type has no text extractor
+	ContentFailed      = "failed"      // Extraction failed; retried when the content changes
+)
+
+// FileContent holds the text extracted from a file's current content for
+// full-text search. The table also has a generated tsvector column,
+// search_vector, created in database.Connect.
+type FileContent struct {
+	FileID    uint `gorm:"primaryKey;autoIncrement:false"`
+	UpdatedAt time.Time
+
+	Source  string `gorm:"not null"`         // File.S3Path the text was extracted from
+	Status  string `gorm:"size:16;not null"` // ContentIndexed, ContentUnsupported or ContentFailed
+	Content string `gorm:"type:text;not null;default:''"`
+}
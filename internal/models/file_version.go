@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// FileVersion is a snapshot of a file's content. The File row always mirrors
+// the version recorded in File.Version.
+type FileVersion struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+
+	FileID     uint   `gorm:"not null;uniqueIndex:idx_file_versions_file_id_version"`
+	Version    int    `gorm:"not null;uniqueIndex:idx_file_versions_file_id_version"` // Sequential per file, starting at 1
+	Size       int64  `gorm:"not null"`
+	MimeType   string `gorm:"not null"`
+	S3Path     string `gorm:"not null"`      // Storage key of the version's content
+	BlobDigest string `gorm:"size:64;index"` // SHA-256 of the content, see Blob
+	UploadedBy uint   `gorm:"not null"`      // The ID of the user who uploaded this version
+
+	DeclaredMimeType string // Content-Type sent by the client; MimeType is detected from the content
+
+	ScanStatus string     `gorm:"size:16;not null;default:pending;index"` // Like File.ScanStatus; the current version's mirrors the File
+	ScannedAt  *time.Time // When the content was last scanned
+}
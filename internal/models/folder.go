@@ -0,0 +1,12 @@
+package models
+
+import "gorm.io/gorm"
+
+// Folder represents a folder in a user's file tree
+type Folder struct {
+	gorm.Model // Includes fields ID, CreatedAt, UpdatedAt, DeletedAt
+
+	Name     string `gorm:"not null"`
+	ParentID *uint  `gorm:"index"`          // nil for folders at the root
+	OwnerID  uint   `gorm:"not null;index"` // The ID of the user who owns the folder
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Job states. Pending jobs wait for RunAt, running jobs are claimed by a
+// worker, and dead jobs exhausted their attempts and are kept for inspection.
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobDead      = "dead"
+)
+
+// Job is a unit of background work in the database-backed job queue.
+type Job struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	Type        string     `gorm:"size:64;not null"`
+	Payload     string     `gorm:"type:jsonb;not null;default:'{}'"` // JSON arguments of the job's handler
+	Status      string     `gorm:"size:16;not null;index"`
+	Attempts    int        `gorm:"not null;default:0"`
+	MaxAttempts int        `gorm:"not null"`
+	RunAt       time.Time  `gorm:"not null"` // The job isn't run before this time
+	LockedBy    string     // Worker running the job
+	LockedAt    *time.Time // When the current attempt started
+	LastError   string     `gorm:"type:text"`
+	FinishedAt  *time.Time
+	UniqueKey   *string `gorm:"uniqueIndex;size:191"` // Optional key that prevents enqueueing a job twice; released when the job dies
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Tags is a list of labels stored as a JSONB array.
+type Tags []string
+
+// Value encodes the tags for the database. No tags are stored as [].
+func (t Tags) Value() (driver.Value, error) {
+	if t == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal([]string(t))
+	return string(b), err
+}
+
+// MarshalJSON encodes no tags as [] rather than null.
+func (t Tags) MarshalJSON() ([]byte, error) {
+	if t == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]string(t))
+}
+
+// Scan decodes tags read from the database.
+func (t *Tags) Scan(src interface{}) error {
+	return scanJSON(src, t)
+}
+
+// Properties are custom key/value pairs stored as a JSONB object.
+type Properties map[string]string
+
+// Value encodes the properties for the database. No properties are stored as {}.
+func (p Properties) Value() (driver.Value, error) {
+	if p == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(map[string]string(p))
+	return string(b), err
+}
+
+// MarshalJSON encodes no properties as {} rather than null.
+func (p Properties) MarshalJSON() ([]byte, error) {
+	if p == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(map[string]string(p))
+}
+
+// Scan decodes properties read from the database.
+func (p *Properties) Scan(src interface{}) error {
+	return scanJSON(src, p)
+}
+
+func scanJSON(src interface{}, dst interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, dst)
+	case string:
+		return json.Unmarshal([]byte(v), dst)
+	default:
+		return fmt.Errorf("cannot scan %T into %T", src, dst)
+	}
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// MultipartUpload is an S3 multipart upload in progress. Its parts are
+// staged on disk until the upload is completed or aborted.
+type MultipartUpload struct {
+	ID        string `gorm:"primaryKey;size:32"` // Random hex upload ID
+	CreatedAt time.Time
+
+	OwnerID     uint   `gorm:"not null;index"`
+	Bucket      string `gorm:"not null"`
+	Key         string `gorm:"not null"`
+	ContentType string
+	Metadata    Properties `gorm:"type:jsonb;not null;default:'{}'"` // x-amz-meta-* headers, stored as file properties on completion
+}
+
+// MultipartPart is an uploaded part of a MultipartUpload. Uploading a part
+// number again replaces it.
+type MultipartPart struct {
+	UploadID   string `gorm:"primaryKey;size:32"`
+	PartNumber int    `gorm:"primaryKey;autoIncrement:false"`
+	CreatedAt  time.Time
+	Size       int64  `gorm:"not null"`
+	ETag       string `gorm:"size:64;not null"` // Hex SHA-256 of the part's content
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// PublicLink gives anyone holding its token access to a file or to a folder,
+// without an account. Exactly one of FileID and FolderID is set.
+type PublicLink struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	Token         string     `gorm:"size:64;uniqueIndex;not null"` // Random, URL-safe secret used in the public URL
+	OwnerID       uint       `gorm:"not null;index"`               // The ID of the user who created the link
+	FileID        *uint      `gorm:"index"`
+	FolderID      *uint      `gorm:"index"`
+	PasswordHash  string     `json:"-"` // bcrypt hash, empty if no password is required
+	HasPassword   bool       `gorm:"-"` // Set when the link is returned to its owner
+	ExpiresAt     *time.Time // nil for links that never expire
+	MaxDownloads  int        `gorm:"not null;default:0"` // 0 for unlimited downloads
+	DownloadCount int        `gorm:"not null;default:0"`
+	RevokedAt     *time.Time
+}
+
+// LinkAccess records one request made through a public link.
+type LinkAccess struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+
+	LinkID    uint   `gorm:"not null;index"`
+	FileID    *uint  // The file that was downloaded, nil for listings
+	Action    string `gorm:"size:16;not null"` // "view" or "download"
+	Status    int    `gorm:"not null"`         // HTTP status of the response
+	IP        string `gorm:"size:64"`
+	UserAgent string
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// QuarantinedBlob marks content in which malware was found. No file or
+// version with this content can be downloaded.
+type QuarantinedBlob struct {
+	Digest    string `gorm:"primaryKey;size:64"` // See Blob
+	CreatedAt time.Time
+	Signature string // Name of the detected malware
+	FileID    uint   // The file whose scan found it
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Share permissions. An editor can do everything a viewer can.
+const (
+	PermissionViewer = "viewer" // May list, download and read the version history
+	PermissionEditor = "editor" // May also upload, rename, move, restore versions and delete
+)
+
+// Share grants another user access to a file or to a folder. A folder share
+// covers everything below the folder. Exactly one of FileID and FolderID is set.
+type Share struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	OwnerID    uint   `gorm:"not null;index"` // The ID of the user who owns the shared item
+	GranteeID  uint   `gorm:"not null;index;uniqueIndex:idx_shares_grantee_file;uniqueIndex:idx_shares_grantee_folder"`
+	FileID     *uint  `gorm:"index;uniqueIndex:idx_shares_grantee_file"`
+	FolderID   *uint  `gorm:"index;uniqueIndex:idx_shares_grantee_folder"`
+	Permission string `gorm:"size:16;not null"` // PermissionViewer or PermissionEditor
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// SignedURLNonce records a single-use nonce of a pre-signed URL once the URL
+// has been used. The row is only needed until the URL expires.
+type SignedURLNonce struct {
+	Nonce     string    `gorm:"primaryKey;size:64"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Rendering states of a Thumbnail.
+const (
+	ThumbnailReady  = "ready"  // The thumbnail is stored under StorageKey
+	ThumbnailFailed = "failed" // The image could not be decoded; a placeholder is served
+)
+
+// Thumbnail is a scaled-down preview of an image blob. Thumbnails belong to
+// content rather than files, so files sharing a blob share their thumbnails.
+type Thumbnail struct {
+	BlobDigest string `gorm:"primaryKey;size:64"`
+	Size       string `gorm:"primaryKey;size:16"` // Name of the bounding box, e.g. "small"
+	CreatedAt  time.Time
+
+	Status      string `gorm:"size:16;not null"` // ThumbnailReady or ThumbnailFailed
+	StorageKey  string // Key of the encoded image in the storage backend
+	ContentType string
+	Width       int
+	Height      int
+	ByteSize    int64
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Upload tracks the state of a resumable (tus) upload.
+// The received bytes live in a staging file; the row survives server restarts
+// so clients can resume from Offset.
+type Upload struct {
+	ID        string `gorm:"primaryKey;size:32"` // Random hex identifier used in the upload URL
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	OwnerID  uint   `gorm:"not null;index"` // The ID of the user who created the upload
+	FolderID *uint  // Folder the completed file is placed in, nil for the root
+	FileName string `gorm:"not null"`
+	MimeType string
+	Length   int64 `gorm:"not null"`           // Total size announced via Upload-Length
+	Offset   int64 `gorm:"not null;default:0"` // Number of bytes received so far
+	FileID   *uint // Set to the created models.File once the upload completes
+}
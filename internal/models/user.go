@@ -6,7 +6,9 @@ import "gorm.io/gorm"
 type User struct {
 	gorm.Model // Includes fields ID, CreatedAt, UpdatedAt, DeletedAt
 
-	Email    string `gorm:"unique;not null"`
-	Password string `gorm:"not null"`
-	Files    []File `gorm:"foreignKey:OwnerID"` // A user can have many files
+	Email      string `gorm:"unique;not null"`
+	Password   string `gorm:"not null"`
+	QuotaBytes int64  `gorm:"not null;default:0"` // Storage quota, 0 uses the deployment default
+	UsedBytes  int64  `gorm:"not null;default:0"` // Bytes used by the user's files, versions and trash
+	Files      []File `gorm:"foreignKey:OwnerID"` // A user can have many files
 }
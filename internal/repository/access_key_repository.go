@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/lskeey/go-filehub/internal/models"
+	"gorm.io/gorm"
+)
+
+type AccessKeyRepository struct {
+	DB *gorm.DB
+}
+
+// NewAccessKeyRepository creates a new access key repository.
+func NewAccessKeyRepository(db *gorm.DB) *AccessKeyRepository {
+	return &AccessKeyRepository{DB: db}
+}
+
+// CreateAccessKey saves a new access key.
+func (r *AccessKeyRepository) CreateAccessKey(key *models.AccessKey) error {
+	return r.DB.Create(key).Error
+}
+
+// FindAccessKeyByAccessKeyID retrieves the access key with the given public ID.
+func (r *AccessKeyRepository) FindAccessKeyByAccessKeyID(accessKeyID string) (*models.AccessKey, error) {
+	var key models.AccessKey
+	err := r.DB.Where("access_key_id = ?", accessKeyID).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// FindAccessKeysByUserID retrieves a user's access keys, newest first.
+func (r *AccessKeyRepository) FindAccessKeysByUserID(userID uint) ([]models.AccessKey, error) {
+	var keys []models.AccessKey
+	err := r.DB.Where("user_id = ?", userID).Order("created_at DESC, id DESC").Find(&keys).Error
+	return keys, err
+}
+
+// DeleteAccessKey deletes one of userID's access keys. It reports whether a
+// key was deleted.
+func (r *AccessKeyRepository) DeleteAccessKey(id, userID uint) (bool, error) {
+	result := r.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.AccessKey{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// TouchAccessKey records that an access key was used at the given time.
+func (r *AccessKeyRepository) TouchAccessKey(id uint, at time.Time) error {
+	return r.DB.Model(&models.AccessKey{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
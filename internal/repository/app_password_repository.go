@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/lskeey/go-filehub/internal/models"
+	"gorm.io/gorm"
+)
+
+type AppPasswordRepository struct {
+	DB *gorm.DB
+}
+
+// NewAppPasswordRepository creates a new app password repository.
+func NewAppPasswordRepository(db *gorm.DB) *AppPasswordRepository {
+	return &AppPasswordRepository{DB: db}
+}
+
+// CreateAppPassword saves a new app password.
+func (r *AppPasswordRepository) CreateAppPassword(password *models.AppPassword) error {
+	return r.DB.Create(password).Error
+}
+
+// FindAppPasswordByHash retrieves the app password with the given hash.
+func (r *AppPasswordRepository) FindAppPasswordByHash(hash string) (*models.AppPassword, error) {
+	var password models.AppPassword
+	err := r.DB.Where("hash = ?", hash).First(&password).Error
+	if err != nil {
+		return nil, err
+	}
+	return &password, nil
+}
+
+// FindAppPasswordsByUserID retrieves a user's app passwords, newest first.
+func (r *AppPasswordRepository) FindAppPasswordsByUserID(userID uint) ([]models.AppPassword, error) {
+	var passwords []models.AppPassword
+	err := r.DB.Where("user_id = ?", userID).Order("created_at DESC, id DESC").Find(&passwords).Error
+	return passwords, err
+}
+
+// DeleteAppPassword deletes one of userID's app passwords. It reports
+// whether a password was deleted.
+func (r *AppPasswordRepository) DeleteAppPassword(id, userID uint) (bool, error) {
+	result := r.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.AppPassword{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// TouchAppPassword records that an app password was used at the given time.
+func (r *AppPasswordRepository) TouchAppPassword(id uint, at time.Time) error {
+	return r.DB.Model(&models.AppPassword{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"github.com/lskeey/go-filehub/internal/models"
+	"gorm.io/gorm"
+)
+
+type BlobRepository struct {
+	DB *gorm.DB
+}
+
+// NewBlobRepository creates a new blob repository.
+func NewBlobRepository(db *gorm.DB) *BlobRepository {
+	return &BlobRepository{DB: db}
+}
+
+// WithDigestLock runs fn in a transaction holding an advisory lock on digest.
+// This serializes reference count changes together with writing or removing
+// the blob's bytes, so a blob can't be removed while it is being re-acquired.
+func (r *BlobRepository) WithDigestLock(digest string, fn func(repo *BlobRepository) error) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", digest).Error; err != nil {
+			return err
+		}
+		return fn(&BlobRepository{DB: tx})
+	})
+}
+
+// FindBlobByDigest retrieves a blob by its content digest.
+func (r *BlobRepository) FindBlobByDigest(digest string) (*models.Blob, error) {
+	var blob models.Blob
+	err := r.DB.Where("digest = ?", digest).First(&blob).Error
+	if err != nil {
+		return nil, err
+	}
+	return &blob, nil
+}
+
+// CreateBlob saves a new blob record.
+func (r *BlobRepository) CreateBlob(blob *models.Blob) error {
+	return r.DB.Create(blob).Error
+}
+
+// IncrementRefCount adds a reference to a blob.
+func (r *BlobRepository) IncrementRefCount(digest string) error {
+	return r.DB.Model(&models.Blob{}).Where("digest = ?", digest).
+		UpdateColumn("ref_count", gorm.Expr("ref_count + 1")).Error
+}
+
+// DecrementRefCount removes a reference from a blob and returns the remaining count.
+func (r *BlobRepository) DecrementRefCount(digest string) (int64, error) {
+	if err := r.DB.Model(&models.Blob{}).Where("digest = ? AND ref_count > 0", digest).
+		UpdateColumn("ref_count", gorm.Expr("ref_count - 1")).Error; err != nil {
+		return 0, err
+	}
+	blob, err := r.FindBlobByDigest(digest)
+	if err != nil {
+		return 0, err
+	}
+	return blob.RefCount, nil
+}
+
+// DeleteBlob removes a blob record.
+func (r *BlobRepository) DeleteBlob(digest string) error {
+	return r.DB.Where("digest = ?", digest).Delete(&models.Blob{}).Error
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"github.com/lskeey/go-filehub/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ContentRepository struct {
+	DB *gorm.DB
+}
+
+// NewContentRepository creates a new repository for extracted file text.
+func NewContentRepository(db *gorm.DB) *ContentRepository {
+	return &ContentRepository{DB: db}
+}
+
+// ContentMatch is a file found by full-text search.
+type ContentMatch struct {
+	models.File
+	Rank    float64
+	Snippet string // Matched passages, terms wrapped in the given markers
+}
+
+// FindFilesToIndex retrieves files whose current content has not been
+// processed yet, oldest first.
+func (r *ContentRepository) FindFilesToIndex(limit int) ([]models.File, error) {
+	var files []models.File
+	err := r.DB.
+		Joins("LEFT JOIN file_contents fc ON fc.file_id = files.id").
+		Where("fc.file_id IS NULL OR fc.source <> files.s3_path").
+		Order("files.id").Limit(limit).Find(&files).Error
+	return files, err
+}
+
+// SaveContent inserts or replaces the extracted text of a file.
+func (r *ContentRepository) SaveContent(content *models.FileContent) error {
+	return r.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(content).Error
+}
+
+// SearchContent ranks the files userID can access (owned, shared directly
+// or inside a shared folder) whose text matches the web-style query.
+// Snippets highlight matches between startSel and stopSel.
+func (r *ContentRepository) SearchContent(userID uint, query, startSel, stopSel string, limit, offset int) ([]ContentMatch, error) {
+	var matches []ContentMatch
+	err := r.DB.Raw(`
+		WITH RECURSIVE shared_folders AS (
+			SELECT f.id FROM folders f JOIN shares s ON s.folder_id = f.id
+			WHERE s.grantee_id = @user AND f.deleted_at IS NULL
+			UNION
+			SELECT c.id FROM folders c JOIN shared_folders t ON c.parent_id = t.id
+			WHERE c.deleted_at IS NULL
+		),
+		ranked AS (
+			SELECT fc.file_id, ts_rank_cd(fc.search_vector, q) AS rank, q
+			FROM file_contents fc
+			JOIN files f ON f.id = fc.file_id AND f.deleted_at IS NULL,
+				websearch_to_tsquery('english', @query) q
+			WHERE fc.search_vector @@ q
+				AND (f.owner_id = @user
+					OR f.id IN (SELECT file_id FROM shares WHERE grantee_id = @user AND file_id IS NOT NULL)
+					OR f.folder_id IN (SELECT id FROM shared_folders))
+			ORDER BY rank DESC, fc.file_id
+			LIMIT @limit OFFSET @offset
+		)
+		SELECT files.*, ranked.rank,
+			ts_headline('english', fc.content, ranked.q, @options) AS snippet
+		FROM ranked
+		JOIN files ON files.id = ranked.file_id
+		JOIN file_contents fc ON fc.file_id = ranked.file_id
+		ORDER BY ranked.rank DESC, ranked.file_id`,
+		map[string]interface{}{
+			"user":    userID,
+			"query":   query,
+			"limit":   limit,
+			"offset":  offset,
+			"options": "StartSel=" + startSel + ", StopSel=" + stopSel + ", MaxFragments=3, MaxWords=25, MinWords=8",
+		}).Scan(&matches).Error
+	return matches, err
+}
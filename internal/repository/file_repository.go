@@ -18,10 +18,42 @@ func (r *FileRepository) CreateFile(file *models.File) error {
 	return r.DB.Create(file).Error
 }
 
-// FindFilesByOwnerID retrieves all files owned by a specific user.
-func (r *FileRepository) FindFilesByOwnerID(userID uint) ([]models.File, error) {
+// FindFilesInFolder retrieves the files directly inside folderID (nil for the root).
+func (r *FileRepository) FindFilesInFolder(userID uint, folderID *uint) ([]models.File, error) {
 	var files []models.File
-	err := r.DB.Where("owner_id = ?", userID).Find(&files).Error
+	err := whereParent(r.DB.Where("owner_id = ?", userID), "folder_id", folderID).
+		Order("file_name").Find(&files).Error
+	return files, err
+}
+
+// FindFilesInFolders retrieves all files inside any of the given folders.
+func (r *FileRepository) FindFilesInFolders(folderIDs []uint) ([]models.File, error) {
+	var files []models.File
+	if len(folderIDs) == 0 {
+		return files, nil
+	}
+	err := r.DB.Where("folder_id IN ?", folderIDs).Find(&files).Error
+	return files, err
+}
+
+// FindFileByName retrieves the file called name inside folderID (nil for the root).
+func (r *FileRepository) FindFileByName(userID uint, folderID *uint, name string) (*models.File, error) {
+	var file models.File
+	err := whereParent(r.DB.Where("owner_id = ? AND file_name = ?", userID, name), "folder_id", folderID).
+		First(&file).Error
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// FindFilesByIDs retrieves the files with the given IDs.
+func (r *FileRepository) FindFilesByIDs(ids []uint) ([]models.File, error) {
+	var files []models.File
+	if len(ids) == 0 {
+		return files, nil
+	}
+	err := r.DB.Where("id IN ?", ids).Find(&files).Error
 	return files, err
 }
 
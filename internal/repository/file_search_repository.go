@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lskeey/go-filehub/internal/models"
+)
+
+// FileQuery selects a filtered, sorted page of a user's files. Zero values
+// disable a filter.
+type FileQuery struct {
+	OwnerID       uint
+	NameContains  string // Case-insensitive substring of the file name
+	NamePrefix    string // Case-insensitive prefix of the file name
+	MimeType      string // Exact MIME type, or a "type/*" wildcard
+	MinSize       *int64
+	MaxSize       *int64
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Tags          []string          // Files must have all of these tags
+	Properties    map[string]string // Files must have these property values
+	PropertyKeys  []string          // Files must have these properties, with any value
+
+	SortColumn string // "file_name", "size" or "created_at"; ties are broken by id
+	Desc       bool
+
+	// Keyset position: only rows after (AfterValue, AfterID) in sort order are returned
+	AfterValue interface{}
+	AfterID    uint
+
+	Limit int
+}
+
+// SearchFiles retrieves the files matching q, in a stable order.
+func (r *FileRepository) SearchFiles(q FileQuery) ([]models.File, error) {
+	db := r.DB.Where("owner_id = ?", q.OwnerID)
+	if q.NameContains != "" {
+		db = db.Where("file_name ILIKE ?", "%"+escapeLike(q.NameContains)+"%")
+	}
+	if q.NamePrefix != "" {
+		db = db.Where("file_name ILIKE ?", escapeLike(q.NamePrefix)+"%")
+	}
+	if prefix, ok := strings.CutSuffix(q.MimeType, "/*"); ok {
+		db = db.Where("mime_type LIKE ?", escapeLike(prefix)+"/%")
+	} else if q.MimeType != "" {
+		db = db.Where("mime_type = ?", q.MimeType)
+	}
+	if q.MinSize != nil {
+		db = db.Where("size >= ?", *q.MinSize)
+	}
+	if q.MaxSize != nil {
+		db = db.Where("size <= ?", *q.MaxSize)
+	}
+	if q.CreatedAfter != nil {
+		db = db.Where("created_at >= ?", *q.CreatedAfter)
+	}
+	if q.CreatedBefore != nil {
+		db = db.Where("created_at < ?", *q.CreatedBefore)
+	}
+	if len(q.Tags) > 0 {
+		tags, err := json.Marshal(q.Tags)
+		if err != nil {
+			return nil, err
+		}
+		db = db.Where("tags @> ?::jsonb", string(tags))
+	}
+	if len(q.Properties) > 0 {
+		properties, err := json.Marshal(q.Properties)
+		if err != nil {
+			return nil, err
+		}
+		db = db.Where("properties @> ?::jsonb", string(properties))
+	}
+	for _, key := range q.PropertyKeys {
+		db = db.Where("properties->>? IS NOT NULL", key)
+	}
+
+	direction, comparison := "ASC", ">"
+	if q.Desc {
+		direction, comparison = "DESC", "<"
+	}
+	if q.AfterValue != nil {
+		db = db.Where(fmt.Sprintf("(%s, id) %s (?, ?)", q.SortColumn, comparison), q.AfterValue, q.AfterID)
+	}
+
+	var files []models.File
+	err := db.Order(fmt.Sprintf("%s %s, id %s", q.SortColumn, direction, direction)).
+		Limit(q.Limit).Find(&files).Error
+	return files, err
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
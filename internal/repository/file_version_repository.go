@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/lskeey/go-filehub/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WithFileLock runs fn in a transaction holding a row lock on the file, so
+// version numbers are assigned without races. The repository passed to fn
+// is bound to the transaction.
+func (r *FileRepository) WithFileLock(fileID uint, fn func(repo *FileRepository, file *models.File) error) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var file models.File
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&file, fileID).Error; err != nil {
+			return err
+		}
+		return fn(&FileRepository{DB: tx}, &file)
+	})
+}
+
+// CreateFileWithVersion saves a new file together with its first version.
+func (r *FileRepository) CreateFileWithVersion(file *models.File, version *models.FileVersion) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(file).Error; err != nil {
+			return err
+		}
+		version.FileID = file.ID
+		return tx.Create(version).Error
+	})
+}
+
+// UpdateFile saves all fields of a file record.
+func (r *FileRepository) UpdateFile(file *models.File) error {
+	return r.DB.Save(file).Error
+}
+
+// CreateVersion saves a new file version.
+func (r *FileRepository) CreateVersion(version *models.FileVersion) error {
+	return r.DB.Create(version).Error
+}
+
+// FindVersionsByFileID retrieves all versions of a file, newest first.
+func (r *FileRepository) FindVersionsByFileID(fileID uint) ([]models.FileVersion, error) {
+	var versions []models.FileVersion
+	err := r.DB.Where("file_id = ?", fileID).Order("version DESC").Find(&versions).Error
+	return versions, err
+}
+
+// FindVersion retrieves a specific version of a file.
+func (r *FileRepository) FindVersion(fileID uint, version int) (*models.FileVersion, error) {
+	var v models.FileVersion
+	err := r.DB.Where("file_id = ? AND version = ?", fileID, version).First(&v).Error
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// LatestVersionNumber returns the highest version number of a file, or 0 if it has none.
+func (r *FileRepository) LatestVersionNumber(fileID uint) (int, error) {
+	var latest int
+	err := r.DB.Model(&models.FileVersion{}).Where("file_id = ?", fileID).
+		Select("COALESCE(MAX(version), 0)").Scan(&latest).Error
+	return latest, err
+}
+
+// FindPrunableVersions returns the versions of a file other than current that
+// are beyond the newest keep versions (if keep > 0) and created before
+// olderThan (if non-zero).
+func (r *FileRepository) FindPrunableVersions(fileID uint, current, keep int, olderThan time.Time) ([]models.FileVersion, error) {
+	var versions []models.FileVersion
+	query := r.DB.Where("file_id = ? AND version <> ?", fileID, current)
+	if !olderThan.IsZero() {
+		query = query.Where("created_at < ?", olderThan)
+	}
+	if keep > 0 {
+		newest := r.DB.Model(&models.FileVersion{}).Select("version").
+			Where("file_id = ?", fileID).Order("version DESC").Limit(keep)
+		query = query.Where("version NOT IN (?)", newest)
+	}
+	err := query.Order("version ASC").Find(&versions).Error
+	return versions, err
+}
+
+// DeleteVersions permanently removes the given versions.
+func (r *FileRepository) DeleteVersions(versions []models.FileVersion) error {
+	if len(versions) == 0 {
+		return nil
+	}
+	return r.DB.Delete(&versions).Error
+}
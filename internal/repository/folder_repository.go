@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"github.com/lskeey/go-filehub/internal/models"
+	"gorm.io/gorm"
+)
+
+type FolderRepository struct {
+	DB *gorm.DB
+}
+
+// NewFolderRepository creates a new folder repository.
+func NewFolderRepository(db *gorm.DB) *FolderRepository {
+	return &FolderRepository{DB: db}
+}
+
+// CreateFolder saves a new folder.
+func (r *FolderRepository) CreateFolder(folder *models.Folder) error {
+	return r.DB.Create(folder).Error
+}
+
+// FindFolderByID retrieves a single folder by its ID.
+func (r *FolderRepository) FindFolderByID(folderID uint) (*models.Folder, error) {
+	var folder models.Folder
+	err := r.DB.First(&folder, folderID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+// FindChildFolders retrieves the folders directly inside parentID (nil for the root).
+func (r *FolderRepository) FindChildFolders(userID uint, parentID *uint) ([]models.Folder, error) {
+	var folders []models.Folder
+	err := whereParent(r.DB.Where("owner_id = ?", userID), "parent_id", parentID).
+		Order("name").Find(&folders).Error
+	return folders, err
+}
+
+// FindFolderByName retrieves the folder called name inside parentID (nil for the root).
+func (r *FolderRepository) FindFolderByName(userID uint, parentID *uint, name string) (*models.Folder, error) {
+	var folder models.Folder
+	err := whereParent(r.DB.Where("owner_id = ? AND name = ?", userID, name), "parent_id", parentID).
+		First(&folder).Error
+	if err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+// FindDescendantIDs returns the IDs of a folder and all folders below it.
+func (r *FolderRepository) FindDescendantIDs(folderID uint) ([]uint, error) {
+	var ids []uint
+	err := r.DB.Raw(`
+		WITH RECURSIVE tree AS (
+			SELECT id FROM folders WHERE id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT f.id FROM folders f JOIN tree t ON f.parent_id = t.id WHERE f.deleted_at IS NULL
+		)
+		SELECT id FROM tree`, folderID).Scan(&ids).Error
+	return ids, err
+}
+
+// FindAncestorIDs returns the IDs of a folder and all folders above it.
+func (r *FolderRepository) FindAncestorIDs(folderID uint) ([]uint, error) {
+	var ids []uint
+	err := r.DB.Raw(`
+		WITH RECURSIVE chain AS (
+			SELECT id, parent_id FROM folders WHERE id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT f.id, f.parent_id FROM folders f JOIN chain c ON f.id = c.parent_id WHERE f.deleted_at IS NULL
+		)
+		SELECT id FROM chain`, folderID).Scan(&ids).Error
+	return ids, err
+}
+
+// FindFoldersByIDs retrieves the folders with the given IDs.
+func (r *FolderRepository) FindFoldersByIDs(ids []uint) ([]models.Folder, error) {
+	var folders []models.Folder
+	if len(ids) == 0 {
+		return folders, nil
+	}
+	err := r.DB.Where("id IN ?", ids).Find(&folders).Error
+	return folders, err
+}
+
+// UpdateFolder saves all fields of a folder.
+func (r *FolderRepository) UpdateFolder(folder *models.Folder) error {
+	return r.DB.Save(folder).Error
+}
+
+// whereParent filters column by a nullable folder ID, where nil means the root.
+func whereParent(db *gorm.DB, column string, folderID *uint) *gorm.DB {
+	if folderID == nil {
+		return db.Where(column + " IS NULL")
+	}
+	return db.Where(column+" = ?", *folderID)
+}
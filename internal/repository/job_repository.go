@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/lskeey/go-filehub/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type JobRepository struct {
+	DB *gorm.DB
+}
+
+// NewJobRepository creates a new job repository.
+func NewJobRepository(db *gorm.DB) *JobRepository {
+	return &JobRepository{DB: db}
+}
+
+// CreateJob enqueues a job. A job whose unique key is already taken is not
+// created; it reports false in that case.
+func (r *JobRepository) CreateJob(job *models.Job) (bool, error) {
+	result := r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(job)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ClaimJob marks the oldest due pending job of one of the given types as
+// running by worker and returns it, or nil if there is none. SKIP LOCKED lets
+// concurrent workers claim different jobs without waiting on each other.
+func (r *JobRepository) ClaimJob(worker string, types []string) (*models.Job, error) {
+	var jobs []models.Job
+	err := r.DB.Raw(`
+		UPDATE jobs SET status = ?, attempts = attempts + 1, locked_by = ?, locked_at = now(), updated_at = now()
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE status = ? AND run_at <= now() AND type IN ?
+			ORDER BY run_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, models.JobRunning, worker, models.JobPending, types).Scan(&jobs).Error
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+	return &jobs[0], nil
+}
+
+// TouchJob renews the lock of a job that worker is still running, so it
+// isn't taken for stale. It reports false if the job's attempt is no longer
+// locked by worker, e.g. because it was requeued in the meantime.
+func (r *JobRepository) TouchJob(job *models.Job, worker string) (bool, error) {
+	result := r.DB.Model(&models.Job{}).
+		Where("id = ? AND status = ? AND locked_by = ? AND attempts = ?", job.ID, models.JobRunning, worker, job.Attempts).
+		Update("locked_at", gorm.Expr("now()"))
+	return result.RowsAffected > 0, result.Error
+}
+
+// CompleteJob marks a running job as succeeded.
+func (r *JobRepository) CompleteJob(id uint) error {
+	return r.finishJob(id, models.JobSucceeded, "")
+}
+
+// KillJob moves a running job to the dead letters after its last failure.
+// Its unique key is released, so the same work can be enqueued again.
+func (r *JobRepository) KillJob(id uint, lastError string) error {
+	updates := finishUpdates(models.JobDead, lastError)
+	updates["unique_key"] = nil
+	return r.DB.Model(&models.Job{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *JobRepository) finishJob(id uint, status, lastError string) error {
+	return r.DB.Model(&models.Job{}).Where("id = ?", id).Updates(finishUpdates(status, lastError)).Error
+}
+
+func finishUpdates(status, lastError string) map[string]interface{} {
+	return map[string]interface{}{
+		"status":      status,
+		"last_error":  lastError,
+		"locked_by":   "",
+		"locked_at":   nil,
+		"finished_at": time.Now(),
+	}
+}
+
+// RetryJob returns a failed job to the queue to run again at runAt.
+func (r *JobRepository) RetryJob(id uint, runAt time.Time, lastError string) error {
+	return r.DB.Model(&models.Job{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     models.JobPending,
+		"run_at":     runAt,
+		"last_error": lastError,
+		"locked_by":  "",
+		"locked_at":  nil,
+	}).Error
+}
+
+// ReleaseJob returns a job interrupted by a shutdown to the queue without
+// counting the attempt.
+func (r *JobRepository) ReleaseJob(id uint) error {
+	return r.DB.Model(&models.Job{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":    models.JobPending,
+		"attempts":  gorm.Expr("GREATEST(attempts - 1, 0)"),
+		"locked_by": "",
+		"locked_at": nil,
+	}).Error
+}
+
+// RequeueStaleJobs returns running jobs whose lock was last renewed before cutoff,
+// e.g. because their worker crashed, to the queue. It returns how many.
+func (r *JobRepository) RequeueStaleJobs(cutoff time.Time) (int64, error) {
+	result := r.DB.Model(&models.Job{}).
+		Where("status = ? AND locked_at < ?", models.JobRunning, cutoff).
+		Updates(map[string]interface{}{
+			"status":     models.JobPending,
+			"run_at":     time.Now(),
+			"last_error": "worker stopped responding",
+			"locked_by":  "",
+			"locked_at":  nil,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// DeleteSucceededJobs removes jobs that succeeded before cutoff.
+func (r *JobRepository) DeleteSucceededJobs(cutoff time.Time) (int64, error) {
+	result := r.DB.Where("status = ? AND finished_at < ?", models.JobSucceeded, cutoff).Delete(&models.Job{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"github.com/lskeey/go-filehub/internal/models"
+	"gorm.io/gorm"
+)
+
+type LinkRepository struct {
+	DB *gorm.DB
+}
+
+// NewLinkRepository creates a new public link repository.
+func NewLinkRepository(db *gorm.DB) *LinkRepository {
+	return &LinkRepository{DB: db}
+}
+
+// CreateLink saves a new public link.
+func (r *LinkRepository) CreateLink(link *models.PublicLink) error {
+	return r.DB.Create(link).Error
+}
+
+// UpdateLink saves all fields of a public link.
+func (r *LinkRepository) UpdateLink(link *models.PublicLink) error {
+	return r.DB.Save(link).Error
+}
+
+// FindLinkByID retrieves a single public link by its ID.
+func (r *LinkRepository) FindLinkByID(linkID uint) (*models.PublicLink, error) {
+	var link models.PublicLink
+	err := r.DB.First(&link, linkID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// FindLinkByToken retrieves a public link by its token.
+func (r *LinkRepository) FindLinkByToken(token string) (*models.PublicLink, error) {
+	var link models.PublicLink
+	err := r.DB.Where("token = ?", token).First(&link).Error
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// FindLinksByOwnerID retrieves a user's public links, optionally limited to
+// one file or folder, newest first.
+func (r *LinkRepository) FindLinksByOwnerID(ownerID uint, fileID, folderID *uint) ([]models.PublicLink, error) {
+	var links []models.PublicLink
+	query := r.DB.Where("owner_id = ?", ownerID)
+	if fileID != nil {
+		query = query.Where("file_id = ?", *fileID)
+	}
+	if folderID != nil {
+		query = query.Where("folder_id = ?", *folderID)
+	}
+	err := query.Order("created_at DESC").Find(&links).Error
+	return links, err
+}
+
+// IncrementDownloadCount atomically counts a download, unless the link has
+// reached its download limit. It reports whether the download was counted.
+func (r *LinkRepository) IncrementDownloadCount(linkID uint) (bool, error) {
+	result := r.DB.Model(&models.PublicLink{}).
+		Where("id = ? AND (max_downloads = 0 OR download_count < max_downloads)", linkID).
+		UpdateColumn("download_count", gorm.Expr("download_count + 1"))
+	return result.RowsAffected == 1, result.Error
+}
+
+// CreateAccess records a request made through a public link.
+func (r *LinkRepository) CreateAccess(access *models.LinkAccess) error {
+	return r.DB.Create(access).Error
+}
+
+// FindAccessesByLinkID retrieves the access log of a link, newest first.
+func (r *LinkRepository) FindAccessesByLinkID(linkID uint) ([]models.LinkAccess, error) {
+	var accesses []models.LinkAccess
+	err := r.DB.Where("link_id = ?", linkID).Order("created_at DESC").Find(&accesses).Error
+	return accesses, err
+}
+
+// deleteLinksWhere removes the public links matching the condition, together
+// with their access log.
+func deleteLinksWhere(tx *gorm.DB, query string, args ...interface{}) error {
+	linkIDs := tx.Model(&models.PublicLink{}).Select("id").Where(query, args...)
+	if err := tx.Where("link_id IN (?)", linkIDs).Delete(&models.LinkAccess{}).Error; err != nil {
+		return err
+	}
+	return tx.Where(query, args...).Delete(&models.PublicLink{}).Error
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/lskeey/go-filehub/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MultipartRepository struct {
+	DB *gorm.DB
+}
+
+// NewMultipartRepository creates a new multipart upload repository.
+func NewMultipartRepository(db *gorm.DB) *MultipartRepository {
+	return &MultipartRepository{DB: db}
+}
+
+// CreateUpload saves a new multipart upload.
+func (r *MultipartRepository) CreateUpload(upload *models.MultipartUpload) error {
+	return r.DB.Create(upload).Error
+}
+
+// FindUploadByID retrieves a multipart upload by its ID.
+func (r *MultipartRepository) FindUploadByID(id string) (*models.MultipartUpload, error) {
+	var upload models.MultipartUpload
+	err := r.DB.Where("id = ?", id).First(&upload).Error
+	if err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// FindUploadsCreatedBefore retrieves multipart uploads started before cutoff.
+func (r *MultipartRepository) FindUploadsCreatedBefore(cutoff time.Time) ([]models.MultipartUpload, error) {
+	var uploads []models.MultipartUpload
+	err := r.DB.Where("created_at < ?", cutoff).Find(&uploads).Error
+	return uploads, err
+}
+
+// SavePart records an uploaded part, replacing an earlier one with the same number.
+func (r *MultipartRepository) SavePart(part *models.MultipartPart) error {
+	return r.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(part).Error
+}
+
+// FindParts retrieves the parts of an upload ordered by part number.
+func (r *MultipartRepository) FindParts(uploadID string) ([]models.MultipartPart, error) {
+	var parts []models.MultipartPart
+	err := r.DB.Where("upload_id = ?", uploadID).Order("part_number").Find(&parts).Error
+	return parts, err
+}
+
+// DeletePart removes a single part of an upload.
+func (r *MultipartRepository) DeletePart(uploadID string, partNumber int) error {
+	return r.DB.Where("upload_id = ? AND part_number = ?", uploadID, partNumber).Delete(&models.MultipartPart{}).Error
+}
+
+// SumPartSizes returns the bytes staged for an upload by all parts except
+// partNumber.
+func (r *MultipartRepository) SumPartSizes(uploadID string, partNumber int) (int64, error) {
+	var total int64
+	err := r.DB.Model(&models.MultipartPart{}).
+		Where("upload_id = ? AND part_number <> ?", uploadID, partNumber).
+		Select("COALESCE(SUM(size), 0)").Scan(&total).Error
+	return total, err
+}
+
+// DeleteUpload removes a multipart upload and its parts.
+func (r *MultipartRepository) DeleteUpload(id string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("upload_id = ?", id).Delete(&models.MultipartPart{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&models.MultipartUpload{}).Error
+	})
+}
@@ -0,0 +1,77 @@
+package repository
+
+// ObjectKey is an entry of an S3 object listing: a file, or a folder when
+// FileID is nil, with its key relative to the listed bucket.
+type ObjectKey struct {
+	Key    string
+	FileID *uint
+}
+
+// ObjectKeyQuery selects object keys below a folder. Keys compare byte by
+// byte, as in S3, regardless of the database collation.
+type ObjectKeyQuery struct {
+	FolderID   uint   // Folder the keys are below
+	BasePath   string // Key of FolderID, the start of every key
+	Prefix     string // Only keys starting with Prefix
+	After      string // Only keys after After
+	SkipPrefix string // If set, no keys starting with SkipPrefix
+	Limit      int
+}
+
+// FindChildKeys retrieves the keys of the files and folders directly inside
+// q.FolderID, in key order. Folder keys end with a slash.
+func (r *FileRepository) FindChildKeys(q ObjectKeyQuery) ([]ObjectKey, error) {
+	var keys []ObjectKey
+	err := r.DB.Raw(`
+		SELECT key, file_id FROM (
+			SELECT @base || name || '/' AS key, CAST(NULL AS bigint) AS file_id
+			FROM folders WHERE parent_id = @folder AND deleted_at IS NULL
+			UNION ALL
+			SELECT @base || file_name, id
+			FROM files WHERE folder_id = @folder AND deleted_at IS NULL
+		) o
+		WHERE starts_with(key, @prefix) AND key COLLATE "C" > @after
+			AND (@skip = '' OR NOT starts_with(key, @skip))
+		ORDER BY key COLLATE "C"
+		LIMIT @limit`, objectKeyArgs(q)).Scan(&keys).Error
+	return keys, err
+}
+
+// FindFileKeysBelow retrieves the keys of the files inside q.FolderID and
+// all folders below it, in key order. Subtrees that can't hold a matching
+// key are not visited.
+func (r *FileRepository) FindFileKeysBelow(q ObjectKeyQuery) ([]ObjectKey, error) {
+	var keys []ObjectKey
+	err := r.DB.Raw(`
+		WITH RECURSIVE tree(id, path) AS (
+			SELECT CAST(@folder AS bigint), CAST(@base AS text)
+			UNION ALL
+			SELECT f.id, t.path || f.name || '/'
+			FROM folders f JOIN tree t ON f.parent_id = t.id
+			WHERE f.deleted_at IS NULL
+				AND (starts_with(t.path || f.name || '/', @prefix) OR starts_with(@prefix, t.path || f.name || '/'))
+				AND ((t.path || f.name || '/') COLLATE "C" > @after OR starts_with(@after, t.path || f.name || '/'))
+				AND (@skip = '' OR NOT starts_with(t.path || f.name || '/', @skip))
+		)
+		SELECT key, file_id FROM (
+			SELECT t.path || files.file_name AS key, files.id AS file_id
+			FROM files JOIN tree t ON files.folder_id = t.id
+			WHERE files.deleted_at IS NULL
+		) o
+		WHERE starts_with(key, @prefix) AND key COLLATE "C" > @after
+			AND (@skip = '' OR NOT starts_with(key, @skip))
+		ORDER BY key COLLATE "C"
+		LIMIT @limit`, objectKeyArgs(q)).Scan(&keys).Error
+	return keys, err
+}
+
+func objectKeyArgs(q ObjectKeyQuery) map[string]interface{} {
+	return map[string]interface{}{
+		"folder": q.FolderID,
+		"base":   q.BasePath,
+		"prefix": q.Prefix,
+		"after":  q.After,
+		"skip":   q.SkipPrefix,
+		"limit":  q.Limit,
+	}
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/lskeey/go-filehub/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ScanRepository struct {
+	DB *gorm.DB
+}
+
+// NewScanRepository creates a new repository for virus scan results.
+func NewScanRepository(db *gorm.DB) *ScanRepository {
+	return &ScanRepository{DB: db}
+}
+
+// FindFilesToScan retrieves files whose current content has not been
+// scanned yet, or whose last scan failed before retryBefore, oldest first.
+// Files stored before content deduplication are included.
+func (r *ScanRepository) FindFilesToScan(retryBefore time.Time, limit int) ([]models.File, error) {
+	var files []models.File
+	err := r.DB.Where("(scan_status = ? OR (scan_status = ? AND scanned_at < ?))",
+		models.ScanPending, models.ScanError, retryBefore).
+		Order("id").Limit(limit).Find(&files).Error
+	return files, err
+}
+
+// FindVersionsToScan retrieves versions other than the current one of their
+// file that have not been scanned yet, or whose last scan failed before
+// retryBefore. Current versions are found by FindFilesToScan.
+func (r *ScanRepository) FindVersionsToScan(retryBefore time.Time, limit int) ([]models.FileVersion, error) {
+	var versions []models.FileVersion
+	err := r.DB.Joins("JOIN files ON files.id = file_versions.file_id AND files.version <> file_versions.version AND files.deleted_at IS NULL").
+		Where("(file_versions.scan_status = ? OR (file_versions.scan_status = ? AND file_versions.scanned_at < ?))",
+			models.ScanPending, models.ScanError, retryBefore).
+		Order("file_versions.id").Limit(limit).Find(&versions).Error
+	return versions, err
+}
+
+// SetScanStatus records the scan result of a file version, and of the file
+// itself unless its content has changed to another version in the meantime.
+func (r *ScanRepository) SetScanStatus(fileID uint, version int, status string) error {
+	updates := map[string]interface{}{"scan_status": status, "scanned_at": time.Now()}
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.File{}).Where("id = ? AND version = ?", fileID, version).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.FileVersion{}).Where("file_id = ? AND version = ?", fileID, version).Updates(updates).Error
+	})
+}
+
+// FindQuarantinedBlob retrieves the quarantine record of content, if any.
+func (r *ScanRepository) FindQuarantinedBlob(digest string) (*models.QuarantinedBlob, error) {
+	var blob models.QuarantinedBlob
+	err := r.DB.Where("digest = ?", digest).First(&blob).Error
+	if err != nil {
+		return nil, err
+	}
+	return &blob, nil
+}
+
+// Quarantine records content as infected and marks every file currently
+// holding it, in the trash or not, and every version holding it as infected.
+func (r *ScanRepository) Quarantine(blob *models.QuarantinedBlob) error {
+	updates := map[string]interface{}{"scan_status": models.ScanInfected, "scanned_at": time.Now()}
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(blob).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.File{}).Where("blob_digest = ?", blob.Digest).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.FileVersion{}).Where("blob_digest = ?", blob.Digest).Updates(updates).Error
+	})
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"github.com/lskeey/go-filehub/internal/models"
+	"gorm.io/gorm"
+)
+
+type ShareRepository struct {
+	DB *gorm.DB
+}
+
+// NewShareRepository creates a new share repository.
+func NewShareRepository(db *gorm.DB) *ShareRepository {
+	return &ShareRepository{DB: db}
+}
+
+// CreateShare saves a new share.
+func (r *ShareRepository) CreateShare(share *models.Share) error {
+	return r.DB.Create(share).Error
+}
+
+// UpdateShare saves all fields of a share.
+func (r *ShareRepository) UpdateShare(share *models.Share) error {
+	return r.DB.Save(share).Error
+}
+
+// FindShareByID retrieves a single share by its ID.
+func (r *ShareRepository) FindShareByID(shareID uint) (*models.Share, error) {
+	var share models.Share
+	err := r.DB.First(&share, shareID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+// FindShare retrieves the share granting granteeID access to a file or a folder.
+func (r *ShareRepository) FindShare(granteeID uint, fileID, folderID *uint) (*models.Share, error) {
+	var share models.Share
+	query := whereParent(r.DB.Where("grantee_id = ?", granteeID), "file_id", fileID)
+	err := whereParent(query, "folder_id", folderID).First(&share).Error
+	if err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+// FindSharesByOwnerID retrieves the shares of a user's items, optionally
+// limited to one file or folder.
+func (r *ShareRepository) FindSharesByOwnerID(ownerID uint, fileID, folderID *uint) ([]models.Share, error) {
+	var shares []models.Share
+	query := r.DB.Where("owner_id = ?", ownerID)
+	if fileID != nil {
+		query = query.Where("file_id = ?", *fileID)
+	}
+	if folderID != nil {
+		query = query.Where("folder_id = ?", *folderID)
+	}
+	err := query.Order("created_at").Find(&shares).Error
+	return shares, err
+}
+
+// FindSharesByGranteeID retrieves the shares granted to a user, newest first.
+func (r *ShareRepository) FindSharesByGranteeID(granteeID uint) ([]models.Share, error) {
+	var shares []models.Share
+	err := r.DB.Where("grantee_id = ?", granteeID).Order("created_at DESC").Find(&shares).Error
+	return shares, err
+}
+
+// FindGrantedPermissions returns the permissions granteeID holds through
+// shares of fileID (if not nil) or of any of the given folders.
+func (r *ShareRepository) FindGrantedPermissions(granteeID uint, fileID *uint, folderIDs []uint) ([]string, error) {
+	var permissions []string
+	if fileID == nil && len(folderIDs) == 0 {
+		return permissions, nil
+	}
+	items := r.DB.Where("folder_id IN ?", folderIDs)
+	if fileID != nil {
+		items = items.Or("file_id = ?", *fileID)
+	}
+	err := r.DB.Model(&models.Share{}).Where("grantee_id = ?", granteeID).Where(items).
+		Pluck("permission", &permissions).Error
+	return permissions, err
+}
+
+// DeleteShare removes a share.
+func (r *ShareRepository) DeleteShare(shareID uint) error {
+	return r.DB.Delete(&models.Share{}, shareID).Error
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/lskeey/go-filehub/internal/models"
+	"gorm.io/gorm"
+)
+
+type SignedURLRepository struct {
+	DB *gorm.DB
+}
+
+// NewSignedURLRepository creates a new signed URL repository.
+func NewSignedURLRepository(db *gorm.DB) *SignedURLRepository {
+	return &SignedURLRepository{DB: db}
+}
+
+// UseNonce records a nonce as used. It reports false if it was used before.
+func (r *SignedURLRepository) UseNonce(nonce string, expiresAt time.Time) (bool, error) {
+	err := r.DB.Create(&models.SignedURLNonce{Nonce: nonce, ExpiresAt: expiresAt}).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// DeleteExpiredNonces removes nonces of URLs that expired before t.
+func (r *SignedURLRepository) DeleteExpiredNonces(t time.Time) error {
+	return r.DB.Where("expires_at < ?", t).Delete(&models.SignedURLNonce{}).Error
+}
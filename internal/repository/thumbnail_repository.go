@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"github.com/lskeey/go-filehub/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ThumbnailRepository struct {
+	DB *gorm.DB
+}
+
+// NewThumbnailRepository creates a new thumbnail repository.
+func NewThumbnailRepository(db *gorm.DB) *ThumbnailRepository {
+	return &ThumbnailRepository{DB: db}
+}
+
+// FindFilesToThumbnail retrieves one file per blob that has no thumbnails
+// yet, limited to files whose MIME type or extension is in the given lists.
+func (r *ThumbnailRepository) FindFilesToThumbnail(mimeTypes, extensions []string, limit int) ([]models.File, error) {
+	var files []models.File
+	err := r.DB.Raw(`
+		SELECT DISTINCT ON (f.blob_digest) f.* FROM files f
+		WHERE f.deleted_at IS NULL AND f.blob_digest <> ''
+			AND (lower(f.mime_type) IN ? OR lower(substring(f.file_name FROM '\.[^.]*$')) IN ?)
+			AND NOT EXISTS (SELECT 1 FROM thumbnails t WHERE t.blob_digest = f.blob_digest)
+		ORDER BY f.blob_digest, f.id
+		LIMIT ?`, mimeTypes, extensions, limit).Scan(&files).Error
+	return files, err
+}
+
+// FindThumbnail retrieves the thumbnail of a blob in the named size.
+func (r *ThumbnailRepository) FindThumbnail(digest, size string) (*models.Thumbnail, error) {
+	var thumb models.Thumbnail
+	err := r.DB.Where("blob_digest = ? AND size = ?", digest, size).First(&thumb).Error
+	if err != nil {
+		return nil, err
+	}
+	return &thumb, nil
+}
+
+// SaveThumbnails inserts or replaces thumbnail records.
+func (r *ThumbnailRepository) SaveThumbnails(thumbs []models.Thumbnail) error {
+	if len(thumbs) == 0 {
+		return nil
+	}
+	return r.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(&thumbs).Error
+}
+
+// FindOrphanedThumbnails retrieves thumbnails whose blob no longer exists.
+func (r *ThumbnailRepository) FindOrphanedThumbnails(limit int) ([]models.Thumbnail, error) {
+	var thumbs []models.Thumbnail
+	err := r.DB.Where("NOT EXISTS (SELECT 1 FROM blobs b WHERE b.digest = thumbnails.blob_digest)").
+		Limit(limit).Find(&thumbs).Error
+	return thumbs, err
+}
+
+// DeleteThumbnail removes a thumbnail record.
+func (r *ThumbnailRepository) DeleteThumbnail(thumb *models.Thumbnail) error {
+	return r.DB.Where("blob_digest = ? AND size = ?", thumb.BlobDigest, thumb.Size).
+		Delete(&models.Thumbnail{}).Error
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/lskeey/go-filehub/internal/models"
+	"gorm.io/gorm"
+)
+
+// Items in the trash are soft-deleted rows. Everything deleted in one
+// operation (a folder and its content) shares the same deleted_at timestamp,
+// which is how the trash tells top-level entries from the items inside them.
+
+// TrashFiles soft-deletes the live files among ids with the given timestamp.
+func (r *FileRepository) TrashFiles(ids []uint, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.DB.Model(&models.File{}).Where("id IN ?", ids).Update("deleted_at", at).Error
+}
+
+// FindTrashedFiles retrieves the user's trashed files that were not deleted
+// together with their folder.
+func (r *FileRepository) FindTrashedFiles(userID uint) ([]models.File, error) {
+	var files []models.File
+	err := r.DB.Unscoped().
+		Joins("LEFT JOIN folders parent ON parent.id = files.folder_id").
+		Where("files.owner_id = ? AND files.deleted_at IS NOT NULL", userID).
+		Where("parent.deleted_at IS NULL OR parent.deleted_at <> files.deleted_at").
+		Order("files.deleted_at DESC").Find(&files).Error
+	return files, err
+}
+
+// FindTrashedFileByID retrieves a file from the trash.
+func (r *FileRepository) FindTrashedFileByID(fileID uint) (*models.File, error) {
+	var file models.File
+	err := r.DB.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", fileID).First(&file).Error
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// FindFilesTrashedWith retrieves the files inside folderIDs that were trashed at the given time.
+func (r *FileRepository) FindFilesTrashedWith(folderIDs []uint, at time.Time) ([]models.File, error) {
+	var files []models.File
+	if len(folderIDs) == 0 {
+		return files, nil
+	}
+	err := r.DB.Unscoped().Where("folder_id IN ? AND deleted_at = ?", folderIDs, at).Find(&files).Error
+	return files, err
+}
+
+// FindFilesTrashedBefore retrieves trashed files of all users deleted before cutoff.
+// A zero userID matches every user.
+func (r *FileRepository) FindFilesTrashedBefore(userID uint, cutoff time.Time) ([]models.File, error) {
+	var files []models.File
+	query := r.DB.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+	if userID != 0 {
+		query = query.Where("owner_id = ?", userID)
+	}
+	err := query.Find(&files).Error
+	return files, err
+}
+
+// RestoreFiles takes files out of the trash.
+func (r *FileRepository) RestoreFiles(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.DB.Unscoped().Model(&models.File{}).Where("id IN ?", ids).Update("deleted_at", nil).Error
+}
+
+// PurgeFile permanently removes a file record together with its versions,
+// shares, public links and extracted text.
+func (r *FileRepository) PurgeFile(fileID uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("file_id = ?", fileID).Delete(&models.FileVersion{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("file_id = ?", fileID).Delete(&models.Share{}).Error; err != nil {
+			return err
+		}
+		if err := deleteLinksWhere(tx, "file_id = ?", fileID); err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.FileContent{}, fileID).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&models.File{}, fileID).Error
+	})
+}
+
+// TrashFolders soft-deletes the given folders with the given timestamp.
+func (r *FolderRepository) TrashFolders(ids []uint, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.DB.Model(&models.Folder{}).Where("id IN ?", ids).Update("deleted_at", at).Error
+}
+
+// FindTrashedFolders retrieves the user's trashed folders that were not
+// deleted together with their parent.
+func (r *FolderRepository) FindTrashedFolders(userID uint) ([]models.Folder, error) {
+	var folders []models.Folder
+	err := r.DB.Unscoped().
+		Joins("LEFT JOIN folders parent ON parent.id = folders.parent_id").
+		Where("folders.owner_id = ? AND folders.deleted_at IS NOT NULL", userID).
+		Where("parent.deleted_at IS NULL OR parent.deleted_at <> folders.deleted_at").
+		Order("folders.deleted_at DESC").Find(&folders).Error
+	return folders, err
+}
+
+// FindTrashedFolderByID retrieves a folder from the trash.
+func (r *FolderRepository) FindTrashedFolderByID(folderID uint) (*models.Folder, error) {
+	var folder models.Folder
+	err := r.DB.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", folderID).First(&folder).Error
+	if err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+// FindTrashedDescendantIDs returns the IDs of a trashed folder and of all
+// folders below it that were trashed at the same time.
+func (r *FolderRepository) FindTrashedDescendantIDs(folderID uint, at time.Time) ([]uint, error) {
+	var ids []uint
+	err := r.DB.Raw(`
+		WITH RECURSIVE tree AS (
+			SELECT id FROM folders WHERE id = ? AND deleted_at = ?
+			UNION ALL
+			SELECT f.id FROM folders f JOIN tree t ON f.parent_id = t.id WHERE f.deleted_at = ?
+		)
+		SELECT id FROM tree`, folderID, at, at).Scan(&ids).Error
+	return ids, err
+}
+
+// FindFoldersTrashedBefore retrieves trashed folders deleted before cutoff.
+// A zero userID matches every user.
+func (r *FolderRepository) FindFoldersTrashedBefore(userID uint, cutoff time.Time) ([]models.Folder, error) {
+	var folders []models.Folder
+	query := r.DB.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+	if userID != 0 {
+		query = query.Where("owner_id = ?", userID)
+	}
+	err := query.Find(&folders).Error
+	return folders, err
+}
+
+// RestoreFolders takes folders out of the trash.
+func (r *FolderRepository) RestoreFolders(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.DB.Unscoped().Model(&models.Folder{}).Where("id IN ?", ids).Update("deleted_at", nil).Error
+}
+
+// PurgeFolders permanently removes folder records with their shares and public links.
+func (r *FolderRepository) PurgeFolders(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("folder_id IN ?", ids).Delete(&models.Share{}).Error; err != nil {
+			return err
+		}
+		if err := deleteLinksWhere(tx, "folder_id IN ?", ids); err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&models.Folder{}, ids).Error
+	})
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/lskeey/go-filehub/internal/models"
+	"gorm.io/gorm"
+)
+
+type UploadRepository struct {
+	DB *gorm.DB
+}
+
+// NewUploadRepository creates a new upload repository.
+func NewUploadRepository(db *gorm.DB) *UploadRepository {
+	return &UploadRepository{DB: db}
+}
+
+// CreateUpload saves the state of a new resumable upload.
+func (r *UploadRepository) CreateUpload(upload *models.Upload) error {
+	return r.DB.Create(upload).Error
+}
+
+// FindUploadByID retrieves a resumable upload by its ID.
+func (r *UploadRepository) FindUploadByID(id string) (*models.Upload, error) {
+	var upload models.Upload
+	err := r.DB.Where("id = ?", id).First(&upload).Error
+	if err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// UpdateUpload persists the offset and completion state of an upload.
+func (r *UploadRepository) UpdateUpload(upload *models.Upload) error {
+	return r.DB.Save(upload).Error
+}
+
+// FindUploadsUpdatedBefore retrieves uploads that haven't changed since cutoff.
+func (r *UploadRepository) FindUploadsUpdatedBefore(cutoff time.Time) ([]models.Upload, error) {
+	var uploads []models.Upload
+	err := r.DB.Where("updated_at < ?", cutoff).Find(&uploads).Error
+	return uploads, err
+}
+
+// DeleteUpload removes a resumable upload record.
+func (r *UploadRepository) DeleteUpload(id string) error {
+	return r.DB.Where("id = ?", id).Delete(&models.Upload{}).Error
+}
@@ -28,3 +28,58 @@ func (r *UserRepository) FindUserByEmail(email string) (*models.User, error) {
 	}
 	return &user, nil
 }
+
+// FindUserByID retrieves a user by their ID.
+func (r *UserRepository) FindUserByID(userID uint) (*models.User, error) {
+	var user models.User
+	err := r.DB.First(&user, userID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindUsersByIDs retrieves the users with the given IDs.
+func (r *UserRepository) FindUsersByIDs(ids []uint) ([]models.User, error) {
+	var users []models.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	err := r.DB.Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
+// FindAllUserIDs retrieves the IDs of all users.
+func (r *UserRepository) FindAllUserIDs() ([]uint, error) {
+	var ids []uint
+	err := r.DB.Model(&models.User{}).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// AddUsedBytes atomically adds delta to a user's usage. When limit is
+// positive the update only happens if the new usage stays within it.
+// It reports whether the usage was updated.
+func (r *UserRepository) AddUsedBytes(userID uint, delta, limit int64) (bool, error) {
+	query := r.DB.Model(&models.User{}).Where("id = ?", userID)
+	if limit > 0 && delta > 0 {
+		query = query.Where("used_bytes + ? <= ?", delta, limit)
+	}
+	result := query.UpdateColumn("used_bytes", gorm.Expr("GREATEST(used_bytes + ?, 0)", delta))
+	return result.RowsAffected == 1, result.Error
+}
+
+// RecomputeUsedBytes sets a user's usage counter from the files table: every
+// version of every file, including trashed ones. Files without version history
+// count once. It returns the recomputed usage.
+func (r *UserRepository) RecomputeUsedBytes(userID uint) (int64, error) {
+	var used int64
+	err := r.DB.Raw(`
+		UPDATE users SET used_bytes = (
+			SELECT COALESCE(SUM(CASE WHEN v.id IS NULL THEN f.size ELSE v.size END), 0)
+			FROM files f LEFT JOIN file_versions v ON v.file_id = f.id
+			WHERE f.owner_id = users.id
+		)
+		WHERE id = ?
+		RETURNING used_bytes`, userID).Scan(&used).Error
+	return used, err
+}
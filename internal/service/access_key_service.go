@@ -0,0 +1,98 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lskeey/go-filehub/internal/models"
+	"github.com/lskeey/go-filehub/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAccessKeyNotFound = errors.New("access key not found")
+	ErrInvalidAccessKey  = errors.New("name must be between 1 and 100 characters")
+	ErrUnknownAccessKey  = errors.New("the access key ID does not exist")
+)
+
+// accessKeyPrefix starts every access key ID, which makes them easy to
+// recognize, e.g. in leaked configuration.
+const accessKeyPrefix = "FHAK"
+
+// AccessKeyService manages the key pairs used to sign requests to the
+// S3-compatible API.
+type AccessKeyService struct {
+	accessKeyRepo *repository.AccessKeyRepository
+}
+
+// NewAccessKeyService creates a new access key service.
+func NewAccessKeyService(accessKeyRepo *repository.AccessKeyRepository) *AccessKeyService {
+	return &AccessKeyService{accessKeyRepo: accessKeyRepo}
+}
+
+// CreateAccessKey creates a key pair for userID. The secret key is part of
+// the returned model but is never serialized, so the caller has to hand it
+// out explicitly.
+func (s *AccessKeyService) CreateAccessKey(userID uint, name string) (*models.AccessKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return nil, ErrInvalidAccessKey
+	}
+
+	id := make([]byte, 10)
+	secret := make([]byte, 30)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	key := &models.AccessKey{
+		UserID:      userID,
+		Name:        name,
+		AccessKeyID: accessKeyPrefix + base32.StdEncoding.EncodeToString(id),
+		SecretKey:   base64.RawURLEncoding.EncodeToString(secret),
+	}
+	if err := s.accessKeyRepo.CreateAccessKey(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// ListAccessKeys retrieves userID's access keys.
+func (s *AccessKeyService) ListAccessKeys(userID uint) ([]models.AccessKey, error) {
+	return s.accessKeyRepo.FindAccessKeysByUserID(userID)
+}
+
+// DeleteAccessKey revokes one of userID's access keys.
+func (s *AccessKeyService) DeleteAccessKey(id, userID uint) error {
+	deleted, err := s.accessKeyRepo.DeleteAccessKey(id, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrAccessKeyNotFound
+	}
+	return nil
+}
+
+// LookupAccessKey retrieves the key pair with the given access key ID, to
+// verify a request signed with it, and records that it was used.
+func (s *AccessKeyService) LookupAccessKey(accessKeyID string) (*models.AccessKey, error) {
+	key, err := s.accessKeyRepo.FindAccessKeyByAccessKeyID(accessKeyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUnknownAccessKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > time.Minute {
+		_ = s.accessKeyRepo.TouchAccessKey(key.ID, now)
+	}
+	return key, nil
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lskeey/go-filehub/internal/models"
+	"github.com/lskeey/go-filehub/internal/repository"
+	"github.com/lskeey/go-filehub/pkg/utils"
+)
+
+var (
+	ErrAppPasswordNotFound = errors.New("app password not found")
+	ErrInvalidAppPassword  = errors.New("name must be between 1 and 100 characters")
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+)
+
+// basicLoginTTL is how long a successful login with an account password is
+// remembered. Basic auth clients send credentials with every request, and
+// checking a bcrypt hash each time would make them crawl.
+const basicLoginTTL = time.Minute
+
+// AppPasswordService manages app passwords and checks Basic auth
+// credentials, which may be either the account password or an app password.
+type AppPasswordService struct {
+	appPasswordRepo *repository.AppPasswordRepository
+	userRepo        *repository.UserRepository
+
+	mu     sync.Mutex
+	logins map[[sha256.Size]byte]basicLogin
+}
+
+type basicLogin struct {
+	userID  uint
+	expires time.Time
+}
+
+// NewAppPasswordService creates a new app password service.
+func NewAppPasswordService(appPasswordRepo *repository.AppPasswordRepository, userRepo *repository.UserRepository) *AppPasswordService {
+	return &AppPasswordService{
+		appPasswordRepo: appPasswordRepo,
+		userRepo:        userRepo,
+		logins:          make(map[[sha256.Size]byte]basicLogin),
+	}
+}
+
+// CreateAppPassword creates an app password for userID. The password itself
+// is only returned here; just its hash is stored.
+func (s *AppPasswordService) CreateAppPassword(userID uint, name string) (*models.AppPassword, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return nil, "", ErrInvalidAppPassword
+	}
+
+	secret, err := newAppPassword()
+	if err != nil {
+		return nil, "", err
+	}
+	password := &models.AppPassword{
+		UserID: userID,
+		Name:   name,
+		Hash:   hashAppPassword(secret),
+		Hint:   secret[len(secret)-4:],
+	}
+	if err := s.appPasswordRepo.CreateAppPassword(password); err != nil {
+		return nil, "", err
+	}
+	return password, secret, nil
+}
+
+// ListAppPasswords retrieves userID's app passwords.
+func (s *AppPasswordService) ListAppPasswords(userID uint) ([]models.AppPassword, error) {
+	return s.appPasswordRepo.FindAppPasswordsByUserID(userID)
+}
+
+// DeleteAppPassword revokes one of userID's app passwords.
+func (s *AppPasswordService) DeleteAppPassword(id, userID uint) error {
+	deleted, err := s.appPasswordRepo.DeleteAppPassword(id, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrAppPasswordNotFound
+	}
+	return nil
+}
+
+// AuthenticateBasic checks Basic auth credentials: the user's email with
+// either one of their app passwords or their account password. It returns
+// the user's ID.
+func (s *AppPasswordService) AuthenticateBasic(email, password string) (uint, error) {
+	user, err := s.userRepo.FindUserByEmail(email)
+	if err != nil {
+		return 0, ErrInvalidCredentials
+	}
+
+	// App passwords are random, so a plain hash lookup is enough
+	if appPassword, err := s.appPasswordRepo.FindAppPasswordByHash(hashAppPassword(password)); err == nil {
+		if appPassword.UserID != user.ID {
+			return 0, ErrInvalidCredentials
+		}
+		now := time.Now()
+		if appPassword.LastUsedAt == nil || now.Sub(*appPassword.LastUsedAt) > time.Minute {
+			_ = s.appPasswordRepo.TouchAppPassword(appPassword.ID, now)
+		}
+		return user.ID, nil
+	}
+
+	key := sha256.Sum256([]byte(user.Password + "\x00" + password))
+	s.mu.Lock()
+	login, ok := s.logins[key]
+	s.mu.Unlock()
+	if ok && login.userID == user.ID && time.Now().Before(login.expires) {
+		return user.ID, nil
+	}
+
+	if !utils.CheckPasswordHash(password, user.Password) {
+		return 0, ErrInvalidCredentials
+	}
+	s.rememberLogin(key, user.ID)
+	return user.ID, nil
+}
+
+func (s *AppPasswordService) rememberLogin(key [sha256.Size]byte, userID uint) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.logins) >= 1024 {
+		for k, login := range s.logins {
+			if now.After(login.expires) {
+				delete(s.logins, k)
+			}
+		}
+	}
+	s.logins[key] = basicLogin{userID: userID, expires: now.Add(basicLoginTTL)}
+}
+
+// newAppPassword generates a random app password that is easy to type.
+func newAppPassword() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b)), nil
+}
+
+func hashAppPassword(password string) string {
+	sum := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/lskeey/go-filehub/internal/models"
+)
+
+// MaxArchiveEntries caps the number of files and folders in one archive.
+const MaxArchiveEntries = 10000
+
+var (
+	ErrInvalidArchive  = errors.New("file_ids or folder_ids must list at least one item")
+	ErrArchiveEmpty    = errors.New("none of the requested files or folders can be accessed")
+	ErrArchiveTooLarge = fmt.Errorf("archives are limited to %d files and folders", MaxArchiveEntries)
+)
+
+// ArchiveEntry is a file or folder placed in an archive.
+type ArchiveEntry struct {
+	Path string       // Slash-separated path inside the archive; folders end in "/"
+	File *models.File // nil for folders
+}
+
+// Archive lists the content of an archive of the given files and folders,
+// in a deterministic order: the requested files first, then each folder
+// with everything inside it, sorted by name. Items userID cannot view, or
+// that may not be downloaded because of the virus scan, are skipped and
+// counted. Names that are already taken in a directory, compared without
+// case, get a " (n)" suffix.
+func (s *FileService) Archive(userID uint, fileIDs, folderIDs []uint) ([]ArchiveEntry, int, error) {
+	if len(fileIDs) == 0 && len(folderIDs) == 0 {
+		return nil, 0, ErrInvalidArchive
+	}
+
+	a := &archiveBuilder{names: make(map[string]bool)}
+	for _, id := range dedupe(fileIDs) {
+		file, err := s.authorizeFile(id, userID, accessViewer)
+		if err != nil || s.scanService.CheckFile(file) != nil {
+			a.skipped++
+			continue
+		}
+		a.addFile("", file)
+	}
+	for _, id := range dedupe(folderIDs) {
+		folder, err := s.getFolder(id, userID, accessViewer)
+		if err != nil {
+			a.skipped++
+			continue
+		}
+		if err := s.archiveFolder(a, folder); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if len(a.entries) > MaxArchiveEntries {
+		return nil, 0, ErrArchiveTooLarge
+	}
+	if len(a.entries) == 0 {
+		return nil, 0, ErrArchiveEmpty
+	}
+	return a.entries, a.skipped, nil
+}
+
+// archiveFolder adds a folder and everything inside it at the archive root.
+func (s *FileService) archiveFolder(a *archiveBuilder, root *models.Folder) error {
+	ids, err := s.folderRepo.FindDescendantIDs(root.ID)
+	if err != nil {
+		return err
+	}
+	folders, err := s.folderRepo.FindFoldersByIDs(ids)
+	if err != nil {
+		return err
+	}
+	files, err := s.fileRepo.FindFilesInFolders(ids)
+	if err != nil {
+		return err
+	}
+	if len(folders)+len(files)+len(a.entries) > MaxArchiveEntries {
+		return ErrArchiveTooLarge
+	}
+
+	subfolders := make(map[uint][]*models.Folder)
+	for i := range folders {
+		if folders[i].ParentID != nil {
+			subfolders[*folders[i].ParentID] = append(subfolders[*folders[i].ParentID], &folders[i])
+		}
+	}
+	folderFiles := make(map[uint][]*models.File)
+	for i := range files {
+		folderFiles[*files[i].FolderID] = append(folderFiles[*files[i].FolderID], &files[i])
+	}
+
+	var walk func(dir string, folder *models.Folder)
+	walk = func(dir string, folder *models.Folder) {
+		dir = a.addFolder(dir, folder.Name)
+
+		children := folderFiles[folder.ID]
+		slices.SortFunc(children, func(x, y *models.File) int {
+			return cmpNameID(x.FileName, y.FileName, x.ID, y.ID)
+		})
+		for _, file := range children {
+			if s.scanService.CheckFile(file) != nil {
+				a.skipped++
+				continue
+			}
+			a.addFile(dir, file)
+		}
+
+		nested := subfolders[folder.ID]
+		slices.SortFunc(nested, func(x, y *models.Folder) int {
+			return cmpNameID(x.Name, y.Name, x.ID, y.ID)
+		})
+		for _, sub := range nested {
+			walk(dir, sub)
+		}
+	}
+	walk("", root)
+	return nil
+}
+
+type archiveBuilder struct {
+	entries []ArchiveEntry
+	names   map[string]bool // Lower-cased paths already taken
+	skipped int
+}
+
+func (a *archiveBuilder) addFile(dir string, file *models.File) {
+	name := a.uniqueName(dir, file.FileName, false)
+	a.entries = append(a.entries, ArchiveEntry{Path: name, File: file})
+}
+
+// addFolder adds a directory entry and returns its path.
+func (a *archiveBuilder) addFolder(dir, name string) string {
+	p := a.uniqueName(dir, name, true) + "/"
+	a.entries = append(a.entries, ArchiveEntry{Path: p})
+	return p
+}
+
+// uniqueName returns dir+name, or dir+"name (n).ext" with the lowest n
+// that is still free, and marks the result as taken.
+func (a *archiveBuilder) uniqueName(dir, name string, isDir bool) string {
+	base, ext := name, ""
+	if !isDir {
+		if e := path.Ext(name); e != "" && e != name {
+			base, ext = strings.TrimSuffix(name, e), e
+		}
+	}
+	candidate := dir + name
+	for n := 1; a.names[strings.ToLower(candidate)]; n++ {
+		candidate = fmt.Sprintf("%s%s (%d)%s", dir, base, n, ext)
+	}
+	a.names[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+func cmpNameID(a, b string, aID, bID uint) int {
+	if c := strings.Compare(a, b); c != 0 {
+		return c
+	}
+	return cmp.Compare(aID, bID)
+}
+
+func dedupe(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
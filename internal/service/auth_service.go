@@ -45,14 +45,14 @@ func (s *AuthService) Login(email, password string) (string, error) {
 	user, err := s.userRepo.FindUserByEmail(email)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return "", errors.New("invalid credentials")
+			return "", ErrInvalidCredentials
 		}
 		return "", err
 	}
 
 	// Check password
 	if !utils.CheckPasswordHash(password, user.Password) {
-		return "", errors.New("invalid credentials")
+		return "", ErrInvalidCredentials
 	}
 
 	// Generate JWT
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/lskeey/go-filehub/internal/models"
+	"github.com/lskeey/go-filehub/internal/repository"
+	"github.com/lskeey/go-filehub/internal/storage"
+	"gorm.io/gorm"
+)
+
+// BlobService stores content once per SHA-256 digest and reference counts it,
+// so identical uploads share the same bytes in the storage backend.
+type BlobService struct {
+	blobRepo *repository.BlobRepository
+	storage  storage.Storage
+}
+
+// NewBlobService creates a new blob service.
+func NewBlobService(repo *repository.BlobRepository, store storage.Storage) *BlobService {
+	return &BlobService{blobRepo: repo, storage: store}
+}
+
+// Store streams r into the storage backend while hashing it and returns the
+// blob holding the content with one new reference taken. If the content is
+// already known, the freshly written copy is discarded.
+func (s *BlobService) Store(ctx context.Context, r io.Reader, contentType string) (*models.Blob, error) {
+	id, err := newUploadID()
+	if err != nil {
+		return nil, err
+	}
+	tempKey := "tmp/" + id
+
+	hasher := sha256.New()
+	counter := &limitedCounter{r: io.TeeReader(r, hasher), limit: -1}
+	if err := s.storage.Put(ctx, tempKey, counter, -1, contentType); err != nil {
+		_ = s.storage.Delete(ctx, tempKey)
+		return nil, err
+	}
+	digest := hex.EncodeToString(hasher.Sum(nil))
+
+	var blob *models.Blob
+	err = s.blobRepo.WithDigestLock(digest, func(repo *repository.BlobRepository) error {
+		existing, err := repo.FindBlobByDigest(digest)
+		switch {
+		case err == nil:
+			blob = existing
+			blob.RefCount++
+			return repo.IncrementRefCount(digest)
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			blob = &models.Blob{
+				Digest:     digest,
+				Size:       counter.n,
+				StorageKey: blobKey(digest),
+				RefCount:   1,
+			}
+			if err := s.storage.Move(ctx, tempKey, blob.StorageKey); err != nil {
+				return err
+			}
+			return repo.CreateBlob(blob)
+		default:
+			return err
+		}
+	})
+
+	// The temporary copy is only left over when the content was already known
+	// or something went wrong
+	if err := s.storage.Delete(ctx, tempKey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		fmt.Printf("Failed to delete temporary object %s: %v\n", tempKey, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return blob, nil
+}
+
+// Acquire takes an additional reference on an existing blob.
+func (s *BlobService) Acquire(digest string) error {
+	return s.blobRepo.WithDigestLock(digest, func(repo *repository.BlobRepository) error {
+		return repo.IncrementRefCount(digest)
+	})
+}
+
+// Release drops a reference to a blob and removes its bytes once the last
+// reference is gone.
+func (s *BlobService) Release(ctx context.Context, digest string) error {
+	return s.blobRepo.WithDigestLock(digest, func(repo *repository.BlobRepository) error {
+		remaining, err := repo.DecrementRefCount(digest)
+		if err != nil {
+			return err
+		}
+		if remaining > 0 {
+			return nil
+		}
+
+		blob, err := repo.FindBlobByDigest(digest)
+		if err != nil {
+			return err
+		}
+		if err := repo.DeleteBlob(digest); err != nil {
+			return err
+		}
+		if err := s.storage.Delete(ctx, blob.StorageKey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+		return nil
+	})
+}
+
+// Open opens stored content for reading.
+func (s *BlobService) Open(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	return s.storage.Get(ctx, key)
+}
+
+// blobKey spreads blobs over 256 prefixes to keep directories small.
+func blobKey(digest string) string {
+	return fmt.Sprintf("blobs/%s/%s", digest[:2], digest)
+}
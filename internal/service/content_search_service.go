@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"html"
+	"log"
+	"strings"
+
+	"github.com/lskeey/go-filehub/internal/extract"
+	"github.com/lskeey/go-filehub/internal/models"
+	"github.com/lskeey/go-filehub/internal/repository"
+)
+
+// Page sizes of full-text search results.
+const (
+	DefaultSearchPageSize = 20
+	MaxSearchPageSize     = 100
+)
+
+// JobIndexContent is the recurring job that indexes new content.
+const JobIndexContent = "search.index"
+
+// indexBatchSize is the number of files the indexer processes per query.
+const indexBatchSize = 50
+
+// Markers ts_headline puts around matches. They are turned into <mark> tags
+// after the rest of the snippet has been HTML-escaped.
+const (
+	snippetStart = "<<HL>>"
+	snippetStop  = "<</HL>>"
+)
+
+var ErrInvalidContentSearch = errors.New("q is required, limit must be between 1 and 100 and offset must not be negative")
+
+// SearchResult is a file whose content matches a full-text query.
+type SearchResult struct {
+	File    models.File `json:"file"`
+	Rank    float64     `json:"rank"`
+	Snippet string      `json:"snippet"` // HTML-escaped text with matches wrapped in <mark>
+}
+
+// ContentSearchService extracts text from uploaded documents and searches it.
+type ContentSearchService struct {
+	contentRepo *repository.ContentRepository
+	blobService *BlobService
+}
+
+// NewContentSearchService creates a new content search service.
+func NewContentSearchService(contentRepo *repository.ContentRepository, blobService *BlobService) *ContentSearchService {
+	return &ContentSearchService{contentRepo: contentRepo, blobService: blobService}
+}
+
+// Search ranks the files userID can access by how well their text matches
+// query, which uses web search syntax ("quoted phrases", -excluded, or).
+func (s *ContentSearchService) Search(userID uint, query string, limit, offset int) ([]SearchResult, error) {
+	if limit == 0 {
+		limit = DefaultSearchPageSize
+	}
+	query = strings.TrimSpace(query)
+	if query == "" || limit < 1 || limit > MaxSearchPageSize || offset < 0 {
+		return nil, ErrInvalidContentSearch
+	}
+
+	matches, err := s.contentRepo.SearchContent(userID, query, snippetStart, snippetStop, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]SearchResult, len(matches))
+	for i, m := range matches {
+		results[i] = SearchResult{File: m.File, Rank: m.Rank, Snippet: highlightSnippet(m.Snippet)}
+	}
+	return results, nil
+}
+
+// IndexPending extracts the text of every file whose current content has not
+// been processed yet. It returns the number of files processed.
+func (s *ContentSearchService) IndexPending(ctx context.Context) (int, error) {
+	indexed := 0
+	for {
+		files, err := s.contentRepo.FindFilesToIndex(indexBatchSize)
+		if err != nil || len(files) == 0 {
+			return indexed, err
+		}
+		for i := range files {
+			if ctx.Err() != nil {
+				return indexed, ctx.Err()
+			}
+			if err := s.indexFile(ctx, &files[i]); err != nil {
+				return indexed, err
+			}
+			indexed++
+		}
+	}
+}
+
+// IndexJob is the handler of JobIndexContent.
+func (s *ContentSearchService) IndexJob(ctx context.Context, _ struct{}) error {
+	indexed, err := s.IndexPending(ctx)
+	if indexed > 0 {
+		log.Printf("Content indexing processed %d files", indexed)
+	}
+	return err
+}
+
+// indexFile extracts and stores the text of a file's current content.
+// Extraction errors are recorded on the file so it is not retried until its
+// content changes; only database errors are returned.
+func (s *ContentSearchService) indexFile(ctx context.Context, file *models.File) error {
+	content := &models.FileContent{FileID: file.ID, Source: file.S3Path, Status: models.ContentUnsupported}
+	if extract.Supported(file.MimeType, file.FileName) {
+		text, err := s.extractText(ctx, file)
+		if err != nil {
+			log.Printf("Failed to extract text of file %d: %v", file.ID, err)
+			content.Status = models.ContentFailed
+		} else {
+			content.Status = models.ContentIndexed
+			content.Content = text
+		}
+	}
+	return s.contentRepo.SaveContent(content)
+}
+
+func (s *ContentSearchService) extractText(ctx context.Context, file *models.File) (string, error) {
+	r, err := s.blobService.Open(ctx, file.S3Path)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	return extract.Text(r, file.Size, file.MimeType, file.FileName)
+}
+
+// highlightSnippet escapes a ts_headline snippet for HTML and turns the
+// match markers into <mark> tags.
+func highlightSnippet(snippet string) string {
+	var sb strings.Builder
+	for {
+		before, rest, found := strings.Cut(snippet, snippetStart)
+		sb.WriteString(html.EscapeString(before))
+		if !found {
+			return sb.String()
+		}
+		match, after, _ := strings.Cut(rest, snippetStop)
+		sb.WriteString("<mark>")
+		sb.WriteString(html.EscapeString(match))
+		sb.WriteString("</mark>")
+		snippet = after
+	}
+}
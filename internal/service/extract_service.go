@@ -0,0 +1,459 @@
+package service
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lskeey/go-filehub/internal/models"
+)
+
+// Defaults for ExtractLimits fields that are not configured.
+const (
+	DefaultExtractMaxEntries = 10000
+	DefaultExtractMaxSize    = 4 << 30
+	DefaultExtractMaxRatio   = 100
+)
+
+// extractRatioFloor is the expanded size below which compression ratios are
+// not checked: small, very repetitive files compress well legitimately.
+const extractRatioFloor = 1 << 20
+
+var (
+	ErrArchiveUnsupported = errors.New("only ZIP and tar.gz archives can be extracted")
+	ErrArchiveCorrupt     = errors.New("archive is corrupt or truncated")
+	ErrArchiveLimit       = errors.New("archive exceeds the extraction limits")
+	errUnsafePath         = errors.New("path points outside the target folder")
+)
+
+// Statuses of entries in an ExtractReport.
+const (
+	ExtractCreated = "created" // A new file
+	ExtractUpdated = "updated" // A new version of an existing file
+	ExtractFolder  = "folder"  // A folder, created or already present
+	ExtractSkipped = "skipped" // Not a regular file or folder, or metadata junk
+	ExtractFailed  = "failed"
+)
+
+// ExtractLimits protect against archives that expand to far more than they
+// appear to hold ("zip bombs").
+type ExtractLimits struct {
+	MaxEntries int     // Most entries an archive may hold
+	MaxSize    int64   // Largest total expanded size in bytes
+	MaxRatio   float64 // Highest expanded-to-compressed size ratio
+}
+
+// ExtractResult reports what happened to a single archive entry.
+type ExtractResult struct {
+	Path   string       `json:"path"`
+	Status string       `json:"status"`
+	Error  string       `json:"error,omitempty"`
+	File   *models.File `json:"file,omitempty"`
+}
+
+// ExtractReport is the outcome of extracting an archive.
+type ExtractReport struct {
+	Created int             `json:"created"`
+	Updated int             `json:"updated"`
+	Folders int             `json:"folders"`
+	Skipped int             `json:"skipped"`
+	Failed  int             `json:"failed"`
+	Aborted string          `json:"aborted,omitempty"` // Why extraction stopped before the end, if it did
+	Entries []ExtractResult `json:"entries"`
+}
+
+func (r *ExtractReport) add(result ExtractResult) {
+	switch result.Status {
+	case ExtractCreated:
+		r.Created++
+	case ExtractUpdated:
+		r.Updated++
+	case ExtractFolder:
+		r.Folders++
+	case ExtractSkipped:
+		r.Skipped++
+	case ExtractFailed:
+		r.Failed++
+	}
+	r.Entries = append(r.Entries, result)
+}
+
+// ExtractService expands uploaded ZIP and tar.gz archives into files and folders.
+type ExtractService struct {
+	fileService   *FileService
+	folderService *FolderService
+	dir           string
+	limits        ExtractLimits
+}
+
+// NewExtractService creates a new extract service staging archives in dir.
+func NewExtractService(fileService *FileService, folderService *FolderService, dir string, limits ExtractLimits) (*ExtractService, error) {
+	if dir == "" {
+		dir = filepath.Join("tmp", "uploads")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if limits.MaxEntries <= 0 {
+		limits.MaxEntries = DefaultExtractMaxEntries
+	}
+	if limits.MaxSize <= 0 {
+		limits.MaxSize = DefaultExtractMaxSize
+	}
+	if limits.MaxRatio <= 0 {
+		limits.MaxRatio = DefaultExtractMaxRatio
+	}
+	return &ExtractService{fileService: fileService, folderService: folderService, dir: dir, limits: limits}, nil
+}
+
+// Extract expands the archive read from r into folderID (nil for the root).
+// The archive is staged on disk first, since ZIP needs random access, and
+// checked against the limits before anything is created. Each file then goes
+// through UploadFile, so the upload policy, quotas, versioning and scanning
+// apply as for single uploads. Folders that already exist are reused.
+// Entries that fail don't stop the others; the report lists each outcome.
+// If the archive turns out to be broken or too large halfway through, what
+// was extracted so far is kept and the report says why it stopped.
+func (s *ExtractService) Extract(ctx context.Context, userID uint, folderID *uint, r io.Reader) (*ExtractReport, error) {
+	if _, err := s.fileService.authorizeFolder(userID, folderID, accessEditor); err != nil {
+		return nil, err
+	}
+
+	f, err := os.CreateTemp(s.dir, "extract-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	counter := &limitedCounter{r: r, limit: s.fileService.MaxUploadSize()}
+	size, err := io.Copy(f, counter)
+	if err != nil {
+		if counter.exceeded {
+			return nil, ErrFileTooLarge
+		}
+		return nil, err
+	}
+
+	archive, err := openArchive(f, size, s.limits.MaxRatio)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkLimits(archive, size); err != nil {
+		return nil, err
+	}
+
+	x := &extraction{
+		service: s,
+		userID:  userID,
+		root:    folderID,
+		folders: make(map[string]*uint),
+		report:  &ExtractReport{Entries: []ExtractResult{}},
+		budget:  s.limits.MaxSize,
+	}
+	err = archive.walk(func(entry archiveMember) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return x.extract(ctx, entry)
+	})
+	if errors.Is(err, ErrArchiveCorrupt) || errors.Is(err, ErrArchiveLimit) {
+		x.report.Aborted = err.Error()
+	} else if err != nil {
+		return nil, err
+	}
+	return x.report, nil
+}
+
+// checkLimits reads the archive's headers and rejects it if it would
+// expand beyond the limits.
+func (s *ExtractService) checkLimits(archive memberWalker, size int64) error {
+	var entries int
+	var total int64
+	err := archive.walk(func(entry archiveMember) error {
+		entries++
+		total += entry.size
+		if entries > s.limits.MaxEntries {
+			return fmt.Errorf("%w: more than %d entries", ErrArchiveLimit, s.limits.MaxEntries)
+		}
+		if entry.size < 0 || total > s.limits.MaxSize {
+			return fmt.Errorf("%w: expands to more than %d bytes", ErrArchiveLimit, s.limits.MaxSize)
+		}
+		// ZIP entries are compressed individually and can be checked on their own
+		if entry.compressed > 0 && entry.size > extractRatioFloor &&
+			float64(entry.size)/float64(entry.compressed) > s.limits.MaxRatio {
+			return fmt.Errorf("%w: %s is compressed more than %g:1", ErrArchiveLimit, entry.name, s.limits.MaxRatio)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if size > 0 && total > extractRatioFloor && float64(total)/float64(size) > s.limits.MaxRatio {
+		return fmt.Errorf("%w: compressed more than %g:1", ErrArchiveLimit, s.limits.MaxRatio)
+	}
+	return nil
+}
+
+// extraction holds the state of one Extract call.
+type extraction struct {
+	service *ExtractService
+	userID  uint
+	root    *uint
+	folders map[string]*uint // Folder IDs by archive path, without trailing slash
+	report  *ExtractReport
+	budget  int64 // Bytes that may still be expanded
+}
+
+// extract creates the file or folder for one archive entry and records the
+// outcome. Only exceeding the limits stops the extraction; a corrupt entry
+// of a streamed format stops it when the next header can't be read.
+func (x *extraction) extract(ctx context.Context, entry archiveMember) error {
+	result := ExtractResult{Path: entry.name}
+	segments, err := archivePath(entry.name)
+	switch {
+	case err != nil:
+		result.Status, result.Error = ExtractFailed, err.Error()
+	case len(segments) == 0 || isArchiveJunk(segments):
+		result.Status = ExtractSkipped
+	case entry.dir:
+		if _, err := x.folder(segments); err != nil {
+			result.Status, result.Error = ExtractFailed, err.Error()
+		} else {
+			result.Status = ExtractFolder
+		}
+	case !entry.regular:
+		result.Status, result.Error = ExtractSkipped, "not a regular file"
+	default:
+		file, err := x.file(ctx, segments, entry)
+		if errors.Is(err, ErrArchiveLimit) {
+			return err
+		}
+		if err != nil {
+			result.Status, result.Error = ExtractFailed, err.Error()
+		} else {
+			result.Status, result.File = ExtractCreated, file
+			if file.Version > 1 {
+				result.Status = ExtractUpdated
+			}
+		}
+	}
+	x.report.add(result)
+	return nil
+}
+
+func (x *extraction) file(ctx context.Context, segments []string, entry archiveMember) (*models.File, error) {
+	folderID, err := x.folder(segments[:len(segments)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := entry.open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrArchiveCorrupt, err)
+	}
+	defer content.Close()
+
+	// Headers can lie about sizes, so the expanded bytes are counted as well
+	counter := &limitedCounter{r: content, limit: x.budget}
+	file, err := x.service.fileService.UploadFile(ctx, x.userID, folderID, segments[len(segments)-1], "", counter)
+	x.budget -= counter.n
+	switch {
+	case counter.exceeded:
+		return nil, fmt.Errorf("%w: expands to more than %d bytes", ErrArchiveLimit, x.service.limits.MaxSize)
+	case errors.Is(err, zip.ErrChecksum), errors.Is(err, zip.ErrFormat),
+		errors.Is(err, gzip.ErrChecksum), errors.Is(err, gzip.ErrHeader),
+		errors.Is(err, io.ErrUnexpectedEOF):
+		return nil, fmt.Errorf("%w: %v", ErrArchiveCorrupt, err)
+	}
+	return file, err
+}
+
+// folder returns the ID of the folder at the given path below the target
+// folder, creating missing folders on the way.
+func (x *extraction) folder(segments []string) (*uint, error) {
+	parentID := x.root
+	for i, name := range segments {
+		key := strings.Join(segments[:i+1], "/")
+		if id, ok := x.folders[key]; ok {
+			parentID = id
+			continue
+		}
+		folder, err := x.service.folderService.EnsureFolder(x.userID, parentID, name)
+		if err != nil {
+			return nil, err
+		}
+		x.folders[key] = &folder.ID
+		parentID = &folder.ID
+	}
+	return parentID, nil
+}
+
+// archivePath splits an entry name into folder and file names. Names that
+// would escape the target folder are rejected rather than cleaned up, so
+// nothing lands somewhere the archive's author didn't obviously intend.
+func archivePath(name string) ([]string, error) {
+	if !utf8.ValidString(name) {
+		return nil, ErrInvalidName
+	}
+	// Archives made on Windows may use backslashes
+	name = strings.ReplaceAll(name, `\`, "/")
+	if strings.HasPrefix(name, "/") || isDriveLetter(name) {
+		return nil, errUnsafePath
+	}
+	var segments []string
+	for _, segment := range strings.Split(name, "/") {
+		switch segment {
+		case "", ".":
+			continue
+		case "..":
+			return nil, errUnsafePath
+		}
+		if err := validateName(segment); err != nil {
+			return nil, err
+		}
+		segments = append(segments, segment)
+	}
+	return segments, nil
+}
+
+// isDriveLetter reports whether name starts with a Windows drive like "C:".
+func isDriveLetter(name string) bool {
+	return len(name) >= 2 && name[1] == ':' &&
+		('a' <= name[0] && name[0] <= 'z' || 'A' <= name[0] && name[0] <= 'Z')
+}
+
+// isArchiveJunk reports whether an entry is metadata added by the archiving
+// tool rather than content, like the resource forks macOS adds to ZIPs.
+func isArchiveJunk(segments []string) bool {
+	name := segments[len(segments)-1]
+	return segments[0] == "__MACOSX" || name == ".DS_Store" || name == "Thumbs.db"
+}
+
+// archiveMember is an entry of an archive being walked.
+type archiveMember struct {
+	name       string
+	dir        bool
+	regular    bool
+	size       int64 // Expanded size as declared in the header
+	compressed int64 // Compressed size, if the format records it per entry
+	open       func() (io.ReadCloser, error)
+}
+
+type memberWalker interface {
+	// walk calls fn for every entry in order. For streamed formats an
+	// entry can only be opened during its own call.
+	walk(fn func(archiveMember) error) error
+}
+
+// openArchive detects the format of the archive in f from its content.
+// Streamed formats stop inflating once they exceed maxRatio.
+func openArchive(f *os.File, size int64, maxRatio float64) (memberWalker, error) {
+	head := make([]byte, 4)
+	if _, err := f.ReadAt(head, 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+	switch {
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")), bytes.HasPrefix(head, []byte("PK\x05\x06")):
+		zr, err := zip.NewReader(f, size)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrArchiveCorrupt, err)
+		}
+		return zipArchive{zr}, nil
+	case bytes.HasPrefix(head, []byte{0x1f, 0x8b}):
+		return tarGzArchive{f, maxRatio}, nil
+	}
+	return nil, ErrArchiveUnsupported
+}
+
+type zipArchive struct{ r *zip.Reader }
+
+func (a zipArchive) walk(fn func(archiveMember) error) error {
+	for _, f := range a.r.File {
+		mode := f.Mode()
+		err := fn(archiveMember{
+			name:       f.Name,
+			dir:        mode.IsDir(),
+			regular:    mode.IsRegular(),
+			size:       int64(f.UncompressedSize64),
+			compressed: int64(f.CompressedSize64),
+			open:       f.Open,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type tarGzArchive struct {
+	f        *os.File
+	maxRatio float64
+}
+
+func (a tarGzArchive) walk(fn func(archiveMember) error) error {
+	if _, err := a.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	compressed := &limitedCounter{r: a.f, limit: -1}
+	gz, err := gzip.NewReader(compressed)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrArchiveCorrupt, err)
+	}
+	defer gz.Close()
+
+	// The whole stream is compressed at once, so the ratio is checked
+	// against the running totals while inflating, including the data
+	// tar.Reader skips between headers
+	tr := tar.NewReader(&ratioReader{r: gz, compressed: compressed, maxRatio: a.maxRatio})
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if errors.Is(err, ErrArchiveLimit) {
+			return err
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrArchiveCorrupt, err)
+		}
+		err = fn(archiveMember{
+			name:    hdr.Name,
+			dir:     hdr.Typeflag == tar.TypeDir,
+			regular: hdr.Typeflag == tar.TypeReg,
+			size:    hdr.Size,
+			open:    func() (io.ReadCloser, error) { return io.NopCloser(tr), nil },
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ratioReader fails with ErrArchiveLimit once more than maxRatio times the
+// bytes read from compressed have been read from r.
+type ratioReader struct {
+	r          io.Reader
+	compressed *limitedCounter
+	maxRatio   float64
+	n          int64
+}
+
+func (r *ratioReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	if r.n > extractRatioFloor && r.compressed.n > 0 &&
+		float64(r.n)/float64(r.compressed.n) > r.maxRatio {
+		return n, fmt.Errorf("%w: compressed more than %g:1", ErrArchiveLimit, r.maxRatio)
+	}
+	return n, err
+}
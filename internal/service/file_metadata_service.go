@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lskeey/go-filehub/internal/models"
+	"github.com/lskeey/go-filehub/internal/repository"
+)
+
+// Limits of the custom metadata of a file.
+const (
+	MaxTags              = 50
+	MaxTagLength         = 64
+	MaxDescriptionLength = 4096
+	MaxProperties        = 50
+	MaxPropertyKeyLength = 64
+	MaxPropertyValueSize = 1024
+)
+
+var (
+	ErrInvalidTag         = errors.New("tags must be 1-64 characters without commas, at most 50 per file")
+	ErrInvalidDescription = errors.New("description must be at most 4096 characters")
+	ErrInvalidProperty    = errors.New("property keys must be 1-64 letters, digits, '_', '-' or '.', values at most 1024 characters, and at most 50 properties per file")
+)
+
+var propertyKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// FileMetadataUpdate changes the custom metadata of a file. Nil fields are
+// left unchanged. Tags replace the current ones; Properties are merged into
+// the current ones, and a nil value removes a key.
+type FileMetadataUpdate struct {
+	Tags        *[]string
+	Description *string
+	Properties  map[string]*string
+}
+
+// UpdateMetadata changes the tags, description and properties of a file.
+// The user must own the file or be an editor of it.
+func (s *FileService) UpdateMetadata(fileID, userID uint, update FileMetadataUpdate) (*models.File, error) {
+	var tags models.Tags
+	if update.Tags != nil {
+		var err error
+		if tags, err = NormalizeTags(*update.Tags); err != nil {
+			return nil, err
+		}
+	}
+	if update.Description != nil && utf8.RuneCountInString(*update.Description) > MaxDescriptionLength {
+		return nil, ErrInvalidDescription
+	}
+	for key, value := range update.Properties {
+		if err := validateProperty(key, value); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := s.authorizeFile(fileID, userID, accessEditor); err != nil {
+		return nil, err
+	}
+
+	var file models.File
+	err := s.fileRepo.WithFileLock(fileID, func(repo *repository.FileRepository, locked *models.File) error {
+		if update.Tags != nil {
+			locked.Tags = tags
+		}
+		if update.Description != nil {
+			locked.Description = *update.Description
+		}
+		if len(update.Properties) > 0 {
+			properties := make(models.Properties, len(locked.Properties))
+			for key, value := range locked.Properties {
+				properties[key] = value
+			}
+			for key, value := range update.Properties {
+				if value == nil {
+					delete(properties, key)
+				} else {
+					properties[key] = *value
+				}
+			}
+			if len(properties) > MaxProperties {
+				return ErrInvalidProperty
+			}
+			locked.Properties = properties
+		}
+		if err := repo.UpdateFile(locked); err != nil {
+			return err
+		}
+		file = *locked
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// NormalizeTags trims and lower-cases tags, drops duplicates and sorts them.
+func NormalizeTags(tags []string) (models.Tags, error) {
+	normalized := make(models.Tags, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || utf8.RuneCountInString(tag) > MaxTagLength || strings.Contains(tag, ",") {
+			return nil, ErrInvalidTag
+		}
+		normalized = append(normalized, tag)
+	}
+	slices.Sort(normalized)
+	normalized = slices.Compact(normalized)
+	if len(normalized) > MaxTags {
+		return nil, ErrInvalidTag
+	}
+	return normalized, nil
+}
+
+func validateProperty(key string, value *string) error {
+	if len(key) > MaxPropertyKeyLength || !propertyKeyPattern.MatchString(key) {
+		return ErrInvalidProperty
+	}
+	if value != nil && len(*value) > MaxPropertyValueSize {
+		return ErrInvalidProperty
+	}
+	return nil
+}
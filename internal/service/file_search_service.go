@@ -0,0 +1,190 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lskeey/go-filehub/internal/models"
+	"github.com/lskeey/go-filehub/internal/repository"
+)
+
+// Page sizes of file listings.
+const (
+	DefaultFilePageSize = 50
+	MaxFilePageSize     = 200
+)
+
+var (
+	ErrInvalidSearch = errors.New("invalid search: sort must be name, size or created_at, order asc or desc, and limit between 1 and 200")
+	ErrInvalidCursor = errors.New("invalid or outdated cursor")
+)
+
+// sortColumns maps the sort keys accepted by SearchFiles to columns.
+var sortColumns = map[string]string{
+	"name":       "file_name",
+	"size":       "size",
+	"created_at": "created_at",
+}
+
+// FileSearch describes a search through a user's files. Zero values disable
+// a filter; Sort defaults to created_at and Order to desc.
+type FileSearch struct {
+	Query         string // Substring of the name
+	Prefix        string // Prefix of the name
+	MimeType      string // Exact type or "type/*"
+	MinSize       *int64
+	MaxSize       *int64
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Tags          []string // Tags the files must all have
+	Properties    []string // "key:value" for a property value, or "key" for any value
+	Sort          string   // "name", "size" or "created_at"
+	Order         string   // "asc" or "desc"
+	Limit         int
+	Cursor        string // next_cursor of the previous page
+}
+
+// fileCursor is the decoded form of the opaque pagination cursor. It pins the
+// sort so a cursor cannot be reused with a different order.
+type fileCursor struct {
+	Sort  string          `json:"s"`
+	Desc  bool            `json:"d"`
+	Value json.RawMessage `json:"v"`
+	ID    uint            `json:"i"`
+}
+
+// SearchFiles retrieves one page of the user's files matching search. The
+// returned cursor fetches the next page and is empty on the last page.
+func (s *FileService) SearchFiles(userID uint, search FileSearch) ([]models.File, string, error) {
+	if search.Sort == "" {
+		search.Sort = "created_at"
+	}
+	if search.Order == "" {
+		search.Order = "desc"
+	}
+	if search.Limit == 0 {
+		search.Limit = DefaultFilePageSize
+	}
+	column, ok := sortColumns[search.Sort]
+	if !ok || (search.Order != "asc" && search.Order != "desc") || search.Limit < 1 || search.Limit > MaxFilePageSize {
+		return nil, "", ErrInvalidSearch
+	}
+
+	q := repository.FileQuery{
+		OwnerID:       userID,
+		NameContains:  search.Query,
+		NamePrefix:    search.Prefix,
+		MimeType:      search.MimeType,
+		MinSize:       search.MinSize,
+		MaxSize:       search.MaxSize,
+		CreatedAfter:  search.CreatedAfter,
+		CreatedBefore: search.CreatedBefore,
+		SortColumn:    column,
+		Desc:          search.Order == "desc",
+		Limit:         search.Limit + 1, // One extra row tells whether there is a next page
+	}
+	if len(search.Tags) > 0 {
+		tags, err := NormalizeTags(search.Tags)
+		if err != nil {
+			return nil, "", err
+		}
+		q.Tags = tags
+	}
+	for _, filter := range search.Properties {
+		key, value, hasValue := strings.Cut(filter, ":")
+		if err := validateProperty(key, &value); err != nil {
+			return nil, "", err
+		}
+		if !hasValue {
+			q.PropertyKeys = append(q.PropertyKeys, key)
+			continue
+		}
+		if q.Properties == nil {
+			q.Properties = make(map[string]string)
+		}
+		if previous, ok := q.Properties[key]; ok && previous != value {
+			// No file has two values for one key
+			return []models.File{}, "", nil
+		}
+		q.Properties[key] = value
+	}
+	if search.Cursor != "" {
+		value, id, err := decodeFileCursor(search.Cursor, search.Sort, q.Desc)
+		if err != nil {
+			return nil, "", err
+		}
+		q.AfterValue, q.AfterID = value, id
+	}
+
+	files, err := s.fileRepo.SearchFiles(q)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(files) <= search.Limit {
+		return files, "", nil
+	}
+
+	files = files[:search.Limit]
+	next, err := encodeFileCursor(&files[len(files)-1], search.Sort, q.Desc)
+	if err != nil {
+		return nil, "", err
+	}
+	return files, next, nil
+}
+
+// encodeFileCursor builds the cursor pointing after file.
+func encodeFileCursor(file *models.File, sort string, desc bool) (string, error) {
+	var value interface{}
+	switch sort {
+	case "name":
+		value = file.FileName
+	case "size":
+		value = file.Size
+	default:
+		value = file.CreatedAt
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(fileCursor{Sort: sort, Desc: desc, Value: raw, ID: file.ID})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodeFileCursor returns the sort value and ID a cursor points after.
+func decodeFileCursor(cursor, sort string, desc bool) (interface{}, uint, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, 0, ErrInvalidCursor
+	}
+	var c fileCursor
+	if err := json.Unmarshal(b, &c); err != nil || c.Sort != sort || c.Desc != desc {
+		return nil, 0, ErrInvalidCursor
+	}
+
+	var value interface{}
+	switch sort {
+	case "name":
+		var name string
+		err = json.Unmarshal(c.Value, &name)
+		value = name
+	case "size":
+		var size int64
+		err = json.Unmarshal(c.Value, &size)
+		value = size
+	default:
+		var createdAt time.Time
+		err = json.Unmarshal(c.Value, &createdAt)
+		value = createdAt
+	}
+	if err != nil {
+		return nil, 0, ErrInvalidCursor
+	}
+	return value, c.ID, nil
+}
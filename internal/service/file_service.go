@@ -1,88 +1,396 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"mime/multipart"
-	"os"
-	"path/filepath"
-	"time"
+	"io"
 
-	"github.com/gin-gonic/gin"
 	"github.com/lskeey/go-filehub/internal/models"
 	"github.com/lskeey/go-filehub/internal/repository"
+	"gorm.io/gorm"
+)
+
+// DefaultMaxUploadSize is used when no upload limit is configured.
+const DefaultMaxUploadSize = 10 * 1024 * 1024
+
+var (
+	ErrFileNotFound  = errors.New("file not found")
+	ErrFileForbidden = errors.New("unauthorized: you do not have permission for this file")
+	ErrFileTooLarge  = errors.New("file size exceeds the upload limit")
 )
 
 type FileService struct {
-	fileRepo *repository.FileRepository
+	fileRepo      *repository.FileRepository
+	folderRepo    *repository.FolderRepository
+	blobService   *BlobService
+	quotaService  *QuotaService
+	shareService  *ShareService
+	scanService   *ScanService
+	policy        *UploadPolicy
+	maxUploadSize int64
+}
+
+func NewFileService(repo *repository.FileRepository, folderRepo *repository.FolderRepository, blobService *BlobService, quotaService *QuotaService, shareService *ShareService, scanService *ScanService, policy *UploadPolicy, maxUploadSize int64) *FileService {
+	if maxUploadSize <= 0 {
+		maxUploadSize = DefaultMaxUploadSize
+	}
+	return &FileService{
+		fileRepo:      repo,
+		folderRepo:    folderRepo,
+		blobService:   blobService,
+		quotaService:  quotaService,
+		shareService:  shareService,
+		scanService:   scanService,
+		policy:        policy,
+		maxUploadSize: maxUploadSize,
+	}
 }
 
-func NewFileService(repo *repository.FileRepository) *FileService {
-	return &FileService{fileRepo: repo}
+// MaxUploadSize returns the largest file size, in bytes, accepted by UploadFile.
+func (s *FileService) MaxUploadSize() int64 {
+	return s.maxUploadSize
 }
 
-// UploadFile handles the business logic of uploading a file.
-func (s *FileService) UploadFile(c *gin.Context, fileHeader *multipart.FileHeader, userID uint) (*models.File, error) {
-	// Generate a unique file name to prevent collisions
-	// Format: <userID>-<timestamp>-<original_filename>
-	uniqueFileName := fmt.Sprintf("%d-%d-%s", userID, time.Now().Unix(), fileHeader.Filename)
+// UploadFile streams content from r to the storage backend and records its metadata.
+// The size is counted while streaming, so r is never buffered as a whole.
+// Content that is already stored is deduplicated by the blob service.
+// Uploading a name that already exists in the folder adds a new version to that file.
+// Every upload path goes through here so they all produce the same models.File record.
+// A file uploaded into a shared folder belongs to the folder's owner and counts
+// towards their quota. The MIME type is detected from the content; the declared
+// contentType is kept alongside it. Files the upload policy rejects are not stored.
+func (s *FileService) UploadFile(ctx context.Context, userID uint, folderID *uint, fileName, contentType string, r io.Reader) (*models.File, error) {
+	if err := validateName(fileName); err != nil {
+		return nil, err
+	}
+	ownerID, err := s.authorizeFolder(userID, folderID, accessEditor)
+	if err != nil {
+		return nil, err
+	}
+	if existing, err := s.fileRepo.FindFileByName(ownerID, folderID, fileName); err == nil {
+		return s.UploadVersion(ctx, existing.ID, userID, contentType, r)
+	}
+	if _, err := s.folderRepo.FindFolderByName(ownerID, folderID, fileName); err == nil {
+		return nil, ErrNameConflict
+	}
 
-	// Define the path to save the file
-	savePath := filepath.Join("uploads", uniqueFileName)
+	detected, r, err := s.policy.detectType(fileName, contentType, r)
+	if err != nil {
+		return nil, err
+	}
+	blob, err := s.storeContent(ctx, ownerID, detected, r)
+	if err != nil {
+		return nil, err
+	}
 
-	// Save the file to the local server
-	if err := c.SaveUploadedFile(fileHeader, savePath); err != nil {
+	// The first version holds a reference of its own
+	if err := s.blobService.Acquire(blob.Digest); err != nil {
+		_ = s.blobService.Release(ctx, blob.Digest)
+		_ = s.quotaService.Credit(ownerID, blob.Size)
 		return nil, err
 	}
 
 	// Create a record for the database
 	fileMetadata := &models.File{
-		FileName: fileHeader.Filename,
-		Size:     fileHeader.Size,
-		MimeType: fileHeader.Header.Get("Content-Type"),
-		S3Path:   savePath, // For now, this is the local path
-		OwnerID:  userID,
+		FileName:   fileName,
+		Size:       blob.Size,
+		MimeType:   detected,
+		S3Path:     blob.StorageKey, // Storage key of the shared blob
+		BlobDigest: blob.Digest,
+		OwnerID:    ownerID,
+		FolderID:   folderID,
+		Version:    1,
+		ScanStatus: models.ScanPending,
+
+		DeclaredMimeType: contentType,
+	}
+	version := &models.FileVersion{
+		Version:    1,
+		Size:       blob.Size,
+		MimeType:   detected,
+		S3Path:     blob.StorageKey,
+		BlobDigest: blob.Digest,
+		UploadedBy: userID,
+
+		DeclaredMimeType: contentType,
 	}
 
 	// Save metadata to the database
-	if err := s.fileRepo.CreateFile(fileMetadata); err != nil {
-		// Here you might want to add logic to delete the saved file if DB insert fails
+	if err := s.fileRepo.CreateFileWithVersion(fileMetadata, version); err != nil {
+		// The file row's reference is not needed either way
+		_ = s.blobService.Release(ctx, blob.Digest)
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			// A concurrent upload created the name first; add to it instead
+			if existing, err := s.fileRepo.FindFileByName(ownerID, folderID, fileName); err == nil {
+				return s.addVersion(ctx, existing.ID, ownerID, version)
+			}
+			err = ErrNameConflict
+		}
+		// Don't leave an unreferenced blob behind
+		_ = s.blobService.Release(ctx, blob.Digest)
+		_ = s.quotaService.Credit(ownerID, blob.Size)
 		return nil, err
 	}
 
+	s.scanService.Submit(fileMetadata)
 	return fileMetadata, nil
 }
 
-// ListUserFiles retrieves all files for a given user.
-func (s *FileService) ListUserFiles(userID uint) ([]models.File, error) {
-	return s.fileRepo.FindFilesByOwnerID(userID)
+// storeContent streams r into a blob and charges its size to ownerID's quota.
+// Streaming stops as soon as the upload limit or the remaining quota is
+// exceeded. On success the caller owns one blob reference.
+func (s *FileService) storeContent(ctx context.Context, ownerID uint, contentType string, r io.Reader) (*models.Blob, error) {
+	remaining, err := s.quotaService.Remaining(ownerID)
+	if err != nil {
+		return nil, err
+	}
+	limit, limitErr := s.maxUploadSize, ErrFileTooLarge
+	if remaining >= 0 && remaining < limit {
+		limit, limitErr = remaining, ErrQuotaExceeded
+	}
+
+	// Save the content, aborting once the limit is exceeded
+	counter := &limitedCounter{r: r, limit: limit}
+	blob, err := s.blobService.Store(ctx, counter, contentType)
+	if err != nil {
+		if counter.exceeded {
+			return nil, limitErr
+		}
+		return nil, err
+	}
+
+	// Concurrent uploads may have used up the quota in the meantime
+	if err := s.quotaService.Charge(ownerID, blob.Size); err != nil {
+		_ = s.blobService.Release(ctx, blob.Digest)
+		return nil, err
+	}
+	return blob, nil
 }
 
-// GetFileByID retrieves a single file record.
-func (s *FileService) GetFileByID(fileID uint) (*models.File, error) {
-	return s.fileRepo.FindFileByID(fileID)
+// limitedCounter counts the bytes read through it and fails once more than
+// limit bytes were read. A negative limit only counts.
+type limitedCounter struct {
+	r        io.Reader
+	n        int64
+	limit    int64
+	exceeded bool
 }
 
-// DeleteFile handles the logic for deleting a file.
-func (s *FileService) DeleteFile(fileID, userID uint) error {
-	// 1. Get file metadata to verify ownership and get the path
+func (l *limitedCounter) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.n += int64(n)
+	if l.limit >= 0 && l.n > l.limit {
+		l.exceeded = true
+		return n, ErrFileTooLarge
+	}
+	return n, err
+}
+
+// GetFile retrieves a file that userID owns or that is shared with them.
+func (s *FileService) GetFile(fileID, userID uint) (*models.File, error) {
+	return s.authorizeFile(fileID, userID, accessViewer)
+}
+
+// authorizeFile retrieves a file and checks that userID has at least the given access to it.
+func (s *FileService) authorizeFile(fileID, userID uint, need access) (*models.File, error) {
 	file, err := s.fileRepo.FindFileByID(fileID)
 	if err != nil {
-		return errors.New("file not found")
+		return nil, ErrFileNotFound
 	}
+	granted, err := s.shareService.fileAccess(file, userID)
+	if err != nil {
+		return nil, err
+	}
+	if granted < need {
+		return nil, ErrFileForbidden
+	}
+	return file, nil
+}
 
-	// 2. IMPORTANT: Check if the user owns the file
-	if file.OwnerID != userID {
-		return errors.New("unauthorized: you do not own this file")
+// RenameFile changes a file's name, keeping names unique within its folder.
+func (s *FileService) RenameFile(fileID, userID uint, name string) (*models.File, error) {
+	file, err := s.authorizeFile(fileID, userID, accessEditor)
+	if err != nil {
+		return nil, err
 	}
+	return s.relocateFile(file, userID, file.FolderID, name)
+}
 
-	// 3. Delete the physical file from storage
-	if err := os.Remove(file.S3Path); err != nil {
-		// Log the error but you might still want to proceed to delete the DB record
-		// depending on desired behavior for orphaned records.
-		fmt.Printf("Failed to delete physical file %s: %v\n", file.S3Path, err)
+// MoveFile moves a file into folderID (nil for the root). Files can only be
+// moved between folders of their owner.
+func (s *FileService) MoveFile(fileID, userID uint, folderID *uint) (*models.File, error) {
+	file, err := s.authorizeFile(fileID, userID, accessEditor)
+	if err != nil {
+		return nil, err
 	}
+	return s.relocateFile(file, userID, folderID, file.FileName)
+}
 
-	// 4. Delete the metadata from the database
+// RelocateFile moves a file into folderID under a new name in one step, so
+// the name only has to be free in the destination.
+func (s *FileService) RelocateFile(fileID, userID uint, folderID *uint, name string) (*models.File, error) {
+	file, err := s.authorizeFile(fileID, userID, accessEditor)
+	if err != nil {
+		return nil, err
+	}
+	return s.relocateFile(file, userID, folderID, name)
+}
+
+func (s *FileService) relocateFile(file *models.File, userID uint, folderID *uint, name string) (*models.File, error) {
+	if name != file.FileName {
+		if err := validateName(name); err != nil {
+			return nil, err
+		}
+		// A new extension must not sneak past the upload policy
+		if err := s.policy.CheckName(name); err != nil {
+			return nil, err
+		}
+	}
+	if !sameFolder(folderID, file.FolderID) {
+		ownerID, err := s.authorizeFolder(userID, folderID, accessEditor)
+		if err != nil {
+			return nil, err
+		}
+		if ownerID != file.OwnerID {
+			return nil, ErrMoveAcrossOwners
+		}
+	}
+	if err := s.checkNameFree(file.OwnerID, folderID, name, file.ID); err != nil {
+		return nil, err
+	}
+
+	file.FileName = name
+	file.FolderID = folderID
+	if err := s.fileRepo.UpdateFile(file); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrNameConflict
+		}
+		return nil, err
+	}
+	return file, nil
+}
+
+// sameFolder reports whether two folder IDs, where nil is the root, are equal.
+func sameFolder(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// authorizeFolder checks that userID has at least the given access to
+// folderID, where nil is the user's own root. It returns the folder's owner,
+// who owns everything created inside it.
+func (s *FileService) authorizeFolder(userID uint, folderID *uint, need access) (uint, error) {
+	if folderID == nil {
+		return userID, nil
+	}
+	folder, err := s.getFolder(*folderID, userID, need)
+	if err != nil {
+		return 0, err
+	}
+	return folder.OwnerID, nil
+}
+
+// getFolder retrieves a folder and checks that userID has at least the given access to it.
+func (s *FileService) getFolder(folderID, userID uint, need access) (*models.Folder, error) {
+	folder, err := s.folderRepo.FindFolderByID(folderID)
+	if err != nil {
+		return nil, ErrFolderNotFound
+	}
+	granted, err := s.shareService.folderAccess(folder, userID)
+	if err != nil {
+		return nil, err
+	}
+	if granted < need {
+		return nil, ErrFolderForbidden
+	}
+	return folder, nil
+}
+
+// checkNameFree verifies that no file other than fileID and no folder inside
+// ownerID's folderID is called name.
+func (s *FileService) checkNameFree(ownerID uint, folderID *uint, name string, fileID uint) error {
+	if existing, err := s.fileRepo.FindFileByName(ownerID, folderID, name); err == nil && existing.ID != fileID {
+		return ErrNameConflict
+	}
+	if _, err := s.folderRepo.FindFolderByName(ownerID, folderID, name); err == nil {
+		return ErrNameConflict
+	}
+	return nil
+}
+
+// OpenFile opens the stored content of a file for reading. Quarantined
+// content, and content not found clean while scanning is enabled, is refused.
+func (s *FileService) OpenFile(ctx context.Context, file *models.File) (io.ReadSeekCloser, error) {
+	if err := s.scanService.CheckFile(file); err != nil {
+		return nil, err
+	}
+	return s.blobService.Open(ctx, file.S3Path)
+}
+
+// DeleteFile moves a file to the trash. Its content and versions are kept
+// until it is permanently deleted or purged.
+func (s *FileService) DeleteFile(fileID, userID uint) error {
+	// 1. Get file metadata and verify the user may edit it
+	if _, err := s.authorizeFile(fileID, userID, accessEditor); err != nil {
+		return err
+	}
+
+	// 2. Soft-delete the record, which moves it to the trash
 	return s.fileRepo.DeleteFileByID(fileID)
 }
+
+// purgeFile permanently deletes a file record with its version history and
+// drops the references to their content. The bytes are only removed from
+// storage once no other file or version uses them.
+func (s *FileService) purgeFile(ctx context.Context, file *models.File) error {
+	versions, err := s.fileRepo.FindVersionsByFileID(file.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.fileRepo.PurgeFile(file.ID); err != nil {
+		return err
+	}
+
+	freed := file.Size
+	if len(versions) > 0 {
+		freed = 0
+		for _, v := range versions {
+			freed += v.Size
+		}
+	}
+	if err := s.quotaService.Credit(file.OwnerID, freed); err != nil {
+		fmt.Printf("Failed to update usage of user %d: %v\n", file.OwnerID, err)
+	}
+
+	// The records are gone already, so only log leaked content
+	if err := s.releaseContent(ctx, file); err != nil {
+		fmt.Printf("Failed to release content %s: %v\n", file.S3Path, err)
+	}
+	for _, v := range versions {
+		if err := s.releaseVersion(ctx, &v); err != nil {
+			fmt.Printf("Failed to release content %s: %v\n", v.S3Path, err)
+		}
+	}
+	return nil
+}
+
+// releaseContent releases the blob referenced by file. Files uploaded before
+// content deduplication own their object directly.
+func (s *FileService) releaseContent(ctx context.Context, file *models.File) error {
+	if file.BlobDigest == "" {
+		return s.blobService.storage.Delete(ctx, file.S3Path)
+	}
+	return s.blobService.Release(ctx, file.BlobDigest)
+}
+
+// releaseVersion releases the blob referenced by a version. The first
+// version of a file uploaded before content deduplication owns its object.
+func (s *FileService) releaseVersion(ctx context.Context, v *models.FileVersion) error {
+	if v.BlobDigest == "" {
+		return s.blobService.storage.Delete(ctx, v.S3Path)
+	}
+	return s.blobService.Release(ctx, v.BlobDigest)
+}
@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/lskeey/go-filehub/internal/models"
+	"github.com/lskeey/go-filehub/internal/repository"
+)
+
+var (
+	ErrVersionNotFound  = errors.New("version not found")
+	ErrInvalidPruneRule = errors.New("either keep or older_than must be set")
+)
+
+// ListVersions retrieves the version history of a file, newest first.
+func (s *FileService) ListVersions(fileID, userID uint) ([]models.FileVersion, error) {
+	if _, err := s.authorizeFile(fileID, userID, accessViewer); err != nil {
+		return nil, err
+	}
+	return s.fileRepo.FindVersionsByFileID(fileID)
+}
+
+// GetVersion retrieves a specific version of a file.
+func (s *FileService) GetVersion(fileID, userID uint, version int) (*models.FileVersion, error) {
+	if _, err := s.authorizeFile(fileID, userID, accessViewer); err != nil {
+		return nil, err
+	}
+	v, err := s.fileRepo.FindVersion(fileID, version)
+	if err != nil {
+		return nil, ErrVersionNotFound
+	}
+	return v, nil
+}
+
+// OpenVersion opens the stored content of a version of file for reading.
+// Like OpenFile, it refuses content that may not be downloaded.
+func (s *FileService) OpenVersion(ctx context.Context, file *models.File, v *models.FileVersion) (io.ReadSeekCloser, error) {
+	// The current version's state is the file's; its version row may
+	// predate scan states being recorded per version
+	var err error
+	if v.Version == file.Version {
+		err = s.scanService.CheckFile(file)
+	} else {
+		err = s.scanService.CheckVersion(v)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.blobService.Open(ctx, v.S3Path)
+}
+
+// UploadVersion stores content from r as a new version of an existing file
+// and makes it the current one. The content counts towards the file owner's
+// quota and is checked against the upload policy like a new upload.
+func (s *FileService) UploadVersion(ctx context.Context, fileID, userID uint, contentType string, r io.Reader) (*models.File, error) {
+	file, err := s.authorizeFile(fileID, userID, accessEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	detected, r, err := s.policy.detectType(file.FileName, contentType, r)
+	if err != nil {
+		return nil, err
+	}
+	blob, err := s.storeContent(ctx, file.OwnerID, detected, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.addVersion(ctx, fileID, file.OwnerID, &models.FileVersion{
+		Size:       blob.Size,
+		MimeType:   detected,
+		S3Path:     blob.StorageKey,
+		BlobDigest: blob.Digest,
+		UploadedBy: userID,
+
+		DeclaredMimeType: contentType,
+	})
+}
+
+// RestoreVersion makes an older version current again by recording a copy
+// of it as the newest version, so the history is never rewritten.
+func (s *FileService) RestoreVersion(ctx context.Context, fileID, userID uint, version int) (*models.File, error) {
+	file, err := s.authorizeFile(fileID, userID, accessEditor)
+	if err != nil {
+		return nil, err
+	}
+	old, err := s.fileRepo.FindVersion(fileID, version)
+	if err != nil {
+		return nil, ErrVersionNotFound
+	}
+
+	// Content stored before deduplication belongs to its version alone, so
+	// it is copied into a blob the restored version can share
+	if old.BlobDigest == "" {
+		return s.restoreLegacyVersion(ctx, file, userID, old)
+	}
+
+	// The restored copy counts towards the quota like any other version
+	if err := s.quotaService.Charge(file.OwnerID, old.Size); err != nil {
+		return nil, err
+	}
+	if err := s.blobService.Acquire(old.BlobDigest); err != nil {
+		_ = s.quotaService.Credit(file.OwnerID, old.Size)
+		return nil, err
+	}
+
+	return s.addVersion(ctx, fileID, file.OwnerID, &models.FileVersion{
+		Size:       old.Size,
+		MimeType:   old.MimeType,
+		S3Path:     old.S3Path,
+		BlobDigest: old.BlobDigest,
+		UploadedBy: userID,
+
+		DeclaredMimeType: old.DeclaredMimeType,
+	})
+}
+
+func (s *FileService) restoreLegacyVersion(ctx context.Context, file *models.File, userID uint, old *models.FileVersion) (*models.File, error) {
+	content, err := s.blobService.Open(ctx, old.S3Path)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	blob, err := s.storeContent(ctx, file.OwnerID, old.MimeType, content)
+	if err != nil {
+		return nil, err
+	}
+	return s.addVersion(ctx, file.ID, file.OwnerID, &models.FileVersion{
+		Size:       blob.Size,
+		MimeType:   old.MimeType,
+		S3Path:     blob.StorageKey,
+		BlobDigest: blob.Digest,
+		UploadedBy: userID,
+
+		DeclaredMimeType: old.DeclaredMimeType,
+	})
+}
+
+// PruneVersions permanently deletes old versions of a file. Versions beyond
+// the newest keep (if keep > 0) that are also older than olderThan (if
+// olderThan > 0) are removed. The current version is always kept.
+// Pruned versions cannot be recovered, so only the owner may prune.
+// It returns the number of versions removed.
+func (s *FileService) PruneVersions(ctx context.Context, fileID, userID uint, keep int, olderThan time.Duration) (int, error) {
+	if keep <= 0 && olderThan <= 0 {
+		return 0, ErrInvalidPruneRule
+	}
+	if _, err := s.authorizeFile(fileID, userID, accessOwner); err != nil {
+		return 0, err
+	}
+
+	var cutoff time.Time
+	if olderThan > 0 {
+		cutoff = time.Now().Add(-olderThan)
+	}
+
+	var pruned []models.FileVersion
+	err := s.fileRepo.WithFileLock(fileID, func(repo *repository.FileRepository, file *models.File) error {
+		versions, err := repo.FindPrunableVersions(fileID, file.Version, keep, cutoff)
+		if err != nil {
+			return err
+		}
+		pruned = versions
+		return repo.DeleteVersions(versions)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var freed int64
+	for _, v := range pruned {
+		freed += v.Size
+		if err := s.releaseVersion(ctx, &v); err != nil {
+			fmt.Printf("Failed to release content %s: %v\n", v.S3Path, err)
+		}
+	}
+	if err := s.quotaService.Credit(userID, freed); err != nil {
+		fmt.Printf("Failed to update usage of user %d: %v\n", userID, err)
+	}
+	return len(pruned), nil
+}
+
+// addVersion records v as the file's current content. v must carry one blob
+// reference taken by the caller, which the new version row owns, and its size
+// must already be charged to ownerID. The file row takes another reference and
+// drops the one to its previous content. On failure the caller's reference and
+// charge are given back.
+func (s *FileService) addVersion(ctx context.Context, fileID, ownerID uint, v *models.FileVersion) (*models.File, error) {
+	if err := s.blobService.Acquire(v.BlobDigest); err != nil {
+		_ = s.blobService.Release(ctx, v.BlobDigest)
+		_ = s.quotaService.Credit(ownerID, v.Size)
+		return nil, err
+	}
+
+	var file, previous models.File
+	var handedOver bool
+	err := s.fileRepo.WithFileLock(fileID, func(repo *repository.FileRepository, locked *models.File) error {
+		latest, err := repo.LatestVersionNumber(fileID)
+		if err != nil {
+			return err
+		}
+
+		// Files created before versioning have no history yet. Their current
+		// content becomes the first version, which takes over the file row's
+		// reference, or the object itself for content stored before deduplication.
+		if latest == 0 {
+			if err := repo.CreateVersion(&models.FileVersion{
+				FileID:     fileID,
+				Version:    locked.Version,
+				Size:       locked.Size,
+				MimeType:   locked.MimeType,
+				S3Path:     locked.S3Path,
+				BlobDigest: locked.BlobDigest,
+				UploadedBy: locked.OwnerID,
+
+				DeclaredMimeType: locked.DeclaredMimeType,
+				ScanStatus:       locked.ScanStatus,
+				ScannedAt:        locked.ScannedAt,
+			}); err != nil {
+				return err
+			}
+			latest = locked.Version
+			handedOver = true
+		}
+
+		v.FileID = fileID
+		v.Version = latest + 1
+		if err := repo.CreateVersion(v); err != nil {
+			return err
+		}
+
+		previous = *locked
+		locked.Size = v.Size
+		locked.MimeType = v.MimeType
+		locked.DeclaredMimeType = v.DeclaredMimeType
+		locked.S3Path = v.S3Path
+		locked.BlobDigest = v.BlobDigest
+		locked.Version = v.Version
+		locked.ScanStatus = models.ScanPending
+		locked.ScannedAt = nil
+		if err := repo.UpdateFile(locked); err != nil {
+			return err
+		}
+		file = *locked
+		return nil
+	})
+	if err != nil {
+		_ = s.blobService.Release(ctx, v.BlobDigest)
+		_ = s.blobService.Release(ctx, v.BlobDigest)
+		_ = s.quotaService.Credit(ownerID, v.Size)
+		return nil, err
+	}
+
+	if !handedOver {
+		if err := s.releaseContent(ctx, &previous); err != nil {
+			fmt.Printf("Failed to release content %s: %v\n", previous.S3Path, err)
+		}
+	}
+	s.scanService.Submit(&file)
+	return &file, nil
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lskeey/go-filehub/internal/models"
+	"github.com/lskeey/go-filehub/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrFolderNotFound   = errors.New("folder not found")
+	ErrFolderForbidden  = errors.New("unauthorized: you do not have permission for this folder")
+	ErrFolderNotEmpty   = errors.New("folder is not empty")
+	ErrInvalidMove      = errors.New("a folder cannot be moved into itself or one of its subfolders")
+	ErrMoveAcrossOwners = errors.New("items can only be moved between folders of the same owner")
+	ErrInvalidName      = errors.New("name must be non-empty, at most 255 characters and must not contain '/'")
+	ErrNameConflict     = errors.New("an item with this name already exists in the folder")
+	ErrPathNotFound     = errors.New("path not found")
+)
+
+// FolderService manages the per-user folder tree.
+type FolderService struct {
+	folderRepo  *repository.FolderRepository
+	fileRepo    *repository.FileRepository
+	fileService *FileService
+}
+
+// NewFolderService creates a new folder service.
+func NewFolderService(folderRepo *repository.FolderRepository, fileRepo *repository.FileRepository, fileService *FileService) *FolderService {
+	return &FolderService{folderRepo: folderRepo, fileRepo: fileRepo, fileService: fileService}
+}
+
+// CreateFolder creates a folder called name inside parentID (nil for the root).
+// A folder created inside a shared folder belongs to the parent's owner.
+func (s *FolderService) CreateFolder(userID uint, name string, parentID *uint) (*models.Folder, error) {
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+	ownerID, err := s.fileService.authorizeFolder(userID, parentID, accessEditor)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fileService.checkNameFree(ownerID, parentID, name, 0); err != nil {
+		return nil, err
+	}
+
+	folder := &models.Folder{Name: name, ParentID: parentID, OwnerID: ownerID}
+	if err := s.folderRepo.CreateFolder(folder); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrNameConflict
+		}
+		return nil, err
+	}
+	return folder, nil
+}
+
+// EnsureFolder returns the folder called name inside parentID, creating it
+// if it doesn't exist yet. A file of that name is a conflict.
+func (s *FolderService) EnsureFolder(userID uint, parentID *uint, name string) (*models.Folder, error) {
+	folder, err := s.CreateFolder(userID, name, parentID)
+	if !errors.Is(err, ErrNameConflict) {
+		return folder, err
+	}
+	ownerID, err := s.fileService.authorizeFolder(userID, parentID, accessEditor)
+	if err != nil {
+		return nil, err
+	}
+	folder, err = s.folderRepo.FindFolderByName(ownerID, parentID, name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNameConflict
+	}
+	return folder, err
+}
+
+// GetFolder retrieves a folder that userID owns or that is shared with them.
+func (s *FolderService) GetFolder(folderID, userID uint) (*models.Folder, error) {
+	return s.fileService.getFolder(folderID, userID, accessViewer)
+}
+
+// ListChildren retrieves the folders and files directly inside parentID (nil for the root).
+func (s *FolderService) ListChildren(userID uint, parentID *uint) ([]models.Folder, []models.File, error) {
+	ownerID, err := s.fileService.authorizeFolder(userID, parentID, accessViewer)
+	if err != nil {
+		return nil, nil, err
+	}
+	folders, err := s.folderRepo.FindChildFolders(ownerID, parentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	files, err := s.fileRepo.FindFilesInFolder(ownerID, parentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return folders, files, nil
+}
+
+// RenameFolder changes a folder's name, keeping names unique within its parent.
+func (s *FolderService) RenameFolder(folderID, userID uint, name string) (*models.Folder, error) {
+	folder, err := s.fileService.getFolder(folderID, userID, accessEditor)
+	if err != nil {
+		return nil, err
+	}
+	return s.relocateFolder(folder, userID, folder.ParentID, name)
+}
+
+// MoveFolder moves a folder into parentID (nil for the root). Folders can only
+// be moved between folders of their owner.
+func (s *FolderService) MoveFolder(folderID, userID uint, parentID *uint) (*models.Folder, error) {
+	folder, err := s.fileService.getFolder(folderID, userID, accessEditor)
+	if err != nil {
+		return nil, err
+	}
+	return s.relocateFolder(folder, userID, parentID, folder.Name)
+}
+
+// RelocateFolder moves a folder into parentID under a new name in one step,
+// so the name only has to be free in the destination.
+func (s *FolderService) RelocateFolder(folderID, userID uint, parentID *uint, name string) (*models.Folder, error) {
+	folder, err := s.fileService.getFolder(folderID, userID, accessEditor)
+	if err != nil {
+		return nil, err
+	}
+	return s.relocateFolder(folder, userID, parentID, name)
+}
+
+func (s *FolderService) relocateFolder(folder *models.Folder, userID uint, parentID *uint, name string) (*models.Folder, error) {
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+	if !sameFolder(parentID, folder.ParentID) {
+		ownerID, err := s.fileService.authorizeFolder(userID, parentID, accessEditor)
+		if err != nil {
+			return nil, err
+		}
+		if ownerID != folder.OwnerID {
+			return nil, ErrMoveAcrossOwners
+		}
+
+		// Walk up from the new parent to make sure it is not inside the folder
+		for ancestor := parentID; ancestor != nil; {
+			if *ancestor == folder.ID {
+				return nil, ErrInvalidMove
+			}
+			parent, err := s.folderRepo.FindFolderByID(*ancestor)
+			if err != nil {
+				return nil, err
+			}
+			ancestor = parent.ParentID
+		}
+	}
+	if err := s.checkFolderNameFree(folder.OwnerID, parentID, name, folder.ID); err != nil {
+		return nil, err
+	}
+
+	folder.Name = name
+	folder.ParentID = parentID
+	return folder, s.saveFolder(folder)
+}
+
+// DeleteFolder moves a folder to the trash. Unless recursive is set, the folder
+// must be empty; otherwise all subfolders and files inside it are trashed with it.
+func (s *FolderService) DeleteFolder(folderID, userID uint, recursive bool) error {
+	folder, err := s.fileService.getFolder(folderID, userID, accessEditor)
+	if err != nil {
+		return err
+	}
+
+	ids, err := s.folderRepo.FindDescendantIDs(folder.ID)
+	if err != nil {
+		return err
+	}
+	files, err := s.fileRepo.FindFilesInFolders(ids)
+	if err != nil {
+		return err
+	}
+	if !recursive && (len(ids) > 1 || len(files) > 0) {
+		return ErrFolderNotEmpty
+	}
+
+	// Everything shares one timestamp so the tree can be restored as a whole
+	at := time.Now().Truncate(time.Microsecond)
+	fileIDs := make([]uint, len(files))
+	for i, file := range files {
+		fileIDs[i] = file.ID
+	}
+	return s.folderRepo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := (&repository.FileRepository{DB: tx}).TrashFiles(fileIDs, at); err != nil {
+			return err
+		}
+		return (&repository.FolderRepository{DB: tx}).TrashFolders(ids, at)
+	})
+}
+
+// ResolvePath looks up a slash-separated path such as "/projects/2024/report.pdf".
+// It returns the folder or the file the path points to.
+func (s *FolderService) ResolvePath(userID uint, path string) (*models.Folder, *models.File, error) {
+	var segments []string
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	if len(segments) == 0 {
+		// The root itself
+		return nil, nil, nil
+	}
+
+	var parentID *uint
+	var folder *models.Folder
+	for i, segment := range segments {
+		found, err := s.folderRepo.FindFolderByName(userID, parentID, segment)
+		if err == nil {
+			folder = found
+			parentID = &found.ID
+			continue
+		}
+		// Only the last segment may name a file
+		if i == len(segments)-1 {
+			if file, err := s.fileRepo.FindFileByName(userID, parentID, segment); err == nil {
+				return nil, file, nil
+			}
+		}
+		return nil, nil, ErrPathNotFound
+	}
+	return folder, nil, nil
+}
+
+// checkFolderNameFree verifies that no folder other than folderID and no file
+// inside ownerID's parentID is called name.
+func (s *FolderService) checkFolderNameFree(ownerID uint, parentID *uint, name string, folderID uint) error {
+	if existing, err := s.folderRepo.FindFolderByName(ownerID, parentID, name); err == nil && existing.ID != folderID {
+		return ErrNameConflict
+	}
+	if _, err := s.fileRepo.FindFileByName(ownerID, parentID, name); err == nil {
+		return ErrNameConflict
+	}
+	return nil
+}
+
+func (s *FolderService) saveFolder(folder *models.Folder) error {
+	if err := s.folderRepo.UpdateFolder(folder); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrNameConflict
+		}
+		return err
+	}
+	return nil
+}
+
+// validateName checks a file or folder name.
+func validateName(name string) error {
+	if strings.TrimSpace(name) == "" || len(name) > 255 || strings.Contains(name, "/") || name == "." || name == ".." {
+		return ErrInvalidName
+	}
+	return nil
+}
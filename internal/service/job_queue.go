@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lskeey/go-filehub/internal/models"
+	"github.com/lskeey/go-filehub/internal/repository"
+)
+
+// Defaults of the job queue.
+const (
+	DefaultJobMaxAttempts = 5
+	jobPollInterval       = time.Second
+	jobHeartbeatInterval  = time.Minute        // How often running jobs renew their lock
+	jobLockTimeout        = 5 * time.Minute    // Running jobs not renewed for this long are assumed orphaned
+	jobRetention          = 7 * 24 * time.Hour // Succeeded jobs are kept this long
+	jobBaseBackoff        = 10 * time.Second
+	jobMaxBackoff         = time.Hour
+)
+
+var ErrUnknownJobType = errors.New("no handler registered for job type")
+
+// JobOptions adjusts how a job is enqueued.
+type JobOptions struct {
+	RunAt       time.Time // Zero to run as soon as possible
+	MaxAttempts int       // Zero for DefaultJobMaxAttempts
+	UniqueKey   string    // Non-empty to enqueue the job at most once
+}
+
+// permanentError marks a job failure that retrying won't fix.
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps a job handler error so the job is dead-lettered right away
+// instead of being retried.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+type jobHandler func(ctx context.Context, payload []byte) error
+
+type recurringJob struct {
+	jobType  string
+	interval time.Duration
+	lastSlot time.Time
+}
+
+// JobQueue runs background work stored in the jobs table. Any number of
+// processes can work the same queue: jobs are claimed with SELECT ... FOR
+// UPDATE SKIP LOCKED, so each one runs on a single worker at a time. Failed
+// jobs are retried with exponential backoff and dead-lettered once they
+// run out of attempts.
+type JobQueue struct {
+	repo     *repository.JobRepository
+	worker   string
+	handlers map[string]jobHandler
+
+	mu        sync.Mutex
+	recurring []*recurringJob
+}
+
+// NewJobQueue creates a new job queue.
+func NewJobQueue(repo *repository.JobRepository) *JobQueue {
+	host, _ := os.Hostname()
+	return &JobQueue{
+		repo:     repo,
+		worker:   fmt.Sprintf("%s-%d", host, os.Getpid()),
+		handlers: make(map[string]jobHandler),
+	}
+}
+
+// RegisterJob registers the handler of a job type. The JSON payload of each
+// job is decoded into a T before the handler is called. Handlers must be
+// registered before the queue is started.
+func RegisterJob[T any](q *JobQueue, jobType string, handle func(ctx context.Context, payload T) error) {
+	q.handlers[jobType] = func(ctx context.Context, data []byte) error {
+		var payload T
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return Permanent(fmt.Errorf("decoding payload: %w", err))
+		}
+		return handle(ctx, payload)
+	}
+}
+
+// Schedule enqueues a job of the given type every interval. Runs are aligned
+// to multiples of interval and deduplicated, so only one job per interval is
+// enqueued no matter how many processes schedule it.
+func (q *JobQueue) Schedule(jobType string, interval time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.recurring = append(q.recurring, &recurringJob{jobType: jobType, interval: interval})
+}
+
+// Enqueue adds a job with the given payload to the queue. Enqueueing a job
+// whose unique key is already taken does nothing and returns nil.
+func (q *JobQueue) Enqueue(jobType string, payload interface{}, opts JobOptions) (*models.Job, error) {
+	if _, ok := q.handlers[jobType]; !ok {
+		return nil, ErrUnknownJobType
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &models.Job{
+		Type:        jobType,
+		Payload:     string(data),
+		Status:      models.JobPending,
+		MaxAttempts: opts.MaxAttempts,
+		RunAt:       opts.RunAt,
+	}
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = DefaultJobMaxAttempts
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = time.Now()
+	}
+	if opts.UniqueKey != "" {
+		job.UniqueKey = &opts.UniqueKey
+	}
+
+	created, err := q.repo.CreateJob(job)
+	if err != nil || !created {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Run starts workers goroutines that process jobs, plus the scheduler of
+// recurring jobs, and blocks until ctx is cancelled. Jobs still running then
+// get up to shutdownTimeout to finish; after that their context is cancelled
+// and they are returned to the queue for another worker.
+func (q *JobQueue) Run(ctx context.Context, workers int, shutdownTimeout time.Duration) {
+	if workers <= 0 {
+		workers = 1
+	}
+
+	// Jobs outlive ctx so they can finish during a graceful shutdown
+	jobCtx, cancelJobs := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelJobs()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx, jobCtx)
+		}()
+	}
+	q.schedule(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(shutdownTimeout):
+		log.Printf("Jobs still running after %s, interrupting them", shutdownTimeout)
+		cancelJobs()
+		<-done
+	}
+}
+
+// work claims and runs jobs until ctx is cancelled.
+func (q *JobQueue) work(ctx, jobCtx context.Context) {
+	types := q.types()
+	for ctx.Err() == nil {
+		job, err := q.repo.ClaimJob(q.worker, types)
+		if err != nil {
+			log.Printf("Failed to claim job: %v", err)
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(jobPollInterval):
+			}
+			continue
+		}
+		q.runJob(ctx, jobCtx, job)
+	}
+}
+
+// runJob runs a claimed job and records its outcome. While the job runs its
+// lock is renewed, however long it takes; if the lock is lost anyway, the job
+// is interrupted and left to the worker that has it now.
+func (q *JobQueue) runJob(ctx, jobCtx context.Context, job *models.Job) {
+	runCtx, cancel := context.WithCancel(jobCtx)
+	defer cancel()
+	var lost bool
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		lost = q.heartbeat(runCtx, job, cancel)
+	}()
+
+	err := q.call(runCtx, job)
+	cancel()
+	<-stopped
+	if lost {
+		log.Printf("Job %d (%s) lost its lock, leaving it to another worker", job.ID, job.Type)
+		return
+	}
+
+	switch {
+	case err == nil:
+		err = q.repo.CompleteJob(job.ID)
+	case jobCtx.Err() != nil && ctx.Err() != nil:
+		// Interrupted by a shutdown; not the job's fault
+		log.Printf("Job %d (%s) interrupted by shutdown", job.ID, job.Type)
+		err = q.repo.ReleaseJob(job.ID)
+	case errors.As(err, new(permanentError)) || job.Attempts >= job.MaxAttempts:
+		log.Printf("Job %d (%s) failed permanently after %d attempts: %v", job.ID, job.Type, job.Attempts, err)
+		err = q.repo.KillJob(job.ID, err.Error())
+	default:
+		log.Printf("Job %d (%s) failed, attempt %d of %d: %v", job.ID, job.Type, job.Attempts, job.MaxAttempts, err)
+		err = q.repo.RetryJob(job.ID, time.Now().Add(jobBackoff(job.Attempts)), err.Error())
+	}
+	if err != nil {
+		log.Printf("Failed to record outcome of job %d: %v", job.ID, err)
+	}
+}
+
+// heartbeat renews the lock of a running job every jobHeartbeatInterval
+// until ctx is done. If the lock has been lost, it cancels the job and
+// reports true.
+func (q *JobQueue) heartbeat(ctx context.Context, job *models.Job, cancel context.CancelFunc) bool {
+	ticker := time.NewTicker(jobHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+		locked, err := q.repo.TouchJob(job, q.worker)
+		if err != nil {
+			// Transient; the lock survives a few missed beats
+			log.Printf("Failed to renew lock of job %d: %v", job.ID, err)
+			continue
+		}
+		if !locked {
+			cancel()
+			return true
+		}
+	}
+}
+
+// call runs a job's handler, turning panics into errors.
+func (q *JobQueue) call(ctx context.Context, job *models.Job) (err error) {
+	handle, ok := q.handlers[job.Type]
+	if !ok {
+		return Permanent(ErrUnknownJobType)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("job panicked: %v", p)
+		}
+	}()
+	return handle(ctx, []byte(job.Payload))
+}
+
+// schedule runs in the background until ctx is cancelled, enqueueing
+// recurring jobs and doing queue housekeeping.
+func (q *JobQueue) schedule(ctx context.Context) {
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+
+	lastHousekeeping := time.Time{}
+	for {
+		now := time.Now()
+		q.enqueueRecurring(now)
+
+		if now.Sub(lastHousekeeping) >= time.Minute {
+			lastHousekeeping = now
+			if n, err := q.repo.RequeueStaleJobs(now.Add(-jobLockTimeout)); err != nil {
+				log.Printf("Failed to requeue stale jobs: %v", err)
+			} else if n > 0 {
+				log.Printf("Requeued %d stale jobs", n)
+			}
+			if _, err := q.repo.DeleteSucceededJobs(now.Add(-jobRetention)); err != nil {
+				log.Printf("Failed to delete old jobs: %v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// enqueueRecurring enqueues the run of every recurring job due at now. The
+// unique key makes enqueueing the same run from several processes safe.
+func (q *JobQueue) enqueueRecurring(now time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, r := range q.recurring {
+		slot := now.Truncate(r.interval)
+		if slot.Equal(r.lastSlot) {
+			continue
+		}
+		key := fmt.Sprintf("%s@%d", r.jobType, slot.Unix())
+		if _, err := q.Enqueue(r.jobType, struct{}{}, JobOptions{RunAt: slot, MaxAttempts: 1, UniqueKey: key}); err != nil {
+			log.Printf("Failed to schedule job %s: %v", r.jobType, err)
+			continue
+		}
+		r.lastSlot = slot
+	}
+}
+
+// types returns the job types this queue has handlers for.
+func (q *JobQueue) types() []string {
+	types := make([]string, 0, len(q.handlers))
+	for t := range q.handlers {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// jobBackoff returns the delay before retrying a job that failed attempts
+// times: exponential with jitter, capped at jobMaxBackoff.
+func jobBackoff(attempts int) time.Duration {
+	backoff := jobMaxBackoff
+	if attempts < 16 {
+		backoff = min(jobBaseBackoff<<max(attempts-1, 0), jobMaxBackoff)
+	}
+	return backoff/2 + rand.N(backoff/2+1)
+}
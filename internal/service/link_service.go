@@ -0,0 +1,273 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lskeey/go-filehub/internal/models"
+	"github.com/lskeey/go-filehub/internal/repository"
+	"github.com/lskeey/go-filehub/pkg/utils"
+)
+
+var (
+	ErrLinkNotFound         = errors.New("link not found")
+	ErrLinkForbidden        = errors.New("unauthorized: you do not own this link")
+	ErrLinkExpired          = errors.New("link has expired or was revoked")
+	ErrLinkLimitReached     = errors.New("link has reached its download limit")
+	ErrLinkPasswordRequired = errors.New("link requires a valid password")
+	ErrInvalidLink          = errors.New("exactly one of file_id and folder_id must be set, expires_at must be in the future and max_downloads must not be negative")
+	ErrNotAFileLink         = errors.New("file_id is required to download from a folder link")
+)
+
+// Actions recorded in the access log of a public link.
+const (
+	LinkActionView     = "view"
+	LinkActionDownload = "download"
+)
+
+// LinkService manages public links, which give access to a file or a folder
+// to anyone holding the link's token.
+type LinkService struct {
+	linkRepo   *repository.LinkRepository
+	fileRepo   *repository.FileRepository
+	folderRepo *repository.FolderRepository
+}
+
+// NewLinkService creates a new public link service.
+func NewLinkService(linkRepo *repository.LinkRepository, fileRepo *repository.FileRepository, folderRepo *repository.FolderRepository) *LinkService {
+	return &LinkService{linkRepo: linkRepo, fileRepo: fileRepo, folderRepo: folderRepo}
+}
+
+// CreateLink creates a public link to a file or folder owned by ownerID.
+// expiresAt, password and maxDownloads are optional (nil, "" and 0).
+func (s *LinkService) CreateLink(ownerID uint, fileID, folderID *uint, expiresAt *time.Time, password string, maxDownloads int) (*models.PublicLink, error) {
+	if (fileID == nil) == (folderID == nil) || maxDownloads < 0 || (expiresAt != nil && !expiresAt.After(time.Now())) {
+		return nil, ErrInvalidLink
+	}
+	if err := s.checkItemOwner(ownerID, fileID, folderID); err != nil {
+		return nil, err
+	}
+
+	token, err := newLinkToken()
+	if err != nil {
+		return nil, err
+	}
+	link := &models.PublicLink{
+		Token:        token,
+		OwnerID:      ownerID,
+		FileID:       fileID,
+		FolderID:     folderID,
+		ExpiresAt:    expiresAt,
+		MaxDownloads: maxDownloads,
+	}
+	if password != "" {
+		hash, err := utils.HashPassword(password)
+		if err != nil {
+			return nil, err
+		}
+		link.PasswordHash = hash
+	}
+
+	if err := s.linkRepo.CreateLink(link); err != nil {
+		return nil, err
+	}
+	link.HasPassword = link.PasswordHash != ""
+	return link, nil
+}
+
+// ListLinks retrieves ownerID's public links, optionally limited to one file or folder.
+func (s *LinkService) ListLinks(ownerID uint, fileID, folderID *uint) ([]models.PublicLink, error) {
+	links, err := s.linkRepo.FindLinksByOwnerID(ownerID, fileID, folderID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range links {
+		links[i].HasPassword = links[i].PasswordHash != ""
+	}
+	return links, nil
+}
+
+// RevokeLink disables a public link. The link and its access log are kept.
+func (s *LinkService) RevokeLink(linkID, ownerID uint) error {
+	link, err := s.getOwnedLink(linkID, ownerID)
+	if err != nil {
+		return err
+	}
+	if link.RevokedAt != nil {
+		return nil
+	}
+	now := time.Now()
+	link.RevokedAt = &now
+	return s.linkRepo.UpdateLink(link)
+}
+
+// ListAccesses retrieves the access log of one of ownerID's links.
+func (s *LinkService) ListAccesses(linkID, ownerID uint) ([]models.LinkAccess, error) {
+	if _, err := s.getOwnedLink(linkID, ownerID); err != nil {
+		return nil, err
+	}
+	return s.linkRepo.FindAccessesByLinkID(linkID)
+}
+
+// FindLink looks up the link for token without checking whether it may be used.
+func (s *LinkService) FindLink(token string) (*models.PublicLink, error) {
+	link, err := s.linkRepo.FindLinkByToken(token)
+	if err != nil {
+		return nil, ErrLinkNotFound
+	}
+	return link, nil
+}
+
+// CheckLink verifies that a link is usable: not revoked, not expired, below
+// its download limit, and unlocked by password if it has one.
+func (s *LinkService) CheckLink(link *models.PublicLink, password string) error {
+	if link.RevokedAt != nil || (link.ExpiresAt != nil && !link.ExpiresAt.After(time.Now())) {
+		return ErrLinkExpired
+	}
+	if link.MaxDownloads > 0 && link.DownloadCount >= link.MaxDownloads {
+		return ErrLinkLimitReached
+	}
+	if link.PasswordHash != "" && !utils.CheckPasswordHash(password, link.PasswordHash) {
+		return ErrLinkPasswordRequired
+	}
+	return nil
+}
+
+// LinkFolder retrieves a folder reachable through a folder link, along with
+// its content. folderID selects a subfolder; nil is the linked folder itself.
+func (s *LinkService) LinkFolder(link *models.PublicLink, folderID *uint) (*models.Folder, []models.Folder, []models.File, error) {
+	if link.FolderID == nil {
+		return nil, nil, nil, ErrFolderNotFound
+	}
+	if folderID == nil {
+		folderID = link.FolderID
+	}
+	if !s.inLinkedFolder(link, folderID) {
+		return nil, nil, nil, ErrFolderNotFound
+	}
+
+	folder, err := s.folderRepo.FindFolderByID(*folderID)
+	if err != nil {
+		return nil, nil, nil, ErrFolderNotFound
+	}
+	folders, err := s.folderRepo.FindChildFolders(folder.OwnerID, folderID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	files, err := s.fileRepo.FindFilesInFolder(folder.OwnerID, folderID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return folder, folders, files, nil
+}
+
+// LinkFile retrieves a file reachable through a link. A file link always
+// yields its file; for a folder link, fileID selects a file inside the folder.
+func (s *LinkService) LinkFile(link *models.PublicLink, fileID *uint) (*models.File, error) {
+	if link.FileID != nil {
+		file, err := s.fileRepo.FindFileByID(*link.FileID)
+		if err != nil {
+			return nil, ErrFileNotFound
+		}
+		return file, nil
+	}
+	if fileID == nil {
+		return nil, ErrNotAFileLink
+	}
+
+	file, err := s.fileRepo.FindFileByID(*fileID)
+	if err != nil || !s.inLinkedFolder(link, file.FolderID) {
+		return nil, ErrFileNotFound
+	}
+	return file, nil
+}
+
+// CountDownload counts a download against the link's limit.
+func (s *LinkService) CountDownload(link *models.PublicLink) error {
+	counted, err := s.linkRepo.IncrementDownloadCount(link.ID)
+	if err != nil {
+		return err
+	}
+	if !counted {
+		return ErrLinkLimitReached
+	}
+	link.DownloadCount++
+	return nil
+}
+
+// RecordAccess adds an entry to the access log of a link. Failures are only
+// logged so they never break the response.
+func (s *LinkService) RecordAccess(link *models.PublicLink, fileID *uint, action string, status int, ip, userAgent string) {
+	if err := s.linkRepo.CreateAccess(&models.LinkAccess{
+		LinkID:    link.ID,
+		FileID:    fileID,
+		Action:    action,
+		Status:    status,
+		IP:        ip,
+		UserAgent: userAgent,
+	}); err != nil {
+		fmt.Printf("Failed to record access to link %d: %v\n", link.ID, err)
+	}
+}
+
+// inLinkedFolder reports whether folderID is the link's folder or lies below it.
+func (s *LinkService) inLinkedFolder(link *models.PublicLink, folderID *uint) bool {
+	if link.FolderID == nil || folderID == nil {
+		return false
+	}
+	ancestors, err := s.folderRepo.FindAncestorIDs(*folderID)
+	if err != nil {
+		return false
+	}
+	for _, id := range ancestors {
+		if id == *link.FolderID {
+			return true
+		}
+	}
+	return false
+}
+
+// checkItemOwner verifies that the file or folder exists and belongs to ownerID.
+func (s *LinkService) checkItemOwner(ownerID uint, fileID, folderID *uint) error {
+	if fileID != nil {
+		file, err := s.fileRepo.FindFileByID(*fileID)
+		if err != nil {
+			return ErrFileNotFound
+		}
+		if file.OwnerID != ownerID {
+			return ErrFileForbidden
+		}
+		return nil
+	}
+	folder, err := s.folderRepo.FindFolderByID(*folderID)
+	if err != nil {
+		return ErrFolderNotFound
+	}
+	if folder.OwnerID != ownerID {
+		return ErrFolderForbidden
+	}
+	return nil
+}
+
+func (s *LinkService) getOwnedLink(linkID, ownerID uint) (*models.PublicLink, error) {
+	link, err := s.linkRepo.FindLinkByID(linkID)
+	if err != nil {
+		return nil, ErrLinkNotFound
+	}
+	if link.OwnerID != ownerID {
+		return nil, ErrLinkForbidden
+	}
+	return link, nil
+}
+
+// newLinkToken generates a random, URL-safe link token.
+func newLinkToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lskeey/go-filehub/internal/repository"
+)
+
+// JobReconcileUsage is the recurring job that recomputes usage counters.
+const JobReconcileUsage = "quota.reconcile"
+
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+// Usage describes a user's storage consumption. A QuotaBytes of 0 means unlimited.
+type Usage struct {
+	UsedBytes      int64 `json:"used_bytes"`
+	QuotaBytes     int64 `json:"quota_bytes"`
+	RemainingBytes int64 `json:"remaining_bytes"`
+}
+
+// QuotaService keeps per-user usage counters and enforces storage quotas.
+type QuotaService struct {
+	userRepo     *repository.UserRepository
+	defaultQuota int64
+}
+
+// NewQuotaService creates a new quota service. defaultQuota applies to users
+// without a quota of their own; 0 means unlimited.
+func NewQuotaService(userRepo *repository.UserRepository, defaultQuota int64) *QuotaService {
+	return &QuotaService{userRepo: userRepo, defaultQuota: defaultQuota}
+}
+
+// GetUsage returns the usage and effective quota of a user.
+func (s *QuotaService) GetUsage(userID uint) (*Usage, error) {
+	user, err := s.userRepo.FindUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &Usage{UsedBytes: user.UsedBytes, QuotaBytes: s.effectiveQuota(user.QuotaBytes)}
+	if usage.QuotaBytes > 0 {
+		usage.RemainingBytes = max(usage.QuotaBytes-usage.UsedBytes, 0)
+	}
+	return usage, nil
+}
+
+// Remaining returns how many more bytes the user may store, or -1 if unlimited.
+func (s *QuotaService) Remaining(userID uint) (int64, error) {
+	usage, err := s.GetUsage(userID)
+	if err != nil {
+		return 0, err
+	}
+	if usage.QuotaBytes == 0 {
+		return -1, nil
+	}
+	return usage.RemainingBytes, nil
+}
+
+// Charge adds bytes to a user's usage, failing with ErrQuotaExceeded if that
+// would exceed the quota. The check and the update are a single statement,
+// so concurrent uploads cannot overshoot the quota together.
+func (s *QuotaService) Charge(userID uint, bytes int64) error {
+	user, err := s.userRepo.FindUserByID(userID)
+	if err != nil {
+		return err
+	}
+	ok, err := s.userRepo.AddUsedBytes(userID, bytes, s.effectiveQuota(user.QuotaBytes))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// Credit removes bytes from a user's usage.
+func (s *QuotaService) Credit(userID uint, bytes int64) error {
+	_, err := s.userRepo.AddUsedBytes(userID, -bytes, 0)
+	return err
+}
+
+// Reconcile recomputes a user's usage from the files table and fixes the
+// counter if it has drifted. It returns the recomputed usage.
+func (s *QuotaService) Reconcile(userID uint) (int64, error) {
+	return s.userRepo.RecomputeUsedBytes(userID)
+}
+
+// ReconcileAll runs Reconcile for every user.
+func (s *QuotaService) ReconcileAll() error {
+	ids, err := s.userRepo.FindAllUserIDs()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, err := s.Reconcile(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReconcileJob is the handler of JobReconcileUsage.
+func (s *QuotaService) ReconcileJob(ctx context.Context, _ struct{}) error {
+	return s.ReconcileAll()
+}
+
+func (s *QuotaService) effectiveQuota(userQuota int64) int64 {
+	if userQuota > 0 {
+		return userQuota
+	}
+	return s.defaultQuota
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lskeey/go-filehub/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNoSuchUpload      = errors.New("the specified multipart upload does not exist")
+	ErrInvalidPart       = errors.New("one or more of the specified parts could not be found or its entity tag did not match")
+	ErrInvalidPartOrder  = errors.New("the list of parts was not in ascending order")
+	ErrInvalidPartNumber = errors.New("part numbers must be between 1 and 10000")
+)
+
+// MaxPartNumber is the highest part number of a multipart upload.
+const MaxPartNumber = 10000
+
+// DefaultMultipartExpiry is how long an unfinished multipart upload is kept.
+const DefaultMultipartExpiry = 7 * 24 * time.Hour
+
+// JobExpireMultipart is the job type that aborts stale multipart uploads.
+const JobExpireMultipart = "s3.expire_multipart"
+
+// CompletedPart is a part listed when completing a multipart upload.
+type CompletedPart struct {
+	PartNumber int
+	ETag       string
+}
+
+// CreateMultipartUpload starts a multipart upload to key. Names the upload
+// policy rejects are refused up front, before any part is sent.
+func (s *S3Service) CreateMultipartUpload(userID uint, bucket, key, contentType string, metadata map[string]string) (*models.MultipartUpload, error) {
+	_, name, err := objectPath(key)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return nil, ErrInvalidObjectKey
+	}
+	if err := validateObjectMetadata(metadata); err != nil {
+		return nil, err
+	}
+	if err := s.fileService.policy.CheckName(name); err != nil {
+		return nil, err
+	}
+	if _, err := s.bucket(userID, bucket); err != nil {
+		return nil, err
+	}
+
+	id, err := newUploadID()
+	if err != nil {
+		return nil, err
+	}
+	upload := &models.MultipartUpload{
+		ID:          id,
+		OwnerID:     userID,
+		Bucket:      bucket,
+		Key:         key,
+		ContentType: contentType,
+		Metadata:    metadata,
+	}
+	if err := os.MkdirAll(s.partDir(id), 0o755); err != nil {
+		return nil, err
+	}
+	if err := s.multipartRepo.CreateUpload(upload); err != nil {
+		_ = os.RemoveAll(s.partDir(id))
+		return nil, err
+	}
+	return upload, nil
+}
+
+// UploadPart stages the content of r as part partNumber and returns its
+// quoted entity tag. Together with the other parts it may neither exceed
+// the upload limit nor the user's remaining quota.
+func (s *S3Service) UploadPart(userID uint, uploadID string, bucket, key string, partNumber int, r io.Reader) (string, error) {
+	if partNumber < 1 || partNumber > MaxPartNumber {
+		return "", ErrInvalidPartNumber
+	}
+	upload, err := s.getUpload(userID, uploadID, bucket, key)
+	if err != nil {
+		return "", err
+	}
+
+	limit, tooLarge, err := s.partLimit(upload, partNumber)
+	if err != nil {
+		return "", err
+	}
+
+	// Write to a temporary file first so a failed upload keeps the old part
+	f, err := os.CreateTemp(s.partDir(upload.ID), "part-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(f, hash), io.LimitReader(r, limit+1))
+	if err != nil {
+		return "", err
+	}
+	if size > limit {
+		return "", tooLarge
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(f.Name(), s.partPath(upload.ID, partNumber)); err != nil {
+		return "", err
+	}
+
+	part := &models.MultipartPart{
+		UploadID:   upload.ID,
+		PartNumber: partNumber,
+		Size:       size,
+		ETag:       hex.EncodeToString(hash.Sum(nil)),
+	}
+	if err := s.multipartRepo.SavePart(part); err != nil {
+		return "", err
+	}
+	// Parts uploaded at the same time were each checked without the other;
+	// with part number 0, every staged part counts
+	if limit, tooLarge, err := s.partLimit(upload, 0); err != nil {
+		return "", err
+	} else if limit < 0 {
+		_ = s.multipartRepo.DeletePart(upload.ID, partNumber)
+		_ = os.Remove(s.partPath(upload.ID, partNumber))
+		return "", tooLarge
+	}
+	return `"` + part.ETag + `"`, nil
+}
+
+// partLimit returns how many bytes part partNumber of upload may hold
+// besides the other staged parts, and the error for exceeding that. The
+// limit is negative if the other parts already exceed it.
+func (s *S3Service) partLimit(upload *models.MultipartUpload, partNumber int) (int64, error, error) {
+	staged, err := s.multipartRepo.SumPartSizes(upload.ID, partNumber)
+	if err != nil {
+		return 0, nil, err
+	}
+	limit, tooLarge := s.fileService.MaxUploadSize()-staged, ErrFileTooLarge
+	remaining, err := s.fileService.quotaService.Remaining(upload.OwnerID)
+	if err != nil {
+		return 0, nil, err
+	}
+	if remaining >= 0 && remaining-staged < limit {
+		limit, tooLarge = remaining-staged, ErrQuotaExceeded
+	}
+	return limit, tooLarge, nil
+}
+
+// CompleteMultipartUpload joins the listed parts into the object at the
+// upload's key, in the order of their part numbers, and ends the upload.
+func (s *S3Service) CompleteMultipartUpload(ctx context.Context, userID uint, uploadID string, bucket, key string, parts []CompletedPart) (*S3Object, error) {
+	upload, err := s.getUpload(userID, uploadID, bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		return nil, ErrInvalidPart
+	}
+	stored, err := s.multipartRepo.FindParts(upload.ID)
+	if err != nil {
+		return nil, err
+	}
+	etags := make(map[int]string, len(stored))
+	for _, part := range stored {
+		etags[part.PartNumber] = part.ETag
+	}
+
+	readers := make([]io.Reader, len(parts))
+	for i, part := range parts {
+		if i > 0 && part.PartNumber <= parts[i-1].PartNumber {
+			return nil, ErrInvalidPartOrder
+		}
+		etag, ok := etags[part.PartNumber]
+		if !ok || etag != strings.Trim(part.ETag, `"`) {
+			return nil, ErrInvalidPart
+		}
+		f, err := os.Open(s.partPath(upload.ID, part.PartNumber))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		readers[i] = f
+	}
+
+	object, err := s.PutObject(ctx, userID, upload.Bucket, upload.Key, upload.ContentType, upload.Metadata, io.MultiReader(readers...))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.removeUpload(upload.ID); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+// AbortMultipartUpload discards a multipart upload and its parts.
+func (s *S3Service) AbortMultipartUpload(userID uint, uploadID string, bucket, key string) error {
+	upload, err := s.getUpload(userID, uploadID, bucket, key)
+	if err != nil {
+		return err
+	}
+	return s.removeUpload(upload.ID)
+}
+
+// ExpireMultipartJob aborts multipart uploads that were started longer than
+// DefaultMultipartExpiry ago and never completed.
+func (s *S3Service) ExpireMultipartJob(ctx context.Context, _ struct{}) error {
+	uploads, err := s.multipartRepo.FindUploadsCreatedBefore(time.Now().Add(-DefaultMultipartExpiry))
+	if err != nil {
+		return err
+	}
+	for _, upload := range uploads {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.removeUpload(upload.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getUpload retrieves a multipart upload of userID to bucket and key. An
+// upload of another user, or to another key, doesn't exist as far as the
+// caller is concerned.
+func (s *S3Service) getUpload(userID uint, uploadID, bucket, key string) (*models.MultipartUpload, error) {
+	upload, err := s.multipartRepo.FindUploadByID(uploadID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoSuchUpload
+	}
+	if err != nil {
+		return nil, err
+	}
+	if upload.OwnerID != userID || upload.Bucket != bucket || upload.Key != key {
+		return nil, ErrNoSuchUpload
+	}
+	return upload, nil
+}
+
+func (s *S3Service) removeUpload(id string) error {
+	if err := os.RemoveAll(s.partDir(id)); err != nil {
+		return err
+	}
+	return s.multipartRepo.DeleteUpload(id)
+}
+
+func (s *S3Service) partDir(uploadID string) string {
+	return filepath.Join(s.dir, uploadID)
+}
+
+func (s *S3Service) partPath(uploadID string, partNumber int) string {
+	return filepath.Join(s.partDir(uploadID), strconv.Itoa(partNumber))
+}
@@ -0,0 +1,421 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lskeey/go-filehub/internal/models"
+	"github.com/lskeey/go-filehub/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNoSuchBucket         = errors.New("the specified bucket does not exist")
+	ErrNoSuchKey            = errors.New("the specified key does not exist")
+	ErrInvalidBucketName    = errors.New("bucket names must be 3-63 lower-case letters, digits, '.' or '-', starting and ending with a letter or digit")
+	ErrInvalidObjectKey     = errors.New("object keys must be at most 1024 bytes of '/'-separated valid names")
+	ErrBucketAlreadyOwned   = errors.New("the bucket already exists and is owned by you")
+	ErrBucketNotEmpty       = errors.New("the bucket is not empty")
+	ErrInvalidObjectListing = errors.New("max-keys must be between 0 and 1000")
+)
+
+// MaxListKeys is the most keys a single ListObjects page returns.
+const MaxListKeys = 1000
+
+// maxObjectKeyLength is the longest object key S3 allows.
+const maxObjectKeyLength = 1024
+
+var bucketNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+
+// emptyDigest is the SHA-256 of no content, the ETag of folder markers.
+var emptyDigest = func() string {
+	sum := sha256.Sum256(nil)
+	return hex.EncodeToString(sum[:])
+}()
+
+// S3Service maps the S3 object model onto the user's files. Buckets are the
+// folders at the user's root and object keys are paths inside them, like
+// "photos/2024/beach.jpg". A key ending in '/' is a folder marker, which
+// stands for the folder itself. Files directly at the root aren't reachable.
+type S3Service struct {
+	fileService   *FileService
+	folderService *FolderService
+	multipartRepo *repository.MultipartRepository
+	dir           string
+}
+
+// NewS3Service creates a new S3 service staging multipart uploads in dir.
+func NewS3Service(fileService *FileService, folderService *FolderService, multipartRepo *repository.MultipartRepository, dir string) (*S3Service, error) {
+	if dir == "" {
+		dir = filepath.Join("tmp", "uploads")
+	}
+	dir = filepath.Join(dir, "s3")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &S3Service{fileService: fileService, folderService: folderService, multipartRepo: multipartRepo, dir: dir}, nil
+}
+
+// S3Object is a file or a folder marker seen as an S3 object.
+type S3Object struct {
+	Key    string
+	File   *models.File   // nil for folder markers
+	Folder *models.Folder // Set for folder markers
+}
+
+// Size returns the size of the object's content.
+func (o *S3Object) Size() int64 {
+	if o.File == nil {
+		return 0
+	}
+	return o.File.Size
+}
+
+// ETag returns the quoted entity tag of the object, derived from the SHA-256
+// of its content. It is deliberately not an MD5, so clients don't mistake it
+// for one and compare it against their own checksums.
+func (o *S3Object) ETag() string {
+	if o.File == nil || o.File.BlobDigest == "" {
+		return `"` + emptyDigest + `"`
+	}
+	return `"` + o.File.BlobDigest + `"`
+}
+
+// LastModified returns when the object's content last changed.
+func (o *S3Object) LastModified() time.Time {
+	if o.File == nil {
+		return o.Folder.UpdatedAt
+	}
+	return o.File.UpdatedAt
+}
+
+// ContentType returns the detected MIME type of the object's content.
+func (o *S3Object) ContentType() string {
+	if o.File == nil {
+		return "application/x-directory"
+	}
+	return o.File.MimeType
+}
+
+// Metadata returns the object's user metadata, which are the file's custom
+// properties.
+func (o *S3Object) Metadata() models.Properties {
+	if o.File == nil {
+		return nil
+	}
+	return o.File.Properties
+}
+
+// ListBuckets retrieves the folders at userID's root.
+func (s *S3Service) ListBuckets(userID uint) ([]models.Folder, error) {
+	return s.folderService.folderRepo.FindChildFolders(userID, nil)
+}
+
+// CreateBucket creates a folder at userID's root. Unlike existing folders,
+// which are all usable as buckets, new ones must follow S3's naming rules.
+func (s *S3Service) CreateBucket(userID uint, bucket string) (*models.Folder, error) {
+	if !bucketNamePattern.MatchString(bucket) || strings.Contains(bucket, "..") {
+		return nil, ErrInvalidBucketName
+	}
+	folder, err := s.folderService.CreateFolder(userID, bucket, nil)
+	if errors.Is(err, ErrNameConflict) {
+		if _, err := s.bucket(userID, bucket); err == nil {
+			return nil, ErrBucketAlreadyOwned
+		}
+	}
+	return folder, err
+}
+
+// HeadBucket checks that a bucket exists.
+func (s *S3Service) HeadBucket(userID uint, bucket string) error {
+	_, err := s.bucket(userID, bucket)
+	return err
+}
+
+// DeleteBucket moves an empty bucket to the trash.
+func (s *S3Service) DeleteBucket(userID uint, bucket string) error {
+	folder, err := s.bucket(userID, bucket)
+	if err != nil {
+		return err
+	}
+	err = s.folderService.DeleteFolder(folder.ID, userID, false)
+	if errors.Is(err, ErrFolderNotEmpty) {
+		return ErrBucketNotEmpty
+	}
+	return err
+}
+
+// GetObject retrieves the object at key.
+func (s *S3Service) GetObject(userID uint, bucket, key string) (*S3Object, error) {
+	dirs, name, err := objectPath(key)
+	if err != nil {
+		return nil, err
+	}
+	folder, err := s.bucket(userID, bucket)
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		if folder, err = s.childFolder(userID, folder.ID, dir); err != nil {
+			return nil, err
+		}
+	}
+	if name == "" {
+		return &S3Object{Key: key, Folder: folder}, nil
+	}
+	file, err := s.fileService.fileRepo.FindFileByName(userID, &folder.ID, name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoSuchKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &S3Object{Key: key, File: file}, nil
+}
+
+// OpenObject opens the content of an object for reading.
+func (s *S3Service) OpenObject(ctx context.Context, object *S3Object) (io.ReadSeekCloser, error) {
+	if object.File == nil {
+		return nopSeekCloser{strings.NewReader("")}, nil
+	}
+	return s.fileService.OpenFile(ctx, object.File)
+}
+
+// PutObject stores the content of r at key, creating the folders on the way.
+// An existing file gets a new version. metadata is merged into the file's
+// custom properties. For a folder marker the content is ignored.
+func (s *S3Service) PutObject(ctx context.Context, userID uint, bucket, key, contentType string, metadata map[string]string, r io.Reader) (*S3Object, error) {
+	dirs, name, err := objectPath(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateObjectMetadata(metadata); err != nil {
+		return nil, err
+	}
+	folder, err := s.bucket(userID, bucket)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		// Folder markers are empty; anything sent along is dropped
+		if _, err := io.Copy(io.Discard, r); err != nil {
+			return nil, err
+		}
+	}
+	for _, dir := range dirs {
+		if folder, err = s.folderService.EnsureFolder(userID, &folder.ID, dir); err != nil {
+			return nil, err
+		}
+	}
+	if name == "" {
+		return &S3Object{Key: key, Folder: folder}, nil
+	}
+
+	file, err := s.fileService.UploadFile(ctx, userID, &folder.ID, name, contentType, r)
+	if err != nil {
+		return nil, err
+	}
+	if len(metadata) > 0 {
+		properties := make(map[string]*string, len(metadata))
+		for k, v := range metadata {
+			properties[k] = &v
+		}
+		if file, err = s.fileService.UpdateMetadata(file.ID, userID, FileMetadataUpdate{Properties: properties}); err != nil {
+			return nil, err
+		}
+	}
+	return &S3Object{Key: key, File: file}, nil
+}
+
+// DeleteObject moves the object at key to the trash. Deleting a key that
+// doesn't exist succeeds, as in S3. A folder marker only removes the folder
+// if it is empty; otherwise the folder still stands for the keys inside it.
+func (s *S3Service) DeleteObject(userID uint, bucket, key string) error {
+	object, err := s.GetObject(userID, bucket, key)
+	if errors.Is(err, ErrNoSuchKey) || errors.Is(err, ErrInvalidObjectKey) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if object.File != nil {
+		return s.fileService.DeleteFile(object.File.ID, userID)
+	}
+	err = s.folderService.DeleteFolder(object.Folder.ID, userID, false)
+	if errors.Is(err, ErrFolderNotEmpty) {
+		return nil
+	}
+	return err
+}
+
+// S3ListOptions selects the keys ListObjects returns.
+type S3ListOptions struct {
+	Prefix     string // Only keys starting with Prefix
+	Delimiter  string // Keys with Delimiter after the prefix are rolled up into common prefixes
+	StartAfter string // Only keys after StartAfter
+	MaxKeys    int    // Most keys and common prefixes returned, at most MaxListKeys
+}
+
+// S3ListResult is a page of ListObjects results, sorted by key.
+type S3ListResult struct {
+	Objects        []S3Object
+	CommonPrefixes []string
+	IsTruncated    bool
+	LastKey        string // Last key or common prefix returned, to continue after
+}
+
+// ListObjects lists the objects in a bucket. Folder markers aren't listed;
+// folders show up as common prefixes when the delimiter is '/', and through
+// the keys of the files inside them otherwise.
+func (s *S3Service) ListObjects(userID uint, bucket string, opts S3ListOptions) (*S3ListResult, error) {
+	if opts.MaxKeys < 0 || opts.MaxKeys > MaxListKeys {
+		return nil, ErrInvalidObjectListing
+	}
+	folder, err := s.bucket(userID, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the folder the prefix points into; without one, nothing matches
+	dirPath := opts.Prefix[:strings.LastIndex(opts.Prefix, "/")+1]
+	for _, dir := range strings.Split(strings.TrimSuffix(dirPath, "/"), "/") {
+		if dir == "" {
+			continue
+		}
+		if folder, err = s.childFolder(userID, folder.ID, dir); errors.Is(err, ErrNoSuchKey) {
+			return &S3ListResult{}, nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	// Keys are fetched in order, in batches just large enough to fill the
+	// page. Keys rolled up into a common prefix are skipped in the query.
+	find := s.fileService.fileRepo.FindFileKeysBelow
+	if opts.Delimiter == "/" {
+		// Everything below the folder's children rolls up into them
+		find = s.fileService.fileRepo.FindChildKeys
+	}
+	q := repository.ObjectKeyQuery{FolderID: folder.ID, BasePath: dirPath, Prefix: opts.Prefix, After: opts.StartAfter}
+
+	result := &S3ListResult{}
+	var fileIDs []uint
+	var entries []repository.ObjectKey
+	for {
+		q.Limit = opts.MaxKeys - len(entries) + 1
+		keys, err := find(q)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range keys {
+			q.After = k.Key
+			if q.SkipPrefix != "" && strings.HasPrefix(k.Key, q.SkipPrefix) {
+				continue
+			}
+			if opts.Delimiter != "" {
+				if n := strings.Index(k.Key[len(opts.Prefix):], opts.Delimiter); n >= 0 {
+					prefix := k.Key[:len(opts.Prefix)+n+len(opts.Delimiter)]
+					q.SkipPrefix = prefix
+					k = repository.ObjectKey{Key: prefix}
+				}
+			}
+			// A continuation inside a common prefix resumes after it
+			if k.Key <= opts.StartAfter {
+				continue
+			}
+			if len(entries) == opts.MaxKeys {
+				result.IsTruncated = true
+				break
+			}
+			entries = append(entries, k)
+			if k.FileID != nil {
+				fileIDs = append(fileIDs, *k.FileID)
+			}
+		}
+		if result.IsTruncated || len(keys) < q.Limit {
+			break
+		}
+	}
+
+	files, err := s.fileService.fileRepo.FindFilesByIDs(fileIDs)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*models.File, len(files))
+	for i := range files {
+		byID[files[i].ID] = &files[i]
+	}
+	for _, e := range entries {
+		if e.FileID == nil {
+			result.CommonPrefixes = append(result.CommonPrefixes, e.Key)
+		} else if file, ok := byID[*e.FileID]; ok {
+			result.Objects = append(result.Objects, S3Object{Key: e.Key, File: file})
+		}
+		result.LastKey = e.Key
+	}
+	return result, nil
+}
+
+// bucket finds the folder called bucket at userID's root. Only the user's
+// own folders are buckets; folders shared with them are not.
+func (s *S3Service) bucket(userID uint, bucket string) (*models.Folder, error) {
+	folder, err := s.folderService.folderRepo.FindFolderByName(userID, nil, bucket)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoSuchBucket
+	}
+	return folder, err
+}
+
+func (s *S3Service) childFolder(userID, parentID uint, name string) (*models.Folder, error) {
+	folder, err := s.folderService.folderRepo.FindFolderByName(userID, &parentID, name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoSuchKey
+	}
+	return folder, err
+}
+
+// objectPath splits an object key into folder names and a file name, which
+// is empty for folder markers.
+func objectPath(key string) ([]string, string, error) {
+	if key == "" || len(key) > maxObjectKeyLength {
+		return nil, "", ErrInvalidObjectKey
+	}
+	segments := strings.Split(key, "/")
+	name := segments[len(segments)-1]
+	dirs := segments[:len(segments)-1]
+	for _, segment := range dirs {
+		if validateName(segment) != nil {
+			return nil, "", ErrInvalidObjectKey
+		}
+	}
+	if name != "" && validateName(name) != nil {
+		return nil, "", ErrInvalidObjectKey
+	}
+	return dirs, name, nil
+}
+
+func validateObjectMetadata(metadata map[string]string) error {
+	if len(metadata) > MaxProperties {
+		return ErrInvalidProperty
+	}
+	for key, value := range metadata {
+		if err := validateProperty(key, &value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopSeekCloser) Close() error { return nil }
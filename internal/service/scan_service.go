@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lskeey/go-filehub/internal/clamd"
+	"github.com/lskeey/go-filehub/internal/models"
+	"github.com/lskeey/go-filehub/internal/repository"
+	"github.com/lskeey/go-filehub/internal/storage"
+	"gorm.io/gorm"
+)
+
+// Jobs of the virus scanner. JobScanFile scans one file; the recurring
+// JobScanPending enqueues scans that were missed, e.g. for files uploaded
+// before scanning was enabled, and retries scans that failed.
+const (
+	JobScanFile    = "scan.file"
+	JobScanPending = "scan.pending"
+)
+
+// scanBatchSize is the number of files JobScanPending enqueues per query.
+const scanBatchSize = 100
+
+// scanRetryDelay is how long a file whose scan failed waits before
+// JobScanPending queues it again.
+const scanRetryDelay = time.Hour
+
+var (
+	ErrFileInfected   = errors.New("file is quarantined: malware was detected in its content")
+	ErrFileNotScanned = errors.New("file has not passed the virus scan yet, try again later")
+)
+
+// ScanFilePayload is the payload of JobScanFile.
+type ScanFilePayload struct {
+	FileID  uint `json:"file_id"`
+	Version int  `json:"version"` // The file version to scan; later versions get their own job
+}
+
+// ScanService scans uploaded content for malware with a clamd-compatible
+// daemon. Content found infected is quarantined: no file or version holding
+// it can be downloaded. Without a scanner, scanning is disabled and only
+// quarantined content is blocked.
+type ScanService struct {
+	scanRepo    *repository.ScanRepository
+	fileRepo    *repository.FileRepository
+	blobService *BlobService
+	jobQueue    *JobQueue
+	scanner     *clamd.Client
+}
+
+// NewScanService creates a new scan service. scanner may be nil to disable scanning.
+func NewScanService(scanRepo *repository.ScanRepository, fileRepo *repository.FileRepository, blobService *BlobService, jobQueue *JobQueue, scanner *clamd.Client) *ScanService {
+	return &ScanService{scanRepo: scanRepo, fileRepo: fileRepo, blobService: blobService, jobQueue: jobQueue, scanner: scanner}
+}
+
+// Enabled reports whether uploads are scanned.
+func (s *ScanService) Enabled() bool {
+	return s.scanner != nil
+}
+
+// Submit is called whenever a file gets new content. Known malware is
+// quarantined right away; anything else is queued for scanning.
+func (s *ScanService) Submit(file *models.File) {
+	if quarantined, err := s.scanRepo.FindQuarantinedBlob(file.BlobDigest); file.BlobDigest != "" && err == nil {
+		if err := s.scanRepo.Quarantine(quarantined); err != nil {
+			log.Printf("Failed to quarantine file %d: %v", file.ID, err)
+		}
+		file.ScanStatus = models.ScanInfected
+		return
+	}
+	if !s.Enabled() {
+		return
+	}
+	if err := s.enqueue(file.ID, file.Version); err != nil {
+		// JobScanPending picks the file up later
+		log.Printf("Failed to queue scan of file %d: %v", file.ID, err)
+	}
+}
+
+// enqueue queues a scan of a file version, at most once.
+func (s *ScanService) enqueue(fileID uint, version int) error {
+	_, err := s.jobQueue.Enqueue(JobScanFile, ScanFilePayload{FileID: fileID, Version: version}, JobOptions{
+		UniqueKey: fmt.Sprintf("%s:%d:%d", JobScanFile, fileID, version),
+	})
+	return err
+}
+
+// CheckFile returns an error if the current content of file may not be
+// downloaded: if it is quarantined, or scanning is enabled and it hasn't
+// been found clean. Content too large for the scanner can't ever be found
+// clean; rather than locking it away for good, it is served unscanned.
+func (s *ScanService) CheckFile(file *models.File) error {
+	return s.checkStatus(file.ScanStatus, file.BlobDigest)
+}
+
+// CheckVersion is CheckFile for a version from a file's history.
+func (s *ScanService) CheckVersion(v *models.FileVersion) error {
+	return s.checkStatus(v.ScanStatus, v.BlobDigest)
+}
+
+func (s *ScanService) checkStatus(status, digest string) error {
+	if status == models.ScanInfected {
+		return ErrFileInfected
+	}
+	if err := s.CheckContent(digest); err != nil {
+		return err
+	}
+	if s.Enabled() && status != models.ScanClean && status != models.ScanTooLarge {
+		return ErrFileNotScanned
+	}
+	return nil
+}
+
+// CheckContent returns ErrFileInfected if content is quarantined.
+func (s *ScanService) CheckContent(digest string) error {
+	if digest == "" {
+		return nil
+	}
+	_, err := s.scanRepo.FindQuarantinedBlob(digest)
+	switch {
+	case err == nil:
+		return ErrFileInfected
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return nil
+	default:
+		return err
+	}
+}
+
+// ScanFileJob is the handler of JobScanFile. Scanner failures are recorded
+// as ScanError and returned, so the job is retried. Content beyond the
+// scanner's size limit is recorded as ScanTooLarge and not scanned again.
+func (s *ScanService) ScanFileJob(ctx context.Context, p ScanFilePayload) error {
+	if !s.Enabled() {
+		return nil
+	}
+	file, err := s.fileRepo.FindFileByID(p.FileID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Deleted; restored files are picked up by JobScanPending
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	status, digest, path := file.ScanStatus, file.BlobDigest, file.S3Path
+	if file.Version != p.Version {
+		// An older version from the file's history
+		v, err := s.fileRepo.FindVersion(p.FileID, p.Version)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		status, digest, path = v.ScanStatus, v.BlobDigest, v.S3Path
+	}
+	if status == models.ScanClean || status == models.ScanInfected || status == models.ScanTooLarge {
+		return nil
+	}
+
+	result, err := s.scan(ctx, path)
+	if errors.Is(err, clamd.ErrSizeLimit) {
+		log.Printf("Version %d of file %d is too large to be scanned", p.Version, p.FileID)
+		return s.scanRepo.SetScanStatus(p.FileID, p.Version, models.ScanTooLarge)
+	}
+	if err != nil {
+		if statusErr := s.scanRepo.SetScanStatus(p.FileID, p.Version, models.ScanError); statusErr != nil {
+			log.Printf("Failed to record scan error of file %d: %v", p.FileID, statusErr)
+		}
+		if errors.Is(err, storage.ErrNotFound) {
+			return Permanent(err)
+		}
+		return err
+	}
+
+	if result.Infected {
+		log.Printf("Malware %q found in version %d of file %d, quarantining its content", result.Signature, p.Version, p.FileID)
+		// Content stored before deduplication belongs to this version alone
+		if digest == "" {
+			return s.scanRepo.SetScanStatus(p.FileID, p.Version, models.ScanInfected)
+		}
+		return s.scanRepo.Quarantine(&models.QuarantinedBlob{
+			Digest:    digest,
+			Signature: result.Signature,
+			FileID:    p.FileID,
+		})
+	}
+	return s.scanRepo.SetScanStatus(p.FileID, p.Version, models.ScanClean)
+}
+
+// ScanPendingJob is the handler of JobScanPending.
+func (s *ScanService) ScanPendingJob(ctx context.Context, _ struct{}) error {
+	if !s.Enabled() {
+		return nil
+	}
+	retryBefore := time.Now().Add(-scanRetryDelay)
+	files, err := s.scanRepo.FindFilesToScan(retryBefore, scanBatchSize)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := s.enqueue(file.ID, file.Version); err != nil {
+			return err
+		}
+	}
+	versions, err := s.scanRepo.FindVersionsToScan(retryBefore, scanBatchSize)
+	if err != nil {
+		return err
+	}
+	for _, v := range versions {
+		if err := s.enqueue(v.FileID, v.Version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ScanService) scan(ctx context.Context, path string) (*clamd.Result, error) {
+	r, err := s.blobService.Open(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return s.scanner.Scan(ctx, r)
+}
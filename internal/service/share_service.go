@@ -0,0 +1,274 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/lskeey/go-filehub/internal/models"
+	"github.com/lskeey/go-filehub/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrShareNotFound     = errors.New("share not found")
+	ErrShareForbidden    = errors.New("unauthorized: you cannot manage this share")
+	ErrInvalidShare      = errors.New("exactly one of file_id and folder_id must be set")
+	ErrInvalidPermission = errors.New("permission must be viewer or editor")
+	ErrShareWithSelf     = errors.New("you cannot share an item with yourself")
+	ErrUserNotFound      = errors.New("user not found")
+)
+
+// access is a user's effective right on a file or folder, from none to owner.
+type access int
+
+const (
+	accessNone access = iota
+	accessViewer
+	accessEditor
+	accessOwner
+)
+
+// permissionAccess maps a share permission to the access it grants.
+func permissionAccess(permission string) access {
+	switch permission {
+	case models.PermissionViewer:
+		return accessViewer
+	case models.PermissionEditor:
+		return accessEditor
+	}
+	return accessNone
+}
+
+// SharedItem is a share together with the item it grants access to.
+type SharedItem struct {
+	Share        *models.Share  `json:"share"`
+	OwnerEmail   string         `json:"owner_email,omitempty"`
+	GranteeEmail string         `json:"grantee_email,omitempty"`
+	File         *models.File   `json:"file,omitempty"`
+	Folder       *models.Folder `json:"folder,omitempty"`
+}
+
+// ShareService manages shares and decides what a user may do with an item.
+type ShareService struct {
+	shareRepo  *repository.ShareRepository
+	userRepo   *repository.UserRepository
+	fileRepo   *repository.FileRepository
+	folderRepo *repository.FolderRepository
+}
+
+// NewShareService creates a new share service.
+func NewShareService(shareRepo *repository.ShareRepository, userRepo *repository.UserRepository, fileRepo *repository.FileRepository, folderRepo *repository.FolderRepository) *ShareService {
+	return &ShareService{shareRepo: shareRepo, userRepo: userRepo, fileRepo: fileRepo, folderRepo: folderRepo}
+}
+
+// ShareItem grants the user with the given email access to a file or a
+// folder owned by ownerID. Sharing an item with the same user again changes
+// the permission of the existing share.
+func (s *ShareService) ShareItem(ownerID uint, fileID, folderID *uint, email, permission string) (*models.Share, error) {
+	if (fileID == nil) == (folderID == nil) {
+		return nil, ErrInvalidShare
+	}
+	if permissionAccess(permission) == accessNone {
+		return nil, ErrInvalidPermission
+	}
+	if err := s.checkItemOwner(ownerID, fileID, folderID); err != nil {
+		return nil, err
+	}
+
+	grantee, err := s.userRepo.FindUserByEmail(strings.TrimSpace(email))
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if grantee.ID == ownerID {
+		return nil, ErrShareWithSelf
+	}
+
+	if existing, err := s.shareRepo.FindShare(grantee.ID, fileID, folderID); err == nil {
+		existing.Permission = permission
+		return existing, s.shareRepo.UpdateShare(existing)
+	}
+
+	share := &models.Share{
+		OwnerID:    ownerID,
+		GranteeID:  grantee.ID,
+		FileID:     fileID,
+		FolderID:   folderID,
+		Permission: permission,
+	}
+	if err := s.shareRepo.CreateShare(share); err != nil {
+		if !errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, err
+		}
+		// Shared concurrently, update that share instead
+		existing, findErr := s.shareRepo.FindShare(grantee.ID, fileID, folderID)
+		if findErr != nil {
+			return nil, err
+		}
+		existing.Permission = permission
+		return existing, s.shareRepo.UpdateShare(existing)
+	}
+	return share, nil
+}
+
+// ListShares retrieves the shares of ownerID's items, optionally limited to
+// one file or folder.
+func (s *ShareService) ListShares(ownerID uint, fileID, folderID *uint) ([]SharedItem, error) {
+	if fileID != nil || folderID != nil {
+		if err := s.checkItemOwner(ownerID, fileID, folderID); err != nil {
+			return nil, err
+		}
+	}
+	shares, err := s.shareRepo.FindSharesByOwnerID(ownerID, fileID, folderID)
+	if err != nil {
+		return nil, err
+	}
+	return s.sharedItems(shares)
+}
+
+// ListSharedWithMe retrieves the files and folders shared with userID.
+// Items that are in the owner's trash are left out.
+func (s *ShareService) ListSharedWithMe(userID uint) ([]SharedItem, error) {
+	shares, err := s.shareRepo.FindSharesByGranteeID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.sharedItems(shares)
+}
+
+// RevokeShare deletes a share. The owner can revoke any share of their items;
+// the grantee can remove a share from their own list.
+func (s *ShareService) RevokeShare(shareID, userID uint) error {
+	share, err := s.shareRepo.FindShareByID(shareID)
+	if err != nil {
+		return ErrShareNotFound
+	}
+	if share.OwnerID != userID && share.GranteeID != userID {
+		return ErrShareForbidden
+	}
+	return s.shareRepo.DeleteShare(share.ID)
+}
+
+// checkItemOwner verifies that the file or folder exists and belongs to ownerID.
+func (s *ShareService) checkItemOwner(ownerID uint, fileID, folderID *uint) error {
+	if fileID != nil {
+		file, err := s.fileRepo.FindFileByID(*fileID)
+		if err != nil {
+			return ErrFileNotFound
+		}
+		if file.OwnerID != ownerID {
+			return ErrFileForbidden
+		}
+		return nil
+	}
+	folder, err := s.folderRepo.FindFolderByID(*folderID)
+	if err != nil {
+		return ErrFolderNotFound
+	}
+	if folder.OwnerID != ownerID {
+		return ErrFolderForbidden
+	}
+	return nil
+}
+
+// sharedItems loads the items and users referenced by shares. Shares of
+// trashed items are skipped.
+func (s *ShareService) sharedItems(shares []models.Share) ([]SharedItem, error) {
+	var fileIDs, folderIDs, userIDs []uint
+	for _, share := range shares {
+		if share.FileID != nil {
+			fileIDs = append(fileIDs, *share.FileID)
+		} else if share.FolderID != nil {
+			folderIDs = append(folderIDs, *share.FolderID)
+		}
+		userIDs = append(userIDs, share.OwnerID, share.GranteeID)
+	}
+
+	files, err := s.fileRepo.FindFilesByIDs(fileIDs)
+	if err != nil {
+		return nil, err
+	}
+	folders, err := s.folderRepo.FindFoldersByIDs(folderIDs)
+	if err != nil {
+		return nil, err
+	}
+	users, err := s.userRepo.FindUsersByIDs(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	filesByID := make(map[uint]*models.File, len(files))
+	for i := range files {
+		filesByID[files[i].ID] = &files[i]
+	}
+	foldersByID := make(map[uint]*models.Folder, len(folders))
+	for i := range folders {
+		foldersByID[folders[i].ID] = &folders[i]
+	}
+	emails := make(map[uint]string, len(users))
+	for _, user := range users {
+		emails[user.ID] = user.Email
+	}
+
+	items := make([]SharedItem, 0, len(shares))
+	for i := range shares {
+		item := SharedItem{
+			Share:        &shares[i],
+			OwnerEmail:   emails[shares[i].OwnerID],
+			GranteeEmail: emails[shares[i].GranteeID],
+		}
+		if shares[i].FileID != nil {
+			item.File = filesByID[*shares[i].FileID]
+		} else if shares[i].FolderID != nil {
+			item.Folder = foldersByID[*shares[i].FolderID]
+		}
+		if item.File == nil && item.Folder == nil {
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// fileAccess returns what userID may do with a file: the owner has full
+// access, anyone else the best permission shared with them on the file or
+// on one of the folders above it.
+func (s *ShareService) fileAccess(file *models.File, userID uint) (access, error) {
+	if file.OwnerID == userID {
+		return accessOwner, nil
+	}
+	var folderIDs []uint
+	if file.FolderID != nil {
+		ids, err := s.folderRepo.FindAncestorIDs(*file.FolderID)
+		if err != nil {
+			return accessNone, err
+		}
+		folderIDs = ids
+	}
+	return s.grantedAccess(userID, &file.ID, folderIDs)
+}
+
+// folderAccess returns what userID may do with a folder and everything inside it.
+func (s *ShareService) folderAccess(folder *models.Folder, userID uint) (access, error) {
+	if folder.OwnerID == userID {
+		return accessOwner, nil
+	}
+	folderIDs, err := s.folderRepo.FindAncestorIDs(folder.ID)
+	if err != nil {
+		return accessNone, err
+	}
+	return s.grantedAccess(userID, nil, folderIDs)
+}
+
+func (s *ShareService) grantedAccess(userID uint, fileID *uint, folderIDs []uint) (access, error) {
+	permissions, err := s.shareRepo.FindGrantedPermissions(userID, fileID, folderIDs)
+	if err != nil {
+		return accessNone, err
+	}
+	best := accessNone
+	for _, permission := range permissions {
+		if a := permissionAccess(permission); a > best {
+			best = a
+		}
+	}
+	return best, nil
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lskeey/go-filehub/internal/repository"
+)
+
+// DefaultSignedURLMaxTTL is used when no maximum validity is configured.
+const DefaultSignedURLMaxTTL = 24 * time.Hour
+
+var (
+	ErrSignedURLDisabled    = errors.New("signed URLs are not configured")
+	ErrSignedURLInvalid     = errors.New("invalid URL signature")
+	ErrSignedURLExpired     = errors.New("signed URL has expired")
+	ErrSignedURLUsed        = errors.New("signed URL has already been used")
+	ErrInvalidSignedURLTTL  = errors.New("expires_in must be positive and within the allowed maximum")
+	ErrInvalidDisposition   = errors.New("disposition must be attachment or inline")
+	ErrInvalidSignedRoute   = errors.New("route must be download or thumbnail")
+	errMalformedSigningKeys = errors.New("signing keys must be comma-separated key-id:secret pairs")
+)
+
+// Routes a signed URL can be minted for.
+const (
+	SignedRouteDownload  = "download"
+	SignedRouteThumbnail = "thumbnail"
+)
+
+// JobPurgeNonces is the job type that removes the nonces of expired URLs.
+const JobPurgeNonces = "signedurl.purge_nonces"
+
+// SignedDownload is what a valid pre-signed download URL grants: userID may
+// request Route of fileID until ExpiresAt.
+type SignedDownload struct {
+	FileID      uint
+	UserID      uint
+	Route       string // SignedRouteDownload or SignedRouteThumbnail
+	ExpiresAt   time.Time
+	Nonce       string // Set for single-use URLs
+	Disposition string // "attachment" or "inline"
+}
+
+type signingKey struct {
+	id     string
+	secret []byte
+}
+
+// SignedURLService mints and verifies HMAC-signed download URLs. The first
+// key signs new URLs; all keys are accepted, so keys can be rotated.
+type SignedURLService struct {
+	repo   *repository.SignedURLRepository
+	keys   []signingKey
+	maxTTL time.Duration
+}
+
+// NewSignedURLService creates a new signed URL service from keys given as
+// comma-separated key-id:secret pairs. Without keys, signed URLs are disabled.
+func NewSignedURLService(repo *repository.SignedURLRepository, keys string, maxTTL time.Duration) (*SignedURLService, error) {
+	if maxTTL <= 0 {
+		maxTTL = DefaultSignedURLMaxTTL
+	}
+	s := &SignedURLService{repo: repo, maxTTL: maxTTL}
+	for _, pair := range strings.Split(keys, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		id, secret, ok := strings.Cut(pair, ":")
+		if !ok || id == "" || secret == "" {
+			return nil, errMalformedSigningKeys
+		}
+		s.keys = append(s.keys, signingKey{id: id, secret: []byte(secret)})
+	}
+	return s, nil
+}
+
+// Sign returns the query parameters that let userID request route of fileID
+// without a token for the next ttl. A single-use URL only works once.
+func (s *SignedURLService) Sign(fileID, userID uint, route string, ttl time.Duration, singleUse bool, disposition string) (url.Values, time.Time, error) {
+	if len(s.keys) == 0 {
+		return nil, time.Time{}, ErrSignedURLDisabled
+	}
+	if ttl <= 0 || ttl > s.maxTTL {
+		return nil, time.Time{}, ErrInvalidSignedURLTTL
+	}
+	if disposition == "" {
+		disposition = "attachment"
+	}
+	if disposition != "attachment" && disposition != "inline" {
+		return nil, time.Time{}, ErrInvalidDisposition
+	}
+	if route != SignedRouteDownload && route != SignedRouteThumbnail {
+		return nil, time.Time{}, ErrInvalidSignedRoute
+	}
+
+	d := &SignedDownload{
+		FileID:      fileID,
+		UserID:      userID,
+		Route:       route,
+		ExpiresAt:   time.Now().Add(ttl).Truncate(time.Second),
+		Disposition: disposition,
+	}
+	if singleUse {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return nil, time.Time{}, err
+		}
+		d.Nonce = hex.EncodeToString(b)
+	}
+
+	key := s.keys[0]
+	query := url.Values{}
+	query.Set("uid", strconv.FormatUint(uint64(userID), 10))
+	query.Set("expires", strconv.FormatInt(d.ExpiresAt.Unix(), 10))
+	query.Set("disposition", disposition)
+	if d.Nonce != "" {
+		query.Set("nonce", d.Nonce)
+	}
+	query.Set("kid", key.id)
+	query.Set("sig", sign(key.secret, d))
+	return query, d.ExpiresAt, nil
+}
+
+// Verify checks the signature and expiry of a signed URL for route of fileID.
+// It does not consume single-use nonces; see Consume.
+func (s *SignedURLService) Verify(fileID uint, route string, query url.Values) (*SignedDownload, error) {
+	if len(s.keys) == 0 {
+		return nil, ErrSignedURLDisabled
+	}
+	userID, err := strconv.ParseUint(query.Get("uid"), 10, 32)
+	if err != nil {
+		return nil, ErrSignedURLInvalid
+	}
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil {
+		return nil, ErrSignedURLInvalid
+	}
+	d := &SignedDownload{
+		FileID:      fileID,
+		UserID:      uint(userID),
+		Route:       route,
+		ExpiresAt:   time.Unix(expires, 0),
+		Nonce:       query.Get("nonce"),
+		Disposition: query.Get("disposition"),
+	}
+
+	var key *signingKey
+	for i := range s.keys {
+		if s.keys[i].id == query.Get("kid") {
+			key = &s.keys[i]
+			break
+		}
+	}
+	if key == nil || !hmac.Equal([]byte(sign(key.secret, d)), []byte(query.Get("sig"))) {
+		return nil, ErrSignedURLInvalid
+	}
+	if !time.Now().Before(d.ExpiresAt) {
+		return nil, ErrSignedURLExpired
+	}
+	return d, nil
+}
+
+// Consume marks a single-use URL as used. It fails if it was used before.
+// URLs without a nonce can be used any number of times.
+func (s *SignedURLService) Consume(d *SignedDownload) error {
+	if d.Nonce == "" {
+		return nil
+	}
+	fresh, err := s.repo.UseNonce(d.Nonce, d.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrSignedURLUsed
+	}
+	return nil
+}
+
+// PurgeNoncesJob removes the nonces of single-use URLs that have expired;
+// they can't be used again anyway.
+func (s *SignedURLService) PurgeNoncesJob(ctx context.Context, _ struct{}) error {
+	return s.repo.DeleteExpiredNonces(time.Now())
+}
+
+// sign computes the signature over every field of a signed download.
+func sign(secret []byte, d *SignedDownload) string {
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "%d\n%d\n%s\n%d\n%s\n%s", d.FileID, d.UserID, d.Route, d.ExpiresAt.Unix(), d.Nonce, d.Disposition)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/lskeey/go-filehub/internal/models"
+	"github.com/lskeey/go-filehub/internal/repository"
+	"github.com/lskeey/go-filehub/internal/storage"
+	"github.com/lskeey/go-filehub/internal/thumbnail"
+	"gorm.io/gorm"
+)
+
+// JobGenerateThumbnails is the recurring job that renders thumbnails of new images.
+const JobGenerateThumbnails = "thumbnails.generate"
+
+// thumbnailBatchSize is the number of blobs the thumbnailer processes per query.
+const thumbnailBatchSize = 50
+
+var ErrInvalidThumbnailSize = errors.New("size must be small, medium or large")
+
+// ThumbnailImage is a thumbnail ready to be served.
+type ThumbnailImage struct {
+	Content     io.ReadSeekCloser
+	ContentType string
+	ETag        string
+	ModTime     time.Time
+	Placeholder bool // The file has no thumbnail; Content is a generic icon
+}
+
+// ThumbnailService renders and stores thumbnails of image files. Thumbnails
+// are keyed by content digest and stored next to the blobs they preview.
+type ThumbnailService struct {
+	thumbRepo   *repository.ThumbnailRepository
+	blobService *BlobService
+	scanService *ScanService
+	storage     storage.Storage
+}
+
+// NewThumbnailService creates a new thumbnail service.
+func NewThumbnailService(thumbRepo *repository.ThumbnailRepository, blobService *BlobService, scanService *ScanService, store storage.Storage) *ThumbnailService {
+	return &ThumbnailService{thumbRepo: thumbRepo, blobService: blobService, scanService: scanService, storage: store}
+}
+
+// Thumbnail returns the thumbnail of file in the named size. Thumbnails not
+// rendered by the background thumbnailer yet are rendered on demand. Files
+// that aren't supported images get a placeholder.
+func (s *ThumbnailService) Thumbnail(ctx context.Context, file *models.File, size string) (*ThumbnailImage, error) {
+	if size == "" {
+		size = thumbnail.DefaultSize
+	}
+	if _, ok := thumbnail.Sizes[size]; !ok {
+		return nil, ErrInvalidThumbnailSize
+	}
+	// A preview shows the content, so it is refused like a download
+	if err := s.scanService.CheckFile(file); err != nil {
+		return nil, err
+	}
+	if file.BlobDigest == "" || !thumbnail.Supported(file.MimeType, file.FileName) {
+		return placeholderImage(size), nil
+	}
+
+	thumb, err := s.thumbRepo.FindThumbnail(file.BlobDigest, size)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := s.generate(ctx, file); err != nil {
+			return nil, err
+		}
+		thumb, err = s.thumbRepo.FindThumbnail(file.BlobDigest, size)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if thumb.Status != models.ThumbnailReady {
+		return placeholderImage(size), nil
+	}
+
+	content, err := s.storage.Get(ctx, thumb.StorageKey)
+	if err != nil {
+		return nil, err
+	}
+	return &ThumbnailImage{
+		Content:     content,
+		ContentType: thumb.ContentType,
+		ETag:        fmt.Sprintf(`"%s-%s"`, thumb.BlobDigest, thumb.Size),
+		ModTime:     thumb.CreatedAt,
+	}, nil
+}
+
+// GeneratePending renders thumbnails for every image blob without any. It
+// returns the number of blobs processed.
+func (s *ThumbnailService) GeneratePending(ctx context.Context) (int, error) {
+	generated := 0
+	for {
+		files, err := s.thumbRepo.FindFilesToThumbnail(thumbnail.MimeTypes(), thumbnail.Extensions(), thumbnailBatchSize)
+		if err != nil || len(files) == 0 {
+			return generated, err
+		}
+		for i := range files {
+			if ctx.Err() != nil {
+				return generated, ctx.Err()
+			}
+			if err := s.generate(ctx, &files[i]); err != nil {
+				return generated, err
+			}
+			generated++
+		}
+	}
+}
+
+// DeleteOrphaned removes the thumbnails of blobs that no longer exist. It
+// returns the number of thumbnails removed.
+func (s *ThumbnailService) DeleteOrphaned(ctx context.Context) (int, error) {
+	deleted := 0
+	for {
+		thumbs, err := s.thumbRepo.FindOrphanedThumbnails(thumbnailBatchSize)
+		if err != nil || len(thumbs) == 0 {
+			return deleted, err
+		}
+		for i := range thumbs {
+			if thumbs[i].StorageKey != "" {
+				if err := s.storage.Delete(ctx, thumbs[i].StorageKey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+					return deleted, err
+				}
+			}
+			if err := s.thumbRepo.DeleteThumbnail(&thumbs[i]); err != nil {
+				return deleted, err
+			}
+			deleted++
+		}
+	}
+}
+
+// ThumbnailJob is the handler of JobGenerateThumbnails. It also removes the
+// thumbnails of deleted content.
+func (s *ThumbnailService) ThumbnailJob(ctx context.Context, _ struct{}) error {
+	generated, err := s.GeneratePending(ctx)
+	if generated > 0 {
+		log.Printf("Thumbnail generation processed %d images", generated)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = s.DeleteOrphaned(ctx)
+	return err
+}
+
+// generate renders and stores every size of thumbnail for a file's content.
+// Images that can't be decoded are recorded as failed, so they are served a
+// placeholder and not retried; only storage and database errors are returned.
+func (s *ThumbnailService) generate(ctx context.Context, file *models.File) error {
+	r, err := s.blobService.Open(ctx, file.S3Path)
+	if err != nil {
+		return err
+	}
+	images, err := thumbnail.Generate(r)
+	r.Close()
+	if err != nil {
+		log.Printf("Failed to render thumbnails of file %d: %v", file.ID, err)
+		thumbs := make([]models.Thumbnail, 0, len(thumbnail.Sizes))
+		for size := range thumbnail.Sizes {
+			thumbs = append(thumbs, models.Thumbnail{BlobDigest: file.BlobDigest, Size: size, Status: models.ThumbnailFailed})
+		}
+		return s.thumbRepo.SaveThumbnails(thumbs)
+	}
+
+	thumbs := make([]models.Thumbnail, 0, len(images))
+	for _, img := range images {
+		key := thumbnailKey(file.BlobDigest, img.Size)
+		if err := s.storage.Put(ctx, key, bytes.NewReader(img.Data), int64(len(img.Data)), img.ContentType); err != nil {
+			return err
+		}
+		thumbs = append(thumbs, models.Thumbnail{
+			BlobDigest:  file.BlobDigest,
+			Size:        img.Size,
+			Status:      models.ThumbnailReady,
+			StorageKey:  key,
+			ContentType: img.ContentType,
+			Width:       img.Width,
+			Height:      img.Height,
+			ByteSize:    int64(len(img.Data)),
+		})
+	}
+	return s.thumbRepo.SaveThumbnails(thumbs)
+}
+
+// thumbnailKey places thumbnails next to the blob they preview.
+func thumbnailKey(digest, size string) string {
+	return fmt.Sprintf("thumbnails/%s/%s/%s", digest[:2], digest, size)
+}
+
+func placeholderImage(size string) *ThumbnailImage {
+	return &ThumbnailImage{
+		Content:     nopCloser{bytes.NewReader(thumbnail.Placeholder(size))},
+		ContentType: "image/png",
+		ETag:        fmt.Sprintf(`"placeholder-%s"`, size),
+		Placeholder: true,
+	}
+}
+
+type nopCloser struct {
+	io.ReadSeeker
+}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/lskeey/go-filehub/internal/models"
+	"github.com/lskeey/go-filehub/internal/repository"
+	"gorm.io/gorm"
+)
+
+// DefaultTrashRetention is used when no retention period is configured.
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// JobPurgeTrash is the recurring job that purges expired trash.
+const JobPurgeTrash = "trash.purge"
+
+var ErrNotInTrash = errors.New("item not found in trash")
+
+// TrashService lists, restores and permanently deletes trashed (soft-deleted)
+// files and folders, and purges them once the retention period has passed.
+type TrashService struct {
+	fileRepo    *repository.FileRepository
+	folderRepo  *repository.FolderRepository
+	fileService *FileService
+	retention   time.Duration
+}
+
+// NewTrashService creates a new trash service.
+func NewTrashService(fileRepo *repository.FileRepository, folderRepo *repository.FolderRepository, fileService *FileService, retention time.Duration) *TrashService {
+	if retention <= 0 {
+		retention = DefaultTrashRetention
+	}
+	return &TrashService{fileRepo: fileRepo, folderRepo: folderRepo, fileService: fileService, retention: retention}
+}
+
+// ListTrash retrieves the top-level items in the user's trash. Items trashed
+// together with a folder are represented by that folder.
+func (s *TrashService) ListTrash(userID uint) ([]models.Folder, []models.File, error) {
+	folders, err := s.folderRepo.FindTrashedFolders(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	files, err := s.fileRepo.FindTrashedFiles(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return folders, files, nil
+}
+
+// RestoreFile takes a file out of the trash. If its folder no longer exists
+// the file is restored to the root.
+func (s *TrashService) RestoreFile(fileID, userID uint) (*models.File, error) {
+	file, err := s.getTrashedFile(fileID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.fileService.authorizeFolder(userID, file.FolderID, accessOwner); err != nil {
+		file.FolderID = nil
+	}
+	if err := s.fileService.checkNameFree(userID, file.FolderID, file.FileName, file.ID); err != nil {
+		return nil, err
+	}
+
+	if err := s.fileRepo.RestoreFiles([]uint{file.ID}); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrNameConflict
+		}
+		return nil, err
+	}
+	file.DeletedAt.Valid = false
+	if err := s.fileRepo.UpdateFile(file); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrNameConflict
+		}
+		return nil, err
+	}
+	return file, nil
+}
+
+// RestoreFolder takes a folder out of the trash together with everything that
+// was trashed with it. If its parent no longer exists the folder is restored
+// to the root.
+func (s *TrashService) RestoreFolder(folderID, userID uint) (*models.Folder, error) {
+	folder, err := s.getTrashedFolder(folderID, userID)
+	if err != nil {
+		return nil, err
+	}
+	at := folder.DeletedAt.Time
+
+	if _, err := s.fileService.authorizeFolder(userID, folder.ParentID, accessOwner); err != nil {
+		folder.ParentID = nil
+	}
+	if err := s.fileService.checkNameFree(userID, folder.ParentID, folder.Name, 0); err != nil {
+		return nil, err
+	}
+
+	ids, err := s.folderRepo.FindTrashedDescendantIDs(folder.ID, at)
+	if err != nil {
+		return nil, err
+	}
+	files, err := s.fileRepo.FindFilesTrashedWith(ids, at)
+	if err != nil {
+		return nil, err
+	}
+	fileIDs := make([]uint, len(files))
+	for i, file := range files {
+		fileIDs[i] = file.ID
+	}
+
+	folder.DeletedAt.Valid = false
+	err = s.folderRepo.DB.Transaction(func(tx *gorm.DB) error {
+		folderRepo := &repository.FolderRepository{DB: tx}
+		if err := (&repository.FileRepository{DB: tx}).RestoreFiles(fileIDs); err != nil {
+			return err
+		}
+		if err := folderRepo.RestoreFolders(ids); err != nil {
+			return err
+		}
+		return folderRepo.UpdateFolder(folder)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrNameConflict
+		}
+		return nil, err
+	}
+	return folder, nil
+}
+
+// DeleteFilePermanently removes a trashed file and releases its content.
+func (s *TrashService) DeleteFilePermanently(ctx context.Context, fileID, userID uint) error {
+	file, err := s.getTrashedFile(fileID, userID)
+	if err != nil {
+		return err
+	}
+	return s.fileService.purgeFile(ctx, file)
+}
+
+// DeleteFolderPermanently removes a trashed folder with everything that was
+// trashed with it.
+func (s *TrashService) DeleteFolderPermanently(ctx context.Context, folderID, userID uint) error {
+	folder, err := s.getTrashedFolder(folderID, userID)
+	if err != nil {
+		return err
+	}
+	return s.purgeFolder(ctx, folder)
+}
+
+// EmptyTrash permanently deletes everything in the user's trash.
+func (s *TrashService) EmptyTrash(ctx context.Context, userID uint) error {
+	_, err := s.purge(ctx, userID, time.Now())
+	return err
+}
+
+// Purge permanently deletes the items of all users that have been in the
+// trash for longer than the retention period. It returns the number of
+// files and folders removed.
+func (s *TrashService) Purge(ctx context.Context) (int, error) {
+	return s.purge(ctx, 0, time.Now().Add(-s.retention))
+}
+
+// PurgeJob is the handler of JobPurgeTrash.
+func (s *TrashService) PurgeJob(ctx context.Context, _ struct{}) error {
+	purged, err := s.Purge(ctx)
+	if purged > 0 {
+		log.Printf("Trash purge removed %d items", purged)
+	}
+	return err
+}
+
+// purge permanently deletes the trashed items of userID (0 for all users)
+// deleted before cutoff.
+func (s *TrashService) purge(ctx context.Context, userID uint, cutoff time.Time) (int, error) {
+	files, err := s.fileRepo.FindFilesTrashedBefore(userID, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	for i := range files {
+		if err := s.fileService.purgeFile(ctx, &files[i]); err != nil {
+			return 0, err
+		}
+	}
+
+	folders, err := s.folderRepo.FindFoldersTrashedBefore(userID, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	ids := make([]uint, len(folders))
+	for i, folder := range folders {
+		ids[i] = folder.ID
+	}
+	if err := s.folderRepo.PurgeFolders(ids); err != nil {
+		return 0, err
+	}
+
+	return len(files) + len(folders), nil
+}
+
+// purgeFolder permanently deletes a trashed folder, its subfolders and files
+// trashed together with it.
+func (s *TrashService) purgeFolder(ctx context.Context, folder *models.Folder) error {
+	at := folder.DeletedAt.Time
+	ids, err := s.folderRepo.FindTrashedDescendantIDs(folder.ID, at)
+	if err != nil {
+		return err
+	}
+	files, err := s.fileRepo.FindFilesTrashedWith(ids, at)
+	if err != nil {
+		return err
+	}
+	for i := range files {
+		if err := s.fileService.purgeFile(ctx, &files[i]); err != nil {
+			return err
+		}
+	}
+	return s.folderRepo.PurgeFolders(ids)
+}
+
+func (s *TrashService) getTrashedFile(fileID, userID uint) (*models.File, error) {
+	file, err := s.fileRepo.FindTrashedFileByID(fileID)
+	if err != nil {
+		return nil, ErrNotInTrash
+	}
+	if file.OwnerID != userID {
+		return nil, ErrFileForbidden
+	}
+	return file, nil
+}
+
+func (s *TrashService) getTrashedFolder(folderID, userID uint) (*models.Folder, error) {
+	folder, err := s.folderRepo.FindTrashedFolderByID(folderID)
+	if err != nil {
+		return nil, ErrNotInTrash
+	}
+	if folder.OwnerID != userID {
+		return nil, ErrFolderForbidden
+	}
+	return folder, nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/lskeey/go-filehub/internal/filetype"
+)
+
+var ErrFileTypeNotAllowed = errors.New("file type not allowed")
+
+// UploadPolicy restricts which files may be stored, by MIME type and by
+// extension. Deny lists take precedence; a non-empty allow list permits only
+// what it lists. Types may use wildcards such as "image/*".
+type UploadPolicy struct {
+	AllowedTypes      []string
+	DeniedTypes       []string
+	AllowedExtensions []string
+	DeniedExtensions  []string
+}
+
+// NewUploadPolicy creates a policy from comma-separated lists. Extensions
+// may be given with or without the leading dot.
+func NewUploadPolicy(allowedTypes, deniedTypes, allowedExtensions, deniedExtensions string) *UploadPolicy {
+	return &UploadPolicy{
+		AllowedTypes:      splitList(allowedTypes, ""),
+		DeniedTypes:       splitList(deniedTypes, ""),
+		AllowedExtensions: splitList(allowedExtensions, "."),
+		DeniedExtensions:  splitList(deniedExtensions, "."),
+	}
+}
+
+// CheckName checks the extension of a file name.
+func (p *UploadPolicy) CheckName(fileName string) error {
+	ext := filetype.Extension(fileName)
+	for _, denied := range p.DeniedExtensions {
+		if ext == denied {
+			return fmt.Errorf("%w: %s files are blocked", ErrFileTypeNotAllowed, ext)
+		}
+	}
+	if len(p.AllowedExtensions) > 0 && !slices.Contains(p.AllowedExtensions, ext) {
+		if ext == "" {
+			return fmt.Errorf("%w: files without an extension are not accepted", ErrFileTypeNotAllowed)
+		}
+		return fmt.Errorf("%w: %s files are not accepted", ErrFileTypeNotAllowed, ext)
+	}
+	return nil
+}
+
+// CheckType checks the type declared by the client and the type detected
+// from the content. Either one being denied rejects the file; the allow list
+// is matched against the detected type only, since the declared one can't
+// be trusted. detected is empty while the content is not known yet.
+func (p *UploadPolicy) CheckType(declared, detected string) error {
+	for _, t := range []string{detected, declared} {
+		if t == "" {
+			continue
+		}
+		for _, denied := range p.DeniedTypes {
+			if filetype.Match(denied, t) {
+				return fmt.Errorf("%w: %s content is blocked", ErrFileTypeNotAllowed, filetype.Base(t))
+			}
+		}
+	}
+	if len(p.AllowedTypes) == 0 || detected == "" {
+		return nil
+	}
+	for _, allowed := range p.AllowedTypes {
+		if filetype.Match(allowed, detected) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s content is not accepted", ErrFileTypeNotAllowed, detected)
+}
+
+// detectType sniffs the type of the content read from r, which must be read
+// from the returned reader afterwards, and checks it against the policy.
+func (p *UploadPolicy) detectType(fileName, declared string, r io.Reader) (string, io.Reader, error) {
+	if err := p.CheckName(fileName); err != nil {
+		return "", nil, err
+	}
+	br := bufio.NewReaderSize(r, filetype.SniffLen)
+	head, err := br.Peek(filetype.SniffLen)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", nil, err
+	}
+	detected := filetype.Detect(head, fileName)
+	if err := p.CheckType(declared, detected); err != nil {
+		return "", nil, err
+	}
+	return detected, br, nil
+}
+
+// splitList splits a comma-separated list into lower-cased entries, adding
+// prefix where it is missing.
+func splitList(list, prefix string) []string {
+	var entries []string
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if !strings.HasPrefix(entry, prefix) {
+			entry = prefix + entry
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
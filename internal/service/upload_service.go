@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/lskeey/go-filehub/internal/models"
+	"github.com/lskeey/go-filehub/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUploadNotFound       = errors.New("upload not found")
+	ErrUploadForbidden      = errors.New("unauthorized: you do not own this upload")
+	ErrUploadOffsetMismatch = errors.New("upload offset does not match the current offset")
+	ErrUploadExceedsLength  = errors.New("upload exceeds the declared length")
+	ErrUploadComplete       = errors.New("upload is already complete")
+)
+
+// DefaultUploadExpiry is how long a resumable upload is kept after its last
+// chunk arrived.
+const DefaultUploadExpiry = 7 * 24 * time.Hour
+
+// JobExpireUploads is the job type that removes stale resumable uploads.
+const JobExpireUploads = "upload.expire"
+
+// UploadService implements resumable uploads. Received bytes are appended to
+// a staging file in dir and the offset is persisted after every chunk, so an
+// interrupted upload can continue after a server restart.
+type UploadService struct {
+	uploadRepo  *repository.UploadRepository
+	fileService *FileService
+	dir         string
+	locks       sync.Map // upload ID -> *sync.Mutex
+}
+
+// NewUploadService creates a new upload service staging partial uploads in dir.
+func NewUploadService(repo *repository.UploadRepository, fileService *FileService, dir string) (*UploadService, error) {
+	if dir == "" {
+		dir = filepath.Join("tmp", "uploads")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &UploadService{uploadRepo: repo, fileService: fileService, dir: dir}, nil
+}
+
+// CreateUpload registers a new resumable upload of length bytes.
+func (s *UploadService) CreateUpload(ctx context.Context, userID uint, folderID *uint, fileName, mimeType string, length int64) (*models.Upload, error) {
+	if length > s.fileService.MaxUploadSize() {
+		return nil, ErrFileTooLarge
+	}
+	if err := validateName(fileName); err != nil {
+		return nil, err
+	}
+	// The content's type is checked once it has arrived; reject what is
+	// already known to be blocked before anything is uploaded
+	if err := s.fileService.policy.CheckName(fileName); err != nil {
+		return nil, err
+	}
+	if err := s.fileService.policy.CheckType(mimeType, ""); err != nil {
+		return nil, err
+	}
+	// The file will belong to the folder's owner, so their quota applies
+	ownerID, err := s.fileService.authorizeFolder(userID, folderID, accessEditor)
+	if err != nil {
+		return nil, err
+	}
+	if remaining, err := s.fileService.quotaService.Remaining(ownerID); err != nil {
+		return nil, err
+	} else if remaining >= 0 && length > remaining {
+		return nil, ErrQuotaExceeded
+	}
+
+	id, err := newUploadID()
+	if err != nil {
+		return nil, err
+	}
+
+	upload := &models.Upload{
+		ID:       id,
+		OwnerID:  userID,
+		FolderID: folderID,
+		FileName: fileName,
+		MimeType: mimeType,
+		Length:   length,
+	}
+	if err := s.uploadRepo.CreateUpload(upload); err != nil {
+		return nil, err
+	}
+
+	// An empty file is complete as soon as it is announced
+	if length == 0 {
+		if err := s.complete(ctx, upload); err != nil {
+			return nil, err
+		}
+	}
+
+	return upload, nil
+}
+
+// GetUpload retrieves an upload owned by userID.
+func (s *UploadService) GetUpload(id string, userID uint) (*models.Upload, error) {
+	upload, err := s.uploadRepo.FindUploadByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUploadNotFound
+		}
+		return nil, err
+	}
+	if upload.OwnerID != userID {
+		return nil, ErrUploadForbidden
+	}
+	return upload, nil
+}
+
+// WriteChunk appends the content of r at offset. Bytes received before a
+// read error are kept, so the client can resume from the returned offset.
+// Once all bytes have arrived the upload is turned into a models.File.
+func (s *UploadService) WriteChunk(ctx context.Context, id string, userID uint, offset int64, r io.Reader) (*models.Upload, error) {
+	unlock := s.lock(id)
+	defer unlock()
+
+	upload, err := s.GetUpload(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	// The staging file of a completed upload is gone; don't recreate it
+	if upload.FileID != nil {
+		return upload, ErrUploadComplete
+	}
+	if offset != upload.Offset {
+		return upload, ErrUploadOffsetMismatch
+	}
+
+	f, err := os.OpenFile(s.stagingPath(id), os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// Drop anything written after the last persisted offset (e.g. before a crash)
+	if err := f.Truncate(upload.Offset); err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(upload.Offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	remaining := upload.Length - upload.Offset
+	written, copyErr := io.Copy(f, io.LimitReader(r, remaining+1))
+	if written > remaining {
+		_ = f.Truncate(upload.Offset)
+		return upload, ErrUploadExceedsLength
+	}
+	if err := f.Sync(); err != nil {
+		return nil, err
+	}
+
+	upload.Offset += written
+	if err := s.uploadRepo.UpdateUpload(upload); err != nil {
+		return nil, err
+	}
+	if copyErr != nil {
+		return upload, copyErr
+	}
+
+	if upload.Offset == upload.Length {
+		if err := s.complete(ctx, upload); err != nil {
+			return upload, err
+		}
+		// Later requests only find the upload complete
+		s.locks.Delete(id)
+	}
+
+	return upload, nil
+}
+
+// MaxUploadSize returns the largest Upload-Length accepted by CreateUpload.
+func (s *UploadService) MaxUploadSize() int64 {
+	return s.fileService.MaxUploadSize()
+}
+
+// TerminateUpload discards an upload and its staged bytes.
+func (s *UploadService) TerminateUpload(id string, userID uint) error {
+	unlock := s.lock(id)
+	defer unlock()
+
+	if _, err := s.GetUpload(id, userID); err != nil {
+		return err
+	}
+	return s.discard(id)
+}
+
+// ExpireJob removes uploads whose last chunk arrived longer than
+// DefaultUploadExpiry ago, completed or not, with their staged bytes.
+func (s *UploadService) ExpireJob(ctx context.Context, _ struct{}) error {
+	cutoff := time.Now().Add(-DefaultUploadExpiry)
+	uploads, err := s.uploadRepo.FindUploadsUpdatedBefore(cutoff)
+	if err != nil {
+		return err
+	}
+	for _, upload := range uploads {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.expire(upload.ID, cutoff); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expire discards an upload unless a chunk arrived since cutoff.
+func (s *UploadService) expire(id string, cutoff time.Time) error {
+	unlock := s.lock(id)
+	defer unlock()
+
+	upload, err := s.uploadRepo.FindUploadByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if upload.UpdatedAt.After(cutoff) {
+		return nil
+	}
+	return s.discard(id)
+}
+
+// discard removes an upload's staged bytes and record. The caller holds
+// the upload's lock.
+func (s *UploadService) discard(id string) error {
+	if err := os.Remove(s.stagingPath(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := s.uploadRepo.DeleteUpload(id); err != nil {
+		return err
+	}
+	s.locks.Delete(id)
+	return nil
+}
+
+// complete moves the staged bytes to storage and creates the file record.
+func (s *UploadService) complete(ctx context.Context, upload *models.Upload) error {
+	path := s.stagingPath(upload.ID)
+	f, err := os.OpenFile(path, os.O_RDONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	file, err := s.fileService.UploadFile(ctx, upload.OwnerID, upload.FolderID, upload.FileName, upload.MimeType, f)
+	if err != nil {
+		return err
+	}
+
+	upload.FileID = &file.ID
+	if err := s.uploadRepo.UpdateUpload(upload); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func (s *UploadService) stagingPath(id string) string {
+	return filepath.Join(s.dir, id)
+}
+
+// lock serializes chunk writes to the same upload.
+func (s *UploadService) lock(id string) func() {
+	m, _ := s.locks.LoadOrStore(id, &sync.Mutex{})
+	mu := m.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+func newUploadID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,239 @@
+package sigv4
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxChunkHeader bounds the size of an aws-chunked chunk header line.
+const maxChunkHeader = 4096
+
+// emptyHash is the SHA-256 of no bytes.
+var emptyHash = hashHex(nil)
+
+// Body returns the payload of r, checked against the signed payload hash
+// and Content-MD5. aws-chunked bodies are decoded, and the signature of
+// each chunk is verified. A mismatch surfaces as ErrPayloadMismatch from
+// Read, at the latest at the end of the payload, and from Err afterwards.
+func (a *Auth) Body(r *http.Request) (*Payload, error) {
+	p := &Payload{}
+	var body io.Reader = r.Body
+	switch a.PayloadHash {
+	case UnsignedPayload:
+	case StreamingPayload, StreamingPayloadTrailer:
+		body = &chunkedReader{r: bufio.NewReader(r.Body), auth: a, signed: true, prevSignature: a.signature,
+			trailer: a.PayloadHash == StreamingPayloadTrailer}
+	case StreamingUnsignedTrailer:
+		body = &chunkedReader{r: bufio.NewReader(r.Body), trailer: true}
+	default:
+		want, err := hex.DecodeString(a.PayloadHash)
+		if err != nil || len(want) != sha256.Size {
+			return nil, ErrMalformedAuth
+		}
+		p.checks = append(p.checks, check{hash: sha256.New(), want: want})
+	}
+	if v := r.Header.Get("Content-MD5"); v != "" {
+		want, err := base64.StdEncoding.DecodeString(v)
+		if err != nil || len(want) != md5.Size {
+			return nil, ErrPayloadMismatch
+		}
+		p.checks = append(p.checks, check{hash: md5.New(), want: want})
+	}
+	p.r = body
+	return p, nil
+}
+
+// Payload is a request body being verified while it is read.
+type Payload struct {
+	r      io.Reader
+	checks []check
+	err    error
+}
+
+type check struct {
+	hash hash.Hash
+	want []byte
+}
+
+func (p *Payload) Read(b []byte) (int, error) {
+	if p.err != nil {
+		return 0, p.err
+	}
+	n, err := p.r.Read(b)
+	for _, c := range p.checks {
+		c.hash.Write(b[:n])
+	}
+	if err == io.EOF {
+		for _, c := range p.checks {
+			if !bytes.Equal(c.hash.Sum(nil), c.want) {
+				err = ErrPayloadMismatch
+			}
+		}
+	}
+	if err != nil && err != io.EOF {
+		p.err = err
+	}
+	return n, err
+}
+
+// Err returns the error that stopped reading the payload, such as
+// ErrPayloadMismatch, or nil.
+func (p *Payload) Err() error {
+	return p.err
+}
+
+// chunkedReader decodes an aws-chunked body:
+//
+//	<hex size>[;chunk-signature=<signature>]\r\n<data>\r\n ... 0[;...]\r\n[trailers]\r\n
+type chunkedReader struct {
+	r       *bufio.Reader
+	auth    *Auth
+	signed  bool
+	trailer bool
+
+	prevSignature string
+	signature     string    // Signature of the current chunk
+	hash          hash.Hash // Of the current chunk's data
+	remaining     int64     // Bytes left in the current chunk
+	inChunk       bool
+	done          bool
+}
+
+func (c *chunkedReader) Read(b []byte) (int, error) {
+	for !c.done && c.remaining == 0 {
+		if c.inChunk {
+			if err := c.endChunk(); err != nil {
+				return 0, err
+			}
+		}
+		if err := c.startChunk(); err != nil {
+			return 0, err
+		}
+	}
+	if c.done {
+		return 0, io.EOF
+	}
+
+	if int64(len(b)) > c.remaining {
+		b = b[:c.remaining]
+	}
+	n, err := c.r.Read(b)
+	c.remaining -= int64(n)
+	if c.hash != nil {
+		c.hash.Write(b[:n])
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (c *chunkedReader) startChunk() error {
+	line, err := c.readLine()
+	if err != nil {
+		return err
+	}
+	sizeField, ext, _ := strings.Cut(line, ";")
+	size, err := strconv.ParseInt(strings.TrimSpace(sizeField), 16, 64)
+	if err != nil || size < 0 {
+		return ErrPayloadMismatch
+	}
+	if c.signed {
+		signature, ok := strings.CutPrefix(ext, "chunk-signature=")
+		if !ok {
+			return ErrPayloadMismatch
+		}
+		c.signature = signature
+		c.hash = sha256.New()
+	}
+	c.remaining, c.inChunk = size, true
+
+	if size == 0 {
+		// The final chunk is signed as well, over no data
+		if err := c.verifyChunk(); err != nil {
+			return err
+		}
+		if err := c.skipTrailers(); err != nil {
+			return err
+		}
+		c.done = true
+	}
+	return nil
+}
+
+func (c *chunkedReader) endChunk() error {
+	if err := c.verifyChunk(); err != nil {
+		return err
+	}
+	c.inChunk = false
+	line, err := c.readLine()
+	if err != nil {
+		return err
+	}
+	if line != "" {
+		return ErrPayloadMismatch
+	}
+	return nil
+}
+
+func (c *chunkedReader) verifyChunk() error {
+	if !c.signed {
+		return nil
+	}
+	stringToSign := strings.Join([]string{
+		algorithm + "-PAYLOAD",
+		c.auth.amzDate,
+		c.auth.scope,
+		c.prevSignature,
+		emptyHash,
+		hex.EncodeToString(c.hash.Sum(nil)),
+	}, "\n")
+	expected := hex.EncodeToString(hmacSHA256(c.auth.signingKey, stringToSign))
+	if !hmac.Equal([]byte(expected), []byte(c.signature)) {
+		return ErrPayloadMismatch
+	}
+	c.prevSignature = c.signature
+	return nil
+}
+
+// skipTrailers reads the trailing headers after the final chunk, such as
+// checksums. They are not verified.
+func (c *chunkedReader) skipTrailers() error {
+	for {
+		line, err := c.readLine()
+		if err == io.ErrUnexpectedEOF && !c.trailer {
+			// Some clients end the body right after the final chunk
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if line == "" {
+			return nil
+		}
+	}
+}
+
+func (c *chunkedReader) readLine() (string, error) {
+	line, err := c.r.ReadSlice('\n')
+	if err == bufio.ErrBufferFull || len(line) > maxChunkHeader {
+		return "", ErrPayloadMismatch
+	}
+	if err == io.EOF {
+		return "", io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(line), "\r\n"), nil
+}
@@ -0,0 +1,290 @@
+// Package sigv4 verifies AWS Signature Version 4 signed S3 requests, both
+// with an Authorization header and as pre-signed URLs.
+package sigv4
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	algorithm = "AWS4-HMAC-SHA256"
+	service   = "s3"
+	terminal  = "aws4_request"
+
+	timeFormat = "20060102T150405Z"
+	dateFormat = "20060102"
+
+	// MaxSkew is how far a request's time may be from the server's.
+	MaxSkew = 15 * time.Minute
+	// MaxPresignExpiry is the longest validity of a pre-signed URL.
+	MaxPresignExpiry = 7 * 24 * time.Hour
+)
+
+// Payload hashes with a special meaning in x-amz-content-sha256.
+const (
+	UnsignedPayload          = "UNSIGNED-PAYLOAD"
+	StreamingPayload         = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	StreamingPayloadTrailer  = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER"
+	StreamingUnsignedTrailer = "STREAMING-UNSIGNED-PAYLOAD-TRAILER"
+)
+
+var (
+	ErrMissingAuth       = errors.New("request is not signed")
+	ErrMalformedAuth     = errors.New("authorization is malformed")
+	ErrSignatureMismatch = errors.New("signature does not match")
+	ErrRequestExpired    = errors.New("request time is too far from the server time, or the URL has expired")
+	ErrPayloadMismatch   = errors.New("payload does not match its signed hash or Content-MD5")
+	ErrUnsupported       = errors.New("unsupported signing method")
+)
+
+// Auth is a verified request signature.
+type Auth struct {
+	AccessKeyID string
+	PayloadHash string // Value of x-amz-content-sha256, UNSIGNED-PAYLOAD for pre-signed URLs
+
+	signingKey []byte
+	signature  string // Seed signature for streaming payloads
+	amzDate    string
+	scope      string
+}
+
+// Verify checks the signature of r. secret returns the secret key of an
+// access key ID; its errors are returned as they are, so callers can tell
+// unknown keys apart. The payload is checked while reading Body.
+func Verify(r *http.Request, now time.Time, secret func(accessKeyID string) (string, error)) (*Auth, error) {
+	if strings.HasPrefix(r.Header.Get("Authorization"), algorithm+" ") {
+		return verifyHeader(r, now, secret)
+	}
+	if r.URL.Query().Get("X-Amz-Algorithm") != "" {
+		return verifyQuery(r, now, secret)
+	}
+	if r.Header.Get("Authorization") != "" {
+		return nil, ErrUnsupported
+	}
+	return nil, ErrMissingAuth
+}
+
+func verifyHeader(r *http.Request, now time.Time, secret func(string) (string, error)) (*Auth, error) {
+	fields := make(map[string]string)
+	for _, field := range strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), algorithm+" "), ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok {
+			return nil, ErrMalformedAuth
+		}
+		fields[key] = value
+	}
+	credential, signedHeaders, signature := fields["Credential"], fields["SignedHeaders"], fields["Signature"]
+	if credential == "" || signedHeaders == "" || signature == "" {
+		return nil, ErrMalformedAuth
+	}
+
+	amzDate := r.Header.Get("X-Amz-Date")
+	if amzDate == "" {
+		amzDate = r.Header.Get("Date")
+	}
+	t, err := parseTime(amzDate)
+	if err != nil {
+		return nil, err
+	}
+	if d := now.Sub(t); d > MaxSkew || d < -MaxSkew {
+		return nil, ErrRequestExpired
+	}
+
+	payloadHash := r.Header.Get("X-Amz-Content-Sha256")
+	if payloadHash == "" {
+		// Only S3 requires the header; the payload is then not covered
+		payloadHash = UnsignedPayload
+	}
+	return verify(r, credential, signedHeaders, signature, t, payloadHash, nil, secret)
+}
+
+func verifyQuery(r *http.Request, now time.Time, secret func(string) (string, error)) (*Auth, error) {
+	q := r.URL.Query()
+	if q.Get("X-Amz-Algorithm") != algorithm {
+		return nil, ErrUnsupported
+	}
+	credential, signedHeaders, signature := q.Get("X-Amz-Credential"), q.Get("X-Amz-SignedHeaders"), q.Get("X-Amz-Signature")
+	if credential == "" || signedHeaders == "" || signature == "" {
+		return nil, ErrMalformedAuth
+	}
+	t, err := parseTime(q.Get("X-Amz-Date"))
+	if err != nil {
+		return nil, err
+	}
+	expires, err := strconv.Atoi(q.Get("X-Amz-Expires"))
+	if err != nil || expires < 0 || time.Duration(expires)*time.Second > MaxPresignExpiry {
+		return nil, ErrMalformedAuth
+	}
+	if t.Sub(now) > MaxSkew || now.After(t.Add(time.Duration(expires)*time.Second)) {
+		return nil, ErrRequestExpired
+	}
+	return verify(r, credential, signedHeaders, signature, t, UnsignedPayload, []string{"X-Amz-Signature"}, secret)
+}
+
+func verify(r *http.Request, credential, signedHeaders, signature string, t time.Time, payloadHash string, excludeQuery []string, secret func(string) (string, error)) (*Auth, error) {
+	// <key>/<date>/<region>/s3/aws4_request
+	parts := strings.Split(credential, "/")
+	if len(parts) != 5 || parts[1] != t.Format(dateFormat) || parts[3] != service || parts[4] != terminal {
+		return nil, ErrMalformedAuth
+	}
+	key, err := secret(parts[0])
+	if err != nil {
+		return nil, err
+	}
+
+	headers := strings.Split(signedHeaders, ";")
+	if !slices.Contains(headers, "host") {
+		return nil, ErrMalformedAuth
+	}
+	canonical := strings.Join([]string{
+		r.Method,
+		canonicalURI(r),
+		canonicalQuery(r.URL.RawQuery, excludeQuery),
+		canonicalHeaders(r, headers),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	amzDate := t.Format(timeFormat)
+	scope := strings.Join(parts[1:], "/")
+	stringToSign := strings.Join([]string{algorithm, amzDate, scope, hashHex([]byte(canonical))}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+key), parts[1])
+	for _, part := range parts[2:] {
+		signingKey = hmacSHA256(signingKey, part)
+	}
+	expected := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return nil, ErrSignatureMismatch
+	}
+
+	return &Auth{
+		AccessKeyID: parts[0],
+		PayloadHash: payloadHash,
+		signingKey:  signingKey,
+		signature:   signature,
+		amzDate:     amzDate,
+		scope:       scope,
+	}, nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(timeFormat, value); err == nil {
+		return t, nil
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return t.UTC().Truncate(time.Second), nil
+	}
+	return time.Time{}, ErrMalformedAuth
+}
+
+func canonicalURI(r *http.Request) string {
+	if r.URL.Path == "" {
+		return "/"
+	}
+	return Escape(r.URL.Path, true)
+}
+
+// canonicalQuery sorts the query parameters and encodes them the way
+// AWS does. Parameters without a value, like "?uploads", get an empty one.
+func canonicalQuery(rawQuery string, exclude []string) string {
+	var params []string
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(pair, "=")
+		key, value = unescape(key), unescape(value)
+		if slices.Contains(exclude, key) {
+			continue
+		}
+		params = append(params, Escape(key, false)+"="+Escape(value, false))
+	}
+	slices.Sort(params)
+	return strings.Join(params, "&")
+}
+
+func canonicalHeaders(r *http.Request, names []string) string {
+	var b strings.Builder
+	for _, name := range names {
+		var value string
+		switch name {
+		case "host":
+			value = r.Host
+		case "content-length":
+			// net/http moves the header into the request
+			value = strconv.FormatInt(r.ContentLength, 10)
+			if v := r.Header.Get("Content-Length"); v != "" {
+				value = v
+			}
+		case "transfer-encoding":
+			value = strings.Join(r.TransferEncoding, ",")
+		default:
+			values := r.Header.Values(name)
+			for i, v := range values {
+				values[i] = strings.Join(strings.Fields(v), " ")
+			}
+			value = strings.Join(values, ",")
+		}
+		fmt.Fprintf(&b, "%s:%s\n", name, value)
+	}
+	return b.String()
+}
+
+// Escape percent-encodes s like AWS does: everything except unreserved
+// characters, and except '/' if keepSlash is set.
+func Escape(s string, keepSlash bool) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (keepSlash && c == '/') {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hexDigits[c>>4])
+		b.WriteByte(hexDigits[c&15])
+	}
+	return b.String()
+}
+
+// unescape decodes percent-encoding, leaving invalid sequences as they are.
+func unescape(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				b = append(b, byte(v))
+				i += 2
+				continue
+			}
+		}
+		b = append(b, s[i])
+	}
+	return string(b)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
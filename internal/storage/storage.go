@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/lskeey/go-filehub/config"
+)
+
+// ErrNotFound is returned when an object does not exist in the backend.
+var ErrNotFound = errors.New("storage: object not found")
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+}
+
+// Storage is the interface implemented by every blob storage backend.
+type Storage interface {
+	// Put writes the content of r under key. size may be -1 if unknown.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the object stored under key for reading. The returned reader
+	// is seekable so callers can serve byte ranges.
+	Get(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	// Stat returns the metadata of the object stored under key.
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// Move renames the object stored under srcKey to dstKey, replacing any existing object.
+	Move(ctx context.Context, srcKey, dstKey string) error
+	// Delete removes the object stored under key.
+	Delete(ctx context.Context, key string) error
+	// List returns all objects whose key starts with prefix.
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+}
+
+// New creates the storage backend selected by cfg.StorageDriver.
+func New(cfg config.Config) (Storage, error) {
+	switch cfg.StorageDriver {
+	case "", "local":
+		return NewLocalStorage(cfg.StorageLocalPath)
+	case "s3":
+		return NewS3Storage(S3Options{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			UseSSL:    cfg.S3UseSSL,
+		})
+	default:
+		return nil, fmt.Errorf("storage: unknown driver %q", cfg.StorageDriver)
+	}
+}
@@ -0,0 +1,55 @@
+package thumbnail
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"sync"
+)
+
+var (
+	placeholderMu    sync.Mutex
+	placeholderCache = map[string][]byte{}
+
+	placeholderBackground = color.RGBA{0xec, 0xef, 0xf1, 0xff}
+	placeholderPage       = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	placeholderLine       = color.RGBA{0xb0, 0xbe, 0xc5, 0xff}
+)
+
+// Placeholder returns a PNG showing a generic document icon, served for files
+// no thumbnail can be rendered for. Placeholders are rendered once per size.
+func Placeholder(size string) []byte {
+	placeholderMu.Lock()
+	defer placeholderMu.Unlock()
+
+	if _, ok := Sizes[size]; !ok {
+		size = DefaultSize
+	}
+	if data, ok := placeholderCache[size]; ok {
+		return data
+	}
+	box := Sizes[size]
+
+	img := image.NewRGBA(image.Rect(0, 0, box, box))
+	draw.Draw(img, img.Bounds(), image.NewUniform(placeholderBackground), image.Point{}, draw.Src)
+
+	// A page with an outline and a few lines of "text"
+	unit := box / 16
+	page := image.Rect(4*unit, 2*unit, 12*unit, 14*unit)
+	draw.Draw(img, page, image.NewUniform(placeholderLine), image.Point{}, draw.Src)
+	draw.Draw(img, page.Inset(max(1, unit/4)), image.NewUniform(placeholderPage), image.Point{}, draw.Src)
+	for y := 5 * unit; y < 12*unit; y += 2 * unit {
+		line := image.Rect(6*unit, y, 10*unit, y+max(1, unit/2))
+		draw.Draw(img, line, image.NewUniform(placeholderLine), image.Point{}, draw.Src)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		// Encoding an in-memory RGBA image can't fail
+		panic(err)
+	}
+	placeholderCache[size] = buf.Bytes()
+	return placeholderCache[size]
+}
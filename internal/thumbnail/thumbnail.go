@@ -0,0 +1,179 @@
+// Package thumbnail renders scaled-down previews of uploaded images.
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // Registers the GIF decoder with image.Decode
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // Registers the WebP decoder with image.Decode
+)
+
+// MaxPixels caps the dimensions of images thumbnails are rendered from, so a
+// small file declaring a huge canvas can't exhaust memory while decoding.
+const MaxPixels = 50_000_000
+
+// Sizes are the bounding boxes thumbnails are rendered for, by name.
+// Thumbnails keep the aspect ratio of the image and are never upscaled.
+var Sizes = map[string]int{
+	"small":  128,
+	"medium": 256,
+	"large":  512,
+}
+
+// DefaultSize is used when no size is requested.
+const DefaultSize = "medium"
+
+var (
+	ErrUnsupported = errors.New("unsupported image type")
+	ErrTooLarge    = errors.New("image dimensions too large for a thumbnail")
+)
+
+var supportedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+var extensionTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+}
+
+// Image is an encoded thumbnail.
+type Image struct {
+	Size        string // Name of the size, see Sizes
+	Width       int
+	Height      int
+	ContentType string
+	Data        []byte
+}
+
+// Supported reports whether thumbnails can be rendered for an image with the
+// given MIME type or file name.
+func Supported(mimeType, fileName string) bool {
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	if supportedTypes[strings.TrimSpace(strings.ToLower(mimeType))] {
+		return true
+	}
+	return supportedTypes[extensionTypes[strings.ToLower(path.Ext(fileName))]]
+}
+
+// MimeTypes returns the MIME types thumbnails can be rendered for.
+func MimeTypes() []string {
+	types := make([]string, 0, len(supportedTypes))
+	for t := range supportedTypes {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Extensions returns the file extensions thumbnails can be rendered for.
+func Extensions() []string {
+	exts := make([]string, 0, len(extensionTypes))
+	for ext := range extensionTypes {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return exts
+}
+
+// Generate decodes an image once and renders a thumbnail for every size in
+// Sizes. For animated GIFs the first frame is used. Images with transparency
+// are encoded as PNG, all others as JPEG.
+func Generate(r io.ReadSeeker) (images []Image, err error) {
+	config, _, err := image.DecodeConfig(r)
+	if err != nil {
+		if errors.Is(err, image.ErrFormat) {
+			return nil, ErrUnsupported
+		}
+		return nil, err
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, ErrUnsupported
+	}
+	if int64(config.Width)*int64(config.Height) > MaxPixels {
+		return nil, ErrTooLarge
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	// Decoders of untrusted images may panic on malformed input
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("decoding image: %v", p)
+		}
+	}()
+
+	src, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	opaque := isOpaque(src)
+	for name, box := range Sizes {
+		img := Image{Size: name}
+		img.Width, img.Height = fit(src.Bounds().Dx(), src.Bounds().Dy(), box)
+
+		dst := image.NewRGBA(image.Rect(0, 0, img.Width, img.Height))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+		var buf bytes.Buffer
+		if opaque {
+			img.ContentType = "image/jpeg"
+			err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+		} else {
+			img.ContentType = "image/png"
+			err = png.Encode(&buf, dst)
+		}
+		if err != nil {
+			return nil, err
+		}
+		img.Data = buf.Bytes()
+		images = append(images, img)
+	}
+	return images, nil
+}
+
+// fit scales width x height down to fit into a box x box square.
+func fit(width, height, box int) (int, int) {
+	if width <= box && height <= box {
+		return width, height
+	}
+	if width >= height {
+		return box, max(1, height*box/width)
+	}
+	return max(1, width*box/height), box
+}
+
+// isOpaque reports whether an image has no transparent pixels. Paletted
+// images are only checked for a transparent palette entry.
+func isOpaque(img image.Image) bool {
+	if p, ok := img.(*image.Paletted); ok {
+		for _, c := range p.Palette {
+			if _, _, _, a := c.RGBA(); a != 0xffff {
+				return false
+			}
+		}
+		return true
+	}
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
@@ -1,11 +1,17 @@
 package utils
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// ErrInvalidClaims is returned by ParseJWT for a validly signed token that
+// does not identify a user.
+var ErrInvalidClaims = errors.New("invalid token claims")
+
 // GenerateJWT creates a new JWT for a given user ID.
 func GenerateJWT(userID uint, secretKey string, expirationHours int) (string, error) {
 	// Create the claims
@@ -23,3 +29,28 @@ func GenerateJWT(userID uint, secretKey string, expirationHours int) (string, er
 
 	return tokenString, err
 }
+
+// ParseJWT validates a token created by GenerateJWT and returns the user ID
+// it was issued for.
+func ParseJWT(tokenString, secretKey string) (uint, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		// Validate the alg is what you expect:
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return []byte(secretKey), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return 0, ErrInvalidClaims
+	}
+	userID, ok := claims["user_id"].(float64)
+	if !ok || userID <= 0 {
+		return 0, ErrInvalidClaims
+	}
+	return uint(userID), nil
+}